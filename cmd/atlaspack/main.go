@@ -0,0 +1,184 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Packs PNG images into sprite sheets which loaders.TexturePackerLoader can
+// read.
+//
+//	atlaspack -o out/sprites.json -trim -rotate art/characters art/logo.png
+//
+// Images in a directory are keyed by their path relative to it, without the
+// extension. Other images are keyed by their file name without the extension.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/pikkpoiss/gamejam/v1/base/core"
+	"github.com/pikkpoiss/gamejam/v1/base/loaders"
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	output    = flag.String("o", "atlas.json", "Output JSON path; images are written alongside it")
+	maxWidth  = flag.Int("max-width", 2048, "Maximum page width")
+	maxHeight = flag.Int("max-height", 2048, "Maximum page height")
+	padding   = flag.Int("padding", 2, "Empty pixels between images")
+	extrude   = flag.Int("extrude", 0, "Pixels of repeated edge around each image")
+	trim      = flag.Bool("trim", false, "Crop transparent borders from images")
+	rotate    = flag.Bool("rotate", false, "Allow images to be rotated 90 degrees")
+	algorithm = flag.String("algorithm", "maxrects", "Packing algorithm: shelf, skyline or maxrects")
+	compare   = flag.Bool("compare", false, "Print statistics for every algorithm")
+)
+
+// Returns the file in dir which ReadImages keyed as key.
+func imagePath(dir, key string) string {
+	var (
+		base       = filepath.Join(dir, filepath.FromSlash(key))
+		matches, _ = filepath.Glob(base + ".*")
+	)
+	for _, m := range matches {
+		if strings.ToLower(filepath.Ext(m)) == ".png" {
+			return m
+		}
+	}
+	return base + ".png"
+}
+
+func readInputs(paths []string) (images []sprites.PackImage, err error) {
+	var (
+		loader  = loaders.NewDirectoryLoader()
+		info    os.FileInfo
+		found   []sprites.PackImage
+		img     image.Image
+		key     string
+		sources = map[string]string{}
+	)
+	var add = func(image sprites.PackImage, source string) error {
+		if other, exists := sources[image.Key]; exists {
+			return fmt.Errorf("Images %v and %v both have the key %q", other, source, image.Key)
+		}
+		sources[image.Key] = source
+		images = append(images, image)
+		return nil
+	}
+	for _, p := range paths {
+		if info, err = os.Stat(p); err != nil {
+			return
+		}
+		if info.IsDir() {
+			if found, err = loader.ReadImages(os.DirFS(p), "."); err != nil {
+				return
+			}
+			for _, image := range found {
+				if err = add(image, imagePath(p, image.Key)); err != nil {
+					return
+				}
+			}
+			continue
+		}
+		if img, err = core.LoadPNG(p); err != nil {
+			err = fmt.Errorf("%v: %v", p, err)
+			return
+		}
+		key = filepath.Base(p)
+		key = strings.TrimSuffix(key, filepath.Ext(key))
+		if err = add(sprites.PackImage{Key: key, Image: img}, p); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func packingConfig(strategy sprites.PackingStrategy) sprites.PackingConfig {
+	var width, height = 256, 256
+	if *maxWidth < width {
+		width = *maxWidth
+	}
+	if *maxHeight < height {
+		height = *maxHeight
+	}
+	return sprites.PackingConfig{
+		Width:         width,
+		Height:        height,
+		MaxWidth:      *maxWidth,
+		MaxHeight:     *maxHeight,
+		Strategy:      strategy,
+		AllowRotation: *rotate,
+		Padding:       *padding,
+		Extrude:       *extrude,
+		Trim:          *trim,
+	}
+}
+
+func printStats(pages []*sprites.PackedSheet) {
+	for i, page := range pages {
+		fmt.Printf("page %v: %vx%v %v\n", i, page.Width, page.Height, page.Stats())
+	}
+}
+
+func run() (err error) {
+	var (
+		images   []sprites.PackImage
+		strategy sprites.PackingStrategy
+		pages    []*sprites.PackedSheet
+	)
+	if flag.NArg() == 0 {
+		err = fmt.Errorf("No input images or directories")
+		return
+	}
+	if strategy, err = sprites.ParsePackingStrategy(*algorithm); err != nil {
+		return
+	}
+	if images, err = readInputs(flag.Args()); err != nil {
+		return
+	}
+	if len(images) == 0 {
+		err = fmt.Errorf("No PNG images found")
+		return
+	}
+	if *compare {
+		for _, s := range sprites.PackingStrategies {
+			if pages, err = sprites.PackPages(images, packingConfig(s)); err != nil {
+				return
+			}
+			printStats(pages)
+		}
+	}
+	if pages, err = sprites.PackPages(images, packingConfig(strategy)); err != nil {
+		return
+	}
+	if !*compare {
+		printStats(pages)
+	}
+	if err = os.MkdirAll(filepath.Dir(*output), 0755); err != nil {
+		return
+	}
+	return loaders.NewTexturePackerWriter().Write(*output, pages)
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %v [flags] image.png|directory ...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "atlaspack: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -70,8 +70,13 @@ func main() {
 		textMapping     *loaders.TextMapping
 		batchData       *render.Geometry
 		renderer        *render.Renderer
+		sdfRenderer     *render.SDFRenderer
+		sdfInstances    *text.GlyphInstanceList
 		spriteInstances *sprites.SpriteInstanceList
 		textInstances   *text.TextInstanceList
+		glyphInstances  *text.GlyphInstanceList
+		spriteText      *text.GlyphInstanceList
+		label           *text.Label
 		batchInstances  *render.InstanceList
 		square          *render.Geometry
 	)
@@ -84,6 +89,10 @@ func main() {
 	if renderer, err = render.NewRenderer(100); err != nil {
 		panic(err)
 	}
+	if sdfRenderer, err = render.NewSDFRenderer(100); err != nil {
+		panic(err)
+	}
+	sdfRenderer.SetOutline(0.5, mgl32.Vec4{0.8, 0.2, 0.2, 1})
 
 	if sheet, err = loaders.NewTexturePackerLoader().Load(
 		"examples/resources/spritesheet.json",
@@ -139,6 +148,36 @@ func main() {
 		inst.SetPosition(mgl32.Vec3{s.X, s.Y, 0})
 		inst.SetRotation(s.R)
 	}
+	glyphInstances = text.NewGlyphInstanceList(text.Config{
+		TextureWidth:  512,
+		TextureHeight: 512,
+		PixelsPerUnit: PixelsPerUnit,
+	}, font)
+	if label, err = glyphInstances.NewLabel(
+		"Glyphs are cached once and laid out per quad",
+		text.LayoutConfig{MaxWidth: 200, Align: text.AlignCenter},
+	); err != nil {
+		panic(err)
+	}
+	label.SetPosition(mgl32.Vec3{-3.0, 2.2, 0})
+	spriteText = text.NewGlyphSourceInstanceList(text.Config{
+		PixelsPerUnit: PixelsPerUnit * 2,
+	}, loaders.NewSpriteFont(textMapping, loaders.SpriteFontConfig{
+		LetterSpacing: 4,
+	}))
+	if label, err = spriteText.NewLabel("AB BA", text.LayoutConfig{}); err != nil {
+		panic(err)
+	}
+	label.SetPosition(mgl32.Vec3{1.5, -1.5, 0})
+	sdfInstances = text.NewSDFGlyphInstanceList(text.Config{
+		TextureWidth:  512,
+		TextureHeight: 512,
+		PixelsPerUnit: PixelsPerUnit / 3,
+	}, font, text.SDFConfig{})
+	if label, err = sdfInstances.NewLabel("Crisp", text.LayoutConfig{}); err != nil {
+		panic(err)
+	}
+	label.SetPosition(mgl32.Vec3{-1.0, 1.0, 0})
 	for _, s := range []Inst{
 		Inst{Key: "numbered_squares_01", X: 0, Y: 0, R: 0},
 		Inst{Key: "numbered_squares_02", X: -1.5, Y: -1.5, R: -15},
@@ -161,13 +200,24 @@ func main() {
 
 		renderer.Render(camera, sheet, batchData, batchInstances)
 		renderer.Render(camera, sheet, square, spriteInstances)
+		renderer.Render(camera, sheet, square, spriteText)
 
 		textInstances.Bind()
 		renderer.Render(camera, textInstances.Sheet(), square, textInstances)
 		textInstances.Unbind()
 
+		glyphInstances.Bind()
+		renderer.Render(camera, glyphInstances.Sheet(), square, glyphInstances)
+		glyphInstances.Unbind()
+
 		renderer.Unbind()
 
+		sdfRenderer.Bind()
+		sdfInstances.Bind()
+		sdfRenderer.Render(camera, sdfInstances.Sheet(), square, sdfInstances)
+		sdfInstances.Unbind()
+		sdfRenderer.Unbind()
+
 		framerate.Bind()
 		framerate.Render(camera)
 		framerate.Unbind()
@@ -189,5 +239,8 @@ func main() {
 		panic(err)
 	}
 	textInstances.Delete()
+	glyphInstances.Delete()
+	sdfInstances.Delete()
+	sdfRenderer.Delete()
 	glog.Flush()
 }
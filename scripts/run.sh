@@ -17,6 +17,7 @@ go get github.com/go-gl/mathgl/mgl32
 go get github.com/golang/freetype
 go get github.com/golang/freetype/truetype
 go get github.com/golang/glog
+go get github.com/jfreymuth/oggvorbis
 go get golang.org/x/image/math/fixed
 
 echo "Generating code..."
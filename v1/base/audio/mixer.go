@@ -0,0 +1,490 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"github.com/golang/glog"
+	"math"
+	"sync"
+	"time"
+)
+
+// Groups voices, such as music or effects, under a shared volume.
+type Bus struct {
+	mixer  *Mixer
+	volume float32
+}
+
+func (b *Bus) Volume() float32 {
+	b.mixer.lock.Lock()
+	defer b.mixer.lock.Unlock()
+	return b.volume
+}
+
+func (b *Bus) SetVolume(volume float32) {
+	b.mixer.lock.Lock()
+	b.volume = volume
+	b.mixer.lock.Unlock()
+}
+
+// Frames played by a voice, either a decoded Sound or a Stream.
+type source interface {
+	rate() int
+	length() int
+	frame(i int) (l, r float32)
+	// Returns an error which stopped decoding, if any.
+	failed() error
+	close() error
+}
+
+func (s *Sound) rate() int {
+	return s.SampleRate
+}
+
+func (s *Sound) length() int {
+	return s.Frames()
+}
+
+func (s *Sound) failed() error {
+	return nil
+}
+
+func (s *Sound) close() error {
+	return nil
+}
+
+// A sound or stream playing in a mixer.
+type Voice struct {
+	mixer      *Mixer
+	sound      *Sound
+	source     source
+	bus        *Bus
+	position   float64 // In frames of the source.
+	loopStart  float64
+	volume     float32
+	fadeTarget float32
+	fadeStep   float32 // Volume change per mixed frame.
+	fadeStop   bool
+	distance   float32 // Attenuation from a Spatializer.
+	pan        float32
+	pitch      float32
+	loop       bool
+	paused     bool
+	culled     bool // Out of a Spatializer's range.
+	playing    bool
+}
+
+// Returns the sound being played, or nil for streams.
+func (v *Voice) Sound() *Sound {
+	return v.sound
+}
+
+func (v *Voice) Volume() float32 {
+	v.mixer.lock.Lock()
+	defer v.mixer.lock.Unlock()
+	return v.volume
+}
+
+// Sets the volume immediately, cancelling any fade.
+func (v *Voice) SetVolume(volume float32) {
+	v.mixer.lock.Lock()
+	v.volume = volume
+	v.fadeStep = 0
+	v.fadeStop = false
+	v.mixer.lock.Unlock()
+}
+
+// Ramps the volume to target over duration.
+func (v *Voice) FadeTo(target float32, duration time.Duration) {
+	v.mixer.lock.Lock()
+	v.fade(target, duration, false)
+	v.mixer.lock.Unlock()
+}
+
+// Ramps the volume to zero over duration and then stops the voice.
+func (v *Voice) FadeOut(duration time.Duration) {
+	v.mixer.lock.Lock()
+	v.fade(0, duration, true)
+	v.mixer.lock.Unlock()
+}
+
+func (v *Voice) fade(target float32, duration time.Duration, stop bool) {
+	var frames = float32(duration.Seconds() * float64(v.mixer.sampleRate))
+	v.fadeTarget = target
+	v.fadeStop = stop
+	if frames < 1 {
+		v.volume = target
+		v.fadeStep = 0
+		if stop {
+			v.playing = false
+		}
+		return
+	}
+	v.fadeStep = (target - v.volume) / frames
+}
+
+// Balances the voice from -1, left only, to 1, right only.
+func (v *Voice) SetPan(pan float32) {
+	v.mixer.lock.Lock()
+	v.pan = clamp(pan, -1, 1)
+	v.mixer.lock.Unlock()
+}
+
+// Scales playback speed, and so pitch. 2 plays an octave higher.
+func (v *Voice) SetPitch(pitch float32) {
+	v.mixer.lock.Lock()
+	v.pitch = pitch
+	v.mixer.lock.Unlock()
+}
+
+func (v *Voice) SetLoop(loop bool) {
+	v.mixer.lock.Lock()
+	v.loop = loop
+	v.mixer.lock.Unlock()
+}
+
+// Sets where looping restarts, so that an intro before it plays only once.
+func (v *Voice) SetLoopStart(offset time.Duration) {
+	v.mixer.lock.Lock()
+	v.setLoopStart(offset)
+	v.mixer.lock.Unlock()
+}
+
+// Limited to the source's length when mixed, as streams can end early.
+func (v *Voice) setLoopStart(offset time.Duration) {
+	v.loopStart = math.Max(0, offset.Seconds()*float64(v.source.rate()))
+}
+
+// Returns the playback position within the sound or stream.
+func (v *Voice) Position() time.Duration {
+	v.mixer.lock.Lock()
+	defer v.mixer.lock.Unlock()
+	return time.Duration(v.position / float64(v.source.rate()) * float64(time.Second))
+}
+
+func (v *Voice) SetPaused(paused bool) {
+	v.mixer.lock.Lock()
+	v.paused = paused
+	v.mixer.lock.Unlock()
+}
+
+// Returns false once the voice has finished or been stopped.
+func (v *Voice) Playing() bool {
+	v.mixer.lock.Lock()
+	defer v.mixer.lock.Unlock()
+	return v.playing
+}
+
+func (v *Voice) Stop() {
+	v.mixer.lock.Lock()
+	v.playing = false
+	v.mixer.lock.Unlock()
+}
+
+// Mixes playing voices into interleaved stereo samples. Methods may be called
+// from the game loop while another goroutine feeds an output.
+type Mixer struct {
+	lock       sync.Mutex
+	mixing     sync.Mutex // Held through Mix, which decodes streams unlocked.
+	sampleRate int
+	volume     float32
+	master     *Bus
+	voices     []*Voice
+	maxVoices  int
+	buffer     []float32
+}
+
+func NewMixer(sampleRate int) (m *Mixer) {
+	m = &Mixer{
+		sampleRate: sampleRate,
+		volume:     1,
+	}
+	m.master = m.NewBus()
+	return
+}
+
+func (m *Mixer) SampleRate() int {
+	return m.sampleRate
+}
+
+func (m *Mixer) NewBus() *Bus {
+	return &Bus{
+		mixer:  m,
+		volume: 1,
+	}
+}
+
+// The bus used by voices played without one.
+func (m *Mixer) Master() *Bus {
+	return m.master
+}
+
+func (m *Mixer) Volume() float32 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.volume
+}
+
+func (m *Mixer) SetVolume(volume float32) {
+	m.lock.Lock()
+	m.volume = volume
+	m.lock.Unlock()
+}
+
+// Limits how many voices play at once, stopping the oldest to make room.
+// Zero means no limit.
+func (m *Mixer) SetMaxVoices(count int) {
+	m.lock.Lock()
+	m.maxVoices = count
+	m.lock.Unlock()
+}
+
+// Returns the number of voices still playing.
+func (m *Mixer) Voices() (count int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, v := range m.voices {
+		if v.playing {
+			count++
+		}
+	}
+	return
+}
+
+// Starts sound on bus, or on the master bus if bus is nil.
+func (m *Mixer) Play(sound *Sound, bus *Bus) *Voice {
+	return m.play(sound, bus, func(v *Voice) {
+		v.sound = sound
+	})
+}
+
+// Starts streaming on bus, or on the master bus if bus is nil. The stream is
+// closed when the voice stops.
+func (m *Mixer) PlayStream(stream Stream, bus *Bus) *Voice {
+	return m.play(newStreamSource(stream), bus, nil)
+}
+
+// Calls setup, if not nil, on the new voice before the mixer can see it.
+// Voices which setup stops are never mixed.
+func (m *Mixer) play(src source, bus *Bus, setup func(v *Voice)) (v *Voice) {
+	if bus == nil {
+		bus = m.master
+	}
+	v = &Voice{
+		mixer:    m,
+		source:   src,
+		bus:      bus,
+		volume:   1,
+		distance: 1,
+		pitch:    1,
+		playing:  true,
+	}
+	if setup != nil {
+		setup(v)
+	}
+	if !v.playing {
+		if err := src.close(); err != nil {
+			glog.Errorf("Closing audio stream: %v", err)
+		}
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.maxVoices > 0 {
+		m.removeStopped()
+		for len(m.voices) >= m.maxVoices {
+			m.voices[0].playing = false
+			m.removeStopped()
+		}
+	}
+	m.voices = append(m.voices, v)
+	return
+}
+
+func (m *Mixer) StopAll() {
+	m.lock.Lock()
+	for _, v := range m.voices {
+		v.playing = false
+	}
+	m.removeStopped()
+	m.lock.Unlock()
+}
+
+func (m *Mixer) removeStopped() {
+	var live = m.voices[:0]
+	for _, v := range m.voices {
+		if v.playing {
+			live = append(live, v)
+		} else if err := v.source.close(); err != nil {
+			glog.Errorf("Closing audio stream: %v", err)
+		}
+	}
+	for i := len(live); i < len(m.voices); i++ {
+		m.voices[i] = nil
+	}
+	m.voices = live
+}
+
+// Fills out, interleaved stereo samples, with the mix of all playing voices
+// and advances them.
+func (m *Mixer) Mix(out []float32) {
+	m.mixing.Lock()
+	defer m.mixing.Unlock()
+	m.load(len(out) / 2)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for i := range out {
+		out[i] = 0
+	}
+	for _, v := range m.voices {
+		if v.playing && !v.paused && !v.culled {
+			m.mixVoice(v, out)
+		}
+	}
+	for i := range out {
+		out[i] = clamp(out[i]*m.volume, -1, 1)
+	}
+	m.removeStopped()
+}
+
+// Decodes what streams will need for the next frames without holding the
+// lock, so that the game loop isn't kept waiting.
+func (m *Mixer) load(frames int) {
+	type request struct {
+		src       *streamSource
+		from, to  int
+		loop      bool
+		loopStart float64
+	}
+	var requests []request
+	m.lock.Lock()
+	for _, v := range m.voices {
+		src, ok := v.source.(*streamSource)
+		if !ok || !v.playing || v.paused || v.culled {
+			continue
+		}
+		var step = float64(v.pitch) * float64(src.rate()) / float64(m.sampleRate)
+		requests = append(requests, request{
+			src:       src,
+			from:      int(v.position),
+			to:        int(v.position+float64(frames)*step) + 2,
+			loop:      v.loop,
+			loopStart: v.loopStart,
+		})
+	}
+	m.lock.Unlock()
+	for _, r := range requests {
+		var length = r.src.length()
+		if r.from >= length || r.to <= r.from {
+			continue
+		}
+		if r.to <= length {
+			r.src.load(r.from, r.to)
+			continue
+		}
+		r.src.load(r.from, length)
+		// Loading may have found that the stream ends sooner.
+		if length = r.src.length(); r.loop && length > 0 {
+			start := int(math.Min(r.loopStart, float64(length-1)))
+			r.src.load(start, start+r.to-length+1)
+		}
+	}
+}
+
+// Resamples v to the mixer's rate with linear interpolation.
+func (m *Mixer) mixVoice(v *Voice, out []float32) {
+	var (
+		src    = v.source
+		frames = src.length()
+		step   = float64(v.pitch) * float64(src.rate()) / float64(m.sampleRate)
+		panL   = clamp(1-v.pan, 0, 1)
+		panR   = clamp(1+v.pan, 0, 1)
+	)
+	if step <= 0 || frames <= 0 {
+		v.playing = frames > 0
+		return
+	}
+	for i := 0; i+1 < len(out); i += 2 {
+		if v.position >= float64(frames) {
+			if !v.loop || frames <= 0 {
+				v.playing = false
+				return
+			}
+			var start = math.Min(v.loopStart, float64(frames-1))
+			v.position = start + math.Mod(v.position-float64(frames), float64(frames)-start)
+		}
+		var (
+			index = int(v.position)
+			frac  = float32(v.position - float64(index))
+			next  = index + 1
+			gain  = v.volume * v.distance * v.bus.volume
+		)
+		if next >= frames {
+			if v.loop {
+				next = int(math.Min(v.loopStart, float64(frames-1)))
+			} else {
+				next = index
+			}
+		}
+		l0, r0 := src.frame(index)
+		l1, r1 := src.frame(next)
+		if err := src.failed(); err != nil {
+			glog.Errorf("Stopping audio stream: %v", err)
+			v.playing = false
+			return
+		}
+		if n := src.length(); n != frames {
+			// The stream ended early, so mix this sample again against
+			// the real end.
+			frames = n
+			i -= 2
+			continue
+		}
+		out[i] += (l0 + (l1-l0)*frac) * gain * panL
+		out[i+1] += (r0 + (r1-r0)*frac) * gain * panR
+		v.position += step
+		if v.fadeStep != 0 {
+			v.volume += v.fadeStep
+			if (v.fadeStep > 0) == (v.volume >= v.fadeTarget) {
+				v.volume = v.fadeTarget
+				v.fadeStep = 0
+				if v.fadeStop {
+					v.playing = false
+					return
+				}
+			}
+		}
+	}
+}
+
+// Mixes frames stereo frames and writes them to sink.
+func (m *Mixer) Render(sink Sink, frames int) (err error) {
+	if cap(m.buffer) < frames*2 {
+		m.buffer = make([]float32, frames*2)
+	}
+	m.buffer = m.buffer[:frames*2]
+	m.Mix(m.buffer)
+	return sink.Write(m.buffer)
+}
+
+func clamp(v, min, max float32) float32 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,181 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+// A mono stream at 10 Hz of frames numbered from 1, in thousandths, which
+// may end before the length it reports.
+type testStream struct {
+	pos    int64
+	frames int64
+	length int64
+	seeks  int
+}
+
+func (s *testStream) SampleRate() int { return 10 }
+func (s *testStream) Channels() int   { return 1 }
+func (s *testStream) Length() int64   { return s.length }
+func (s *testStream) Close() error    { return nil }
+
+func (s *testStream) Read(samples []float32) (n int, err error) {
+	for n < len(samples) && s.pos < s.frames {
+		samples[n] = float32(s.pos%1000+1) / 1000
+		n++
+		s.pos++
+	}
+	if n == 0 {
+		err = io.EOF
+	}
+	return
+}
+
+func (s *testStream) SetPosition(frame int64) error {
+	s.seeks++
+	s.pos = frame
+	return nil
+}
+
+func approx(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-5
+}
+
+// Mixes frames frames and returns the left channel.
+func mixLeft(m *Mixer, frames int) (left []float32) {
+	var out = make([]float32, frames*2)
+	m.Mix(out)
+	for i := 0; i < len(out); i += 2 {
+		left = append(left, out[i])
+	}
+	return
+}
+
+func TestMixerSound(t *testing.T) {
+	var (
+		sound = &Sound{SampleRate: 10, Channels: 1, Samples: []float32{0.1, 0.2, 0.3, 0.4}}
+		tests = []struct {
+			name  string
+			setup func(m *Mixer, v *Voice)
+			want  []float32
+		}{
+			{"plain", func(m *Mixer, v *Voice) {}, []float32{0.1, 0.2, 0.3, 0.4, 0, 0}},
+			{"volume", func(m *Mixer, v *Voice) {
+				v.SetVolume(0.5)
+				m.Master().SetVolume(0.5)
+				m.SetVolume(2)
+			}, []float32{0.05, 0.1, 0.15, 0.2, 0, 0}},
+			{"half pitch", func(m *Mixer, v *Voice) { v.SetPitch(0.5) }, []float32{0.1, 0.15, 0.2, 0.25, 0.3, 0.35}},
+			{"loop", func(m *Mixer, v *Voice) {
+				v.SetLoop(true)
+				v.SetLoopStart(200 * time.Millisecond)
+			}, []float32{0.1, 0.2, 0.3, 0.4, 0.3, 0.4}},
+			{"panned right", func(m *Mixer, v *Voice) { v.SetPan(0.5) }, []float32{0.05, 0.1, 0.15, 0.2, 0, 0}},
+			{"paused", func(m *Mixer, v *Voice) { v.SetPaused(true) }, []float32{0, 0, 0, 0, 0, 0}},
+			{"clipped", func(m *Mixer, v *Voice) { m.SetVolume(5) }, []float32{0.5, 1, 1, 1, 0, 0}},
+		}
+	)
+	for _, test := range tests {
+		var (
+			m = NewMixer(10)
+			v = m.Play(sound, nil)
+		)
+		test.setup(m, v)
+		got := mixLeft(m, 6)
+		for i := range test.want {
+			if !approx(got[i], test.want[i]) {
+				t.Errorf("%v: mixed %v, want %v", test.name, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestMixerFadeOut(t *testing.T) {
+	var (
+		m = NewMixer(10)
+		v = m.Play(&Sound{SampleRate: 10, Channels: 1, Samples: make([]float32, 100)}, nil)
+	)
+	v.FadeOut(500 * time.Millisecond)
+	mixLeft(m, 4)
+	if !v.Playing() || !approx(v.Volume(), 0.2) {
+		t.Errorf("Partway through the fade, playing %v at volume %v", v.Playing(), v.Volume())
+	}
+	// Rounding may leave the volume a hair above zero for a frame.
+	mixLeft(m, 2)
+	if v.Playing() || m.Voices() != 0 {
+		t.Errorf("Still playing after fading out")
+	}
+}
+
+func TestMixerMaxVoices(t *testing.T) {
+	var (
+		m     = NewMixer(10)
+		sound = &Sound{SampleRate: 10, Channels: 1, Samples: make([]float32, 100)}
+	)
+	m.SetMaxVoices(2)
+	first := m.Play(sound, nil)
+	m.Play(sound, nil)
+	m.Play(sound, nil)
+	if first.Playing() || m.Voices() != 2 {
+		t.Errorf("%v voices playing, first %v", m.Voices(), first.Playing())
+	}
+}
+
+// Streams longer than a decoded window, looping past the end.
+func TestMixerStreamLoop(t *testing.T) {
+	var (
+		m      = NewMixer(10)
+		stream = &testStream{frames: 10000, length: 10000}
+		v      = m.PlayStream(stream, nil)
+		pos    = 0
+	)
+	v.SetLoop(true)
+	v.SetLoopStart(10 * time.Second)
+	for mix := 0; mix < 10; mix++ {
+		for i, got := range mixLeft(m, 3000) {
+			if want := float32(pos%1000+1) / 1000; got != want {
+				t.Fatalf("Mix %v, frame %v: %v, want %v", mix, i, got, want)
+			}
+			if pos++; pos == 10000 {
+				pos = 100
+			}
+		}
+	}
+}
+
+// Streams which hit io.EOF before their length end or loop there.
+func TestMixerStreamEndsEarly(t *testing.T) {
+	var (
+		m    = NewMixer(10)
+		loop = m.PlayStream(&testStream{frames: 3, length: 100}, nil)
+		once = m.PlayStream(&testStream{frames: 3, length: 100}, m.NewBus())
+	)
+	loop.SetLoop(true)
+	once.SetVolume(0)
+	got := mixLeft(m, 7)
+	for i, want := range []float32{0.001, 0.002, 0.003, 0.001, 0.002, 0.003, 0.001} {
+		if !approx(got[i], want) {
+			t.Fatalf("Mixed %v, want it to loop every 3 frames", got)
+		}
+	}
+	if !loop.Playing() || once.Playing() {
+		t.Errorf("Looping voice playing %v, one-shot playing %v", loop.Playing(), once.Playing())
+	}
+}
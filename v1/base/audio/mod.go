@@ -0,0 +1,176 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	modSamples    = 31
+	modHeaderSize = 1084
+)
+
+// Returns the channel count given by a MOD signature, or zero if it isn't
+// one.
+func modChannels(signature string) int {
+	switch signature {
+	case "M.K.", "M!K!", "FLT4", "4CHN":
+		return 4
+	case "FLT8":
+		return 8
+	}
+	if strings.HasSuffix(signature, "CHN") {
+		n, _ := strconv.Atoi(signature[:1])
+		return n
+	}
+	if strings.HasSuffix(signature, "CH") || strings.HasSuffix(signature, "CN") {
+		n, _ := strconv.Atoi(signature[:2])
+		return n
+	}
+	return 0
+}
+
+func trimName(b []byte) string {
+	return strings.TrimRight(string(b), "\x00 ")
+}
+
+// Decodes a 31 sample ProTracker style module.
+func DecodeMOD(r io.Reader) (module *Module, err error) {
+	var (
+		data     []byte
+		channels int
+		patterns int
+		offset   int
+	)
+	if data, err = ioutil.ReadAll(r); err != nil {
+		return
+	}
+	if len(data) < modHeaderSize {
+		err = fmt.Errorf("MOD file too short")
+		return
+	}
+	if channels = modChannels(string(data[1080:1084])); channels == 0 {
+		err = fmt.Errorf("Unsupported MOD signature %q", data[1080:1084])
+		return
+	}
+	module = &Module{
+		Title:    trimName(data[0:20]),
+		Channels: channels,
+		Speed:    6,
+		Tempo:    125,
+		Panning:  make([]int, channels),
+	}
+	for i := range module.Panning {
+		// Amiga channels alternate left, right, right, left.
+		if i%4 == 0 || i%4 == 3 {
+			module.Panning[i] = 64
+		} else {
+			module.Panning[i] = 192
+		}
+	}
+	for i, order := range data[952 : 952+int(data[950])] {
+		if i >= 128 {
+			break
+		}
+		module.Orders = append(module.Orders, int(order))
+		if int(order)+1 > patterns {
+			patterns = int(order) + 1
+		}
+	}
+	offset = modHeaderSize
+	for i := 0; i < patterns; i++ {
+		var pattern = &ModulePattern{
+			Rows:  64,
+			Notes: make([]ModuleNote, 64*channels),
+		}
+		if offset+64*channels*4 > len(data) {
+			err = fmt.Errorf("MOD pattern %v is truncated", i)
+			return
+		}
+		for j := range pattern.Notes {
+			b := data[offset+j*4:]
+			pattern.Notes[j] = ModuleNote{
+				Note:       modNote(int(b[0]&0x0F)<<8 | int(b[1])),
+				Instrument: b[0]&0xF0 | b[2]>>4,
+				Effect:     b[2] & 0x0F,
+				Param:      b[3],
+			}
+		}
+		module.Patterns = append(module.Patterns, pattern)
+		offset += 64 * channels * 4
+	}
+	for i := 0; i < modSamples; i++ {
+		var (
+			h          = data[20+i*30:]
+			length     = int(binary.BigEndian.Uint16(h[22:])) * 2
+			finetune   = int(h[24] & 0x0F)
+			loopStart  = int(binary.BigEndian.Uint16(h[26:])) * 2
+			loopLength = int(binary.BigEndian.Uint16(h[28:])) * 2
+			sample     = &ModuleSample{
+				Name:    trimName(h[0:22]),
+				Volume:  int(h[25]),
+				Panning: -1,
+			}
+		)
+		if finetune >= 8 {
+			finetune -= 16
+		}
+		sample.Finetune = finetune * 16
+		if sample.Volume > 64 {
+			sample.Volume = 64
+		}
+		if offset+length > len(data) {
+			length = len(data) - offset
+		}
+		sample.Data = make([]float32, length)
+		for j := range sample.Data {
+			sample.Data[j] = float32(int8(data[offset+j])) / 128
+		}
+		offset += length
+		// A two byte loop is ProTracker's marker for no loop.
+		if loopLength > 2 && loopStart < length {
+			if loopStart+loopLength > length {
+				loopLength = length - loopStart
+			}
+			sample.LoopStart = loopStart
+			sample.LoopLength = loopLength
+		}
+		module.Instruments = append(module.Instruments, &ModuleInstrument{
+			Name:    sample.Name,
+			Samples: []*ModuleSample{sample},
+		})
+	}
+	return
+}
+
+// Converts an Amiga period to a note numbered as in XM, where period 428
+// plays at C-4.
+func modNote(period int) uint8 {
+	if period == 0 {
+		return 0
+	}
+	var note = int(math.Floor(12*math.Log2(428/float64(period))+0.5)) + 48
+	if note < 0 || note >= moduleMaxNote {
+		return 0
+	}
+	return uint8(note + 1)
+}
@@ -0,0 +1,154 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// Tracker music, as loaded from a MOD or XM file.
+type Module struct {
+	Title         string
+	Channels      int
+	Orders        []int // Pattern indices in play order.
+	Restart       int   // Order to continue from after the last.
+	Patterns      []*ModulePattern
+	Instruments   []*ModuleInstrument // Numbered from one in patterns.
+	Speed         int                 // Ticks per row.
+	Tempo         int                 // Beats per minute, where a tick is 2.5/Tempo seconds.
+	LinearPeriods bool                // XM linear frequency table, otherwise Amiga periods.
+	Panning       []int               // Initial panning per channel, 0 to 255.
+}
+
+const (
+	moduleNoteKeyOff = 97
+	moduleMaxNote    = 96
+)
+
+type ModuleNote struct {
+	Note       uint8 // 1 is C-0, 97 is key off and 0 is empty.
+	Instrument uint8
+	Volume     uint8 // XM volume column.
+	Effect     uint8 // 0x0-0xF as in MOD, then XM's G onwards from 0x10.
+	Param      uint8
+}
+
+type ModulePattern struct {
+	Rows  int
+	Notes []ModuleNote // Rows of one note per channel.
+}
+
+func (p *ModulePattern) Note(row, channel, channels int) ModuleNote {
+	return p.Notes[row*channels+channel]
+}
+
+type ModuleSample struct {
+	Name         string
+	Data         []float32
+	LoopStart    int
+	LoopLength   int // Zero for samples which don't loop.
+	PingPong     bool
+	Volume       int // 0 to 64.
+	Finetune     int // In 1/128ths of a semitone.
+	RelativeNote int
+	Panning      int // 0 to 255, or negative to keep the channel's panning.
+}
+
+type ModuleEnvelopePoint struct {
+	Tick  int
+	Value int // 0 to 64.
+}
+
+type ModuleEnvelope struct {
+	Enabled      bool
+	Points       []ModuleEnvelopePoint
+	Sustain      bool
+	SustainPoint int
+	Loop         bool
+	LoopStart    int
+	LoopEnd      int
+}
+
+// Returns the envelope's value at tick, interpolating between points.
+func (e *ModuleEnvelope) value(tick int) int {
+	var n = len(e.Points)
+	if n == 0 {
+		return 64
+	}
+	if tick <= e.Points[0].Tick {
+		return e.Points[0].Value
+	}
+	for i := 1; i < n; i++ {
+		a, b := e.Points[i-1], e.Points[i]
+		if tick < b.Tick {
+			return a.Value + (b.Value-a.Value)*(tick-a.Tick)/(b.Tick-a.Tick)
+		}
+	}
+	return e.Points[n-1].Value
+}
+
+// Returns the tick after tick, holding at the sustain point while the key
+// is down and wrapping around any loop.
+func (e *ModuleEnvelope) next(tick int, keyOn bool) int {
+	if keyOn && e.Sustain && e.SustainPoint < len(e.Points) && tick == e.Points[e.SustainPoint].Tick {
+		return tick
+	}
+	tick++
+	if e.Loop && e.LoopEnd < len(e.Points) && e.LoopStart <= e.LoopEnd && tick >= e.Points[e.LoopEnd].Tick {
+		tick = e.Points[e.LoopStart].Tick
+	}
+	return tick
+}
+
+type ModuleInstrument struct {
+	Name            string
+	Samples         []*ModuleSample
+	SampleMap       [moduleMaxNote]int // Sample index for each note.
+	VolumeEnvelope  ModuleEnvelope
+	PanningEnvelope ModuleEnvelope
+	Fadeout         int // Volume lost per tick after key off, out of 65536.
+}
+
+func (i *ModuleInstrument) sample(note int) *ModuleSample {
+	var index int
+	if note >= 0 && note < moduleMaxNote {
+		index = i.SampleMap[note]
+	}
+	if index < 0 || index >= len(i.Samples) {
+		return nil
+	}
+	return i.Samples[index]
+}
+
+// Loads a MOD or XM file, chosen by extension.
+func LoadModule(p string) (module *Module, err error) {
+	var f *os.File
+	if f, err = os.Open(p); err != nil {
+		return
+	}
+	defer f.Close()
+	switch strings.ToLower(path.Ext(p)) {
+	case ".mod":
+		module, err = DecodeMOD(f)
+	case ".xm":
+		module, err = DecodeXM(f)
+	default:
+		err = fmt.Errorf("Unsupported module format %v", p)
+	}
+	return
+}
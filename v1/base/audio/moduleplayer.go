@@ -0,0 +1,858 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"io"
+	"math"
+	"sync"
+)
+
+const (
+	moduleFadeMax = 65536
+	// Longest song measured, in case a module never ends.
+	moduleMaxLength = 60 * 60
+)
+
+type ModulePosition struct {
+	Order   int
+	Pattern int
+	Row     int
+	Tick    int
+}
+
+type moduleChannel struct {
+	instrument *ModuleInstrument
+	sample     *ModuleSample
+	cell       ModuleNote
+	active     bool
+	position   float64
+	backwards  bool // Playing a ping-pong loop in reverse.
+	period     float64
+	target     float64 // Tone portamento destination.
+	volume     int
+	panning    int
+	keyOn      bool
+	fadeout    int
+	volumeTick int
+	panTick    int
+
+	// Effect memory.
+	portaUp, portaDown, portaSpeed int
+	finePortaUp, finePortaDown     int
+	volumeSlide, panSlide          int
+	fineVolumeUp, fineVolumeDown   int
+	offset                         int
+	vibratoSpeed, vibratoDepth     int
+	vibratoPos                     int
+	tremoloSpeed, tremoloDepth     int
+	tremoloPos                     int
+	loopRow, loopCount             int
+
+	// Modulation for the current tick only.
+	vibrato   float64
+	tremolo   int
+	arpeggio  int
+	step      float64
+	gainLeft  float32
+	gainRight float32
+}
+
+// Plays a Module as a Stream, so it can be mixed like any other music. Methods
+// may be called from the game loop while the mixer reads.
+type ModulePlayer struct {
+	lock         sync.Mutex
+	module       *Module
+	sampleRate   int
+	channels     []moduleChannel
+	current      ModulePosition
+	order        int
+	row          int
+	tick         int
+	speed        int
+	tempo        int
+	startSpeed   int // Set by SetSpeed to replace the module's, or zero.
+	startTempo   int
+	tempoScale   float64
+	globalVolume int
+	globalSlide  int
+	tickLeft     float64 // Frames left in the current tick.
+	patternDelay int
+	repeatRow    bool
+	breakOrder   int
+	breakRow     int
+	loopJump     int
+	visited      map[int]bool
+	ended        bool
+	length       int64 // Of the current pass.
+	nextLength   int64 // Of passes from the start with the current settings.
+}
+
+func NewModulePlayer(module *Module, sampleRate int) (p *ModulePlayer) {
+	p = newModulePlayer(module, sampleRate)
+	p.nextLength = newModulePlayer(module, sampleRate).measure()
+	p.length = p.nextLength
+	return
+}
+
+func newModulePlayer(module *Module, sampleRate int) (p *ModulePlayer) {
+	p = &ModulePlayer{
+		module:     module,
+		sampleRate: sampleRate,
+		tempoScale: 1,
+	}
+	p.reset()
+	return
+}
+
+// Renders the whole song, once through, into a sound.
+func (m *Module) Render(sampleRate int) (sound *Sound) {
+	var p = NewModulePlayer(m, sampleRate)
+	sound = &Sound{
+		SampleRate: sampleRate,
+		Channels:   2,
+		Samples:    make([]float32, p.length*2),
+	}
+	p.render(sound.Samples, int(p.length))
+	return
+}
+
+func (p *ModulePlayer) reset() {
+	p.order = 0
+	p.row = 0
+	p.tick = 0
+	if p.speed = p.module.Speed; p.startSpeed > 0 {
+		p.speed = p.startSpeed
+	} else if p.speed <= 0 {
+		p.speed = 6
+	}
+	if p.tempo = p.module.Tempo; p.startTempo > 0 {
+		p.tempo = p.startTempo
+	} else if p.tempo <= 0 {
+		p.tempo = 125
+	}
+	p.length = p.nextLength
+	p.globalVolume = 64
+	p.tickLeft = 0
+	p.patternDelay = 0
+	p.repeatRow = false
+	p.breakOrder = -1
+	p.breakRow = -1
+	p.loopJump = -1
+	p.visited = map[int]bool{}
+	p.ended = len(p.module.Orders) == 0
+	p.channels = make([]moduleChannel, p.module.Channels)
+	for i := range p.channels {
+		p.channels[i].panning = 128
+		if i < len(p.module.Panning) {
+			p.channels[i].panning = p.module.Panning[i]
+		}
+	}
+}
+
+// Returns the frames until the song ends or first repeats itself.
+func (p *ModulePlayer) measure() (frames int64) {
+	for !p.ended && frames < int64(moduleMaxLength*p.sampleRate) {
+		p.processTick()
+		n := math.Ceil(p.tickLeft)
+		p.tickLeft -= n
+		frames += int64(n)
+	}
+	return
+}
+
+// Measures a pass from the start with the current tempo settings. A pass
+// already under way keeps the longer of the two lengths, since the stream
+// ends early rather than being cut off if it was overestimated.
+func (p *ModulePlayer) updateLength() {
+	var q = newModulePlayer(p.module, p.sampleRate)
+	q.startSpeed = p.startSpeed
+	q.startTempo = p.startTempo
+	q.tempoScale = p.tempoScale
+	q.reset()
+	if p.nextLength = q.measure(); p.nextLength > p.length {
+		p.length = p.nextLength
+	}
+}
+
+func (p *ModulePlayer) Module() *Module {
+	return p.module
+}
+
+func (p *ModulePlayer) SampleRate() int {
+	return p.sampleRate
+}
+
+func (p *ModulePlayer) Channels() int {
+	return 2
+}
+
+// Returns the frames in one pass through the song, at most, with the tempo
+// settings in effect.
+func (p *ModulePlayer) Length() int64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.length
+}
+
+// Returns the row being played, for syncing gameplay to the music.
+func (p *ModulePlayer) Position() ModulePosition {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.current
+}
+
+// Returns the beats per minute, which the module may change as it plays.
+func (p *ModulePlayer) Tempo() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.tempo
+}
+
+// Sets the tempo now and whenever the song restarts, in place of the
+// module's initial tempo. Tempo changes in the module's patterns still apply.
+func (p *ModulePlayer) SetTempo(bpm int) {
+	p.lock.Lock()
+	if bpm > 0 {
+		p.tempo = bpm
+		p.startTempo = bpm
+		p.updateLength()
+	}
+	p.lock.Unlock()
+}
+
+// Returns ticks per row.
+func (p *ModulePlayer) Speed() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.speed
+}
+
+// Sets ticks per row now and whenever the song restarts, like SetTempo.
+func (p *ModulePlayer) SetSpeed(ticks int) {
+	p.lock.Lock()
+	if ticks > 0 {
+		p.speed = ticks
+		p.startSpeed = ticks
+		p.updateLength()
+	}
+	p.lock.Unlock()
+}
+
+// Multiplies the tempo, including tempos set by the module, without changing
+// pitch. Each change measures the song again, so avoid calling it per frame.
+func (p *ModulePlayer) SetTempoScale(scale float32) {
+	p.lock.Lock()
+	if scale > 0 && float64(scale) != p.tempoScale {
+		p.tempoScale = float64(scale)
+		p.updateLength()
+	}
+	p.lock.Unlock()
+}
+
+// Jumps to the start of an order at the end of the current row.
+func (p *ModulePlayer) SetOrder(order int) {
+	p.lock.Lock()
+	if order >= 0 && order < len(p.module.Orders) {
+		p.breakOrder = order
+		p.breakRow = 0
+	}
+	p.lock.Unlock()
+}
+
+func (p *ModulePlayer) Read(samples []float32) (n int, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for i := range samples {
+		samples[i] = 0
+	}
+	if n = p.render(samples, len(samples)/2) * 2; n == 0 {
+		err = io.EOF
+	}
+	return
+}
+
+// Restarts and plays silently up to frame.
+func (p *ModulePlayer) SetPosition(frame int64) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.reset()
+	p.render(nil, int(frame))
+	return nil
+}
+
+func (p *ModulePlayer) Close() error {
+	return nil
+}
+
+// Adds up to frames frames to out, or just advances if out is nil, returning
+// how many were played before the song ended.
+func (p *ModulePlayer) render(out []float32, frames int) (done int) {
+	for done < frames {
+		if p.tickLeft <= 0 {
+			if p.ended {
+				return
+			}
+			p.processTick()
+		}
+		n := int(math.Ceil(p.tickLeft))
+		if n > frames-done {
+			n = frames - done
+		}
+		for i := range p.channels {
+			if out == nil {
+				p.channels[i].mix(nil, n)
+			} else {
+				p.channels[i].mix(out[done*2:], n)
+			}
+		}
+		p.tickLeft -= float64(n)
+		done += n
+	}
+	return
+}
+
+func (p *ModulePlayer) pattern() *ModulePattern {
+	var index = p.module.Orders[p.order]
+	if index >= len(p.module.Patterns) {
+		return nil
+	}
+	return p.module.Patterns[index]
+}
+
+func (p *ModulePlayer) processTick() {
+	p.current = ModulePosition{
+		Order:   p.order,
+		Pattern: p.module.Orders[p.order],
+		Row:     p.row,
+		Tick:    p.tick,
+	}
+	for i := range p.channels {
+		c := &p.channels[i]
+		c.vibrato = 0
+		c.tremolo = 0
+		c.arpeggio = 0
+	}
+	if p.tick == 0 {
+		if !p.repeatRow {
+			p.startRow()
+		}
+	} else {
+		if p.globalSlide != 0 && p.anyEffect(0x11) {
+			p.globalVolume = clampInt(p.globalVolume+slideAmount(p.globalSlide), 0, 64)
+		}
+		for i := range p.channels {
+			p.tickEffects(&p.channels[i])
+		}
+	}
+	for i := range p.channels {
+		p.updateChannel(&p.channels[i])
+	}
+	p.tickLeft += float64(p.sampleRate) * 2.5 / (float64(p.tempo) * p.tempoScale)
+	if p.tick++; p.tick >= p.speed {
+		p.tick = 0
+		p.endRow()
+	}
+}
+
+func (p *ModulePlayer) anyEffect(effect uint8) bool {
+	for i := range p.channels {
+		if p.channels[i].cell.Effect == effect {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ModulePlayer) startRow() {
+	var pattern = p.pattern()
+	p.visited[p.order<<8|p.row] = true
+	for i := range p.channels {
+		c := &p.channels[i]
+		c.cell = ModuleNote{}
+		if pattern != nil && p.row < pattern.Rows {
+			c.cell = pattern.Note(p.row, i, p.module.Channels)
+		}
+		if c.cell.Effect == 0xE && c.cell.Param>>4 == 0xD && c.cell.Param&0xF > 0 {
+			// Delayed until a later tick.
+			continue
+		}
+		p.triggerNote(c)
+		p.rowEffects(c)
+	}
+}
+
+func (p *ModulePlayer) endRow() {
+	var (
+		order   = p.order
+		row     = p.row + 1
+		jumped  bool
+		pattern *ModulePattern
+	)
+	if p.patternDelay > 0 {
+		p.patternDelay--
+		p.repeatRow = true
+		return
+	}
+	p.repeatRow = false
+	switch {
+	case p.breakOrder >= 0 || p.breakRow >= 0:
+		jumped = true
+		if order = p.order + 1; p.breakOrder >= 0 {
+			order = p.breakOrder
+		}
+		if row = 0; p.breakRow >= 0 {
+			row = p.breakRow
+		}
+	case p.loopJump >= 0:
+		row = p.loopJump
+	default:
+		if pattern = p.pattern(); pattern == nil || row >= pattern.Rows {
+			order++
+			row = 0
+		}
+	}
+	p.breakOrder, p.breakRow, p.loopJump = -1, -1, -1
+	if order >= len(p.module.Orders) {
+		if order = p.module.Restart; order >= len(p.module.Orders) {
+			order = 0
+		}
+		row = 0
+		p.ended = true
+	}
+	p.order = order
+	if pattern = p.pattern(); pattern == nil || row >= pattern.Rows {
+		row = 0
+	}
+	p.row = row
+	if jumped && p.visited[order<<8|row] {
+		p.ended = true
+	}
+	if p.ended {
+		p.visited = map[int]bool{}
+	}
+}
+
+func (p *ModulePlayer) notePeriod(note, finetune int) float64 {
+	var n = float64(note) + float64(finetune)/128
+	if p.module.LinearPeriods {
+		return 7680 - n*64
+	}
+	return 1712 * math.Pow(2, (48-n)/12)
+}
+
+func (p *ModulePlayer) frequency(period float64) float64 {
+	if p.module.LinearPeriods {
+		return 8363 * math.Pow(2, (4608-period)/768)
+	}
+	return 8363 * 1712 / period
+}
+
+// Returns period shifted up by semitones.
+func (p *ModulePlayer) transpose(period float64, semitones int) float64 {
+	if p.module.LinearPeriods {
+		return period - float64(semitones*64)
+	}
+	return period * math.Pow(2, -float64(semitones)/12)
+}
+
+func (p *ModulePlayer) triggerNote(c *moduleChannel) {
+	var (
+		cell      = c.cell
+		tonePorta = cell.Effect == 0x3 || cell.Effect == 0x5 || cell.Volume>>4 == 0xF
+	)
+	if cell.Instrument > 0 && int(cell.Instrument) <= len(p.module.Instruments) {
+		c.instrument = p.module.Instruments[cell.Instrument-1]
+	}
+	if cell.Note == moduleNoteKeyOff {
+		p.keyOff(c)
+	} else if cell.Note > 0 && c.instrument != nil {
+		note := int(cell.Note) - 1
+		if sample := c.instrument.sample(note); sample != nil {
+			period := p.notePeriod(note+sample.RelativeNote, sample.Finetune)
+			if tonePorta && c.active {
+				c.target = period
+			} else {
+				c.sample = sample
+				c.period = period
+				c.target = period
+				c.position = 0
+				c.backwards = false
+				c.active = len(sample.Data) > 0
+				c.vibratoPos = 0
+				c.tremoloPos = 0
+			}
+		}
+	}
+	if cell.Instrument > 0 && c.sample != nil {
+		c.volume = c.sample.Volume
+		if c.sample.Panning >= 0 {
+			c.panning = c.sample.Panning
+		}
+		c.keyOn = true
+		c.fadeout = moduleFadeMax
+		c.volumeTick = 0
+		c.panTick = 0
+	}
+}
+
+func (p *ModulePlayer) keyOff(c *moduleChannel) {
+	c.keyOn = false
+	if c.instrument == nil || !c.instrument.VolumeEnvelope.Enabled {
+		c.volume = 0
+	}
+}
+
+// Applies effects which happen once at the start of a row.
+func (p *ModulePlayer) rowEffects(c *moduleChannel) {
+	var (
+		x      = int(c.cell.Param)
+		hi, lo = x >> 4, x & 0xF
+	)
+	p.volumeColumn(c, true)
+	switch c.cell.Effect {
+	case 0x1:
+		remember(&c.portaUp, x)
+	case 0x2:
+		remember(&c.portaDown, x)
+	case 0x3:
+		remember(&c.portaSpeed, x)
+	case 0x4:
+		remember(&c.vibratoSpeed, hi)
+		remember(&c.vibratoDepth, lo)
+	case 0x5, 0x6, 0xA:
+		remember(&c.volumeSlide, x)
+	case 0x7:
+		remember(&c.tremoloSpeed, hi)
+		remember(&c.tremoloDepth, lo)
+	case 0x8:
+		c.panning = x
+	case 0x9:
+		remember(&c.offset, x*256)
+		if c.cell.Note > 0 && c.cell.Note != moduleNoteKeyOff && c.sample != nil {
+			c.position = float64(c.offset)
+			if c.offset >= len(c.sample.Data) {
+				c.active = false
+			}
+		}
+	case 0xB:
+		p.breakOrder = x
+	case 0xC:
+		c.volume = clampInt(x, 0, 64)
+	case 0xD:
+		p.breakRow = hi*10 + lo
+	case 0xE:
+		switch hi {
+		case 0x1:
+			remember(&c.finePortaUp, lo)
+			c.period = clampPeriod(c.period - float64(4*c.finePortaUp))
+		case 0x2:
+			remember(&c.finePortaDown, lo)
+			c.period = clampPeriod(c.period + float64(4*c.finePortaDown))
+		case 0x6:
+			if lo == 0 {
+				c.loopRow = p.row
+			} else if c.loopCount == 0 {
+				c.loopCount = lo
+				p.loopJump = c.loopRow
+			} else if c.loopCount--; c.loopCount > 0 {
+				p.loopJump = c.loopRow
+			}
+		case 0x8:
+			c.panning = lo * 17
+		case 0xA:
+			remember(&c.fineVolumeUp, lo)
+			c.volume = clampInt(c.volume+c.fineVolumeUp, 0, 64)
+		case 0xB:
+			remember(&c.fineVolumeDown, lo)
+			c.volume = clampInt(c.volume-c.fineVolumeDown, 0, 64)
+		case 0xC:
+			if lo == 0 {
+				c.volume = 0
+			}
+		case 0xE:
+			p.patternDelay = lo
+		}
+	case 0xF:
+		if x > 0 && x < 32 {
+			p.speed = x
+		} else if x >= 32 {
+			p.tempo = x
+		}
+	case 0x10:
+		p.globalVolume = clampInt(x, 0, 64)
+	case 0x11:
+		remember(&p.globalSlide, x)
+	case 0x14:
+		if x == 0 {
+			p.keyOff(c)
+		}
+	case 0x15:
+		c.volumeTick = x
+		c.panTick = x
+	case 0x19:
+		remember(&c.panSlide, x)
+	}
+}
+
+// Applies effects which continue on the ticks after the first of a row.
+func (p *ModulePlayer) tickEffects(c *moduleChannel) {
+	var (
+		x      = int(c.cell.Param)
+		hi, lo = x >> 4, x & 0xF
+	)
+	p.volumeColumn(c, false)
+	switch c.cell.Effect {
+	case 0x0:
+		if x != 0 {
+			c.arpeggio = [3]int{0, hi, lo}[p.tick%3]
+		}
+	case 0x1:
+		c.period = clampPeriod(c.period - float64(4*c.portaUp))
+	case 0x2:
+		c.period = clampPeriod(c.period + float64(4*c.portaDown))
+	case 0x3:
+		c.tonePorta()
+	case 0x4:
+		c.vibratoTick()
+	case 0x5:
+		c.tonePorta()
+		c.volume = clampInt(c.volume+slideAmount(c.volumeSlide), 0, 64)
+	case 0x6:
+		c.vibratoTick()
+		c.volume = clampInt(c.volume+slideAmount(c.volumeSlide), 0, 64)
+	case 0x7:
+		c.tremolo = int(moduleSine(c.tremoloPos) * float64(c.tremoloDepth) * 4)
+		c.tremoloPos = (c.tremoloPos + c.tremoloSpeed) & 63
+	case 0xA:
+		c.volume = clampInt(c.volume+slideAmount(c.volumeSlide), 0, 64)
+	case 0xE:
+		switch hi {
+		case 0x9:
+			if lo > 0 && p.tick%lo == 0 {
+				c.retrigger()
+			}
+		case 0xC:
+			if p.tick == lo {
+				c.volume = 0
+			}
+		case 0xD:
+			if p.tick == lo {
+				p.triggerNote(c)
+				p.volumeColumn(c, true)
+			}
+		}
+	case 0x14:
+		if p.tick == x {
+			p.keyOff(c)
+		}
+	case 0x19:
+		c.panning = clampInt(c.panning-slideAmount(c.panSlide), 0, 255)
+	case 0x1B:
+		if lo > 0 && p.tick%lo == 0 {
+			c.retrigger()
+		}
+	}
+}
+
+// Applies the XM volume column, either at the start of a row or on later
+// ticks.
+func (p *ModulePlayer) volumeColumn(c *moduleChannel, row bool) {
+	var (
+		v  = int(c.cell.Volume)
+		lo = v & 0xF
+	)
+	switch {
+	case v >= 0x10 && v <= 0x50:
+		if row {
+			c.volume = v - 0x10
+		}
+	case v>>4 == 0x6 && !row:
+		c.volume = clampInt(c.volume-lo, 0, 64)
+	case v>>4 == 0x7 && !row:
+		c.volume = clampInt(c.volume+lo, 0, 64)
+	case v>>4 == 0x8 && row:
+		c.volume = clampInt(c.volume-lo, 0, 64)
+	case v>>4 == 0x9 && row:
+		c.volume = clampInt(c.volume+lo, 0, 64)
+	case v>>4 == 0xA && row:
+		remember(&c.vibratoSpeed, lo)
+	case v>>4 == 0xB:
+		if row {
+			remember(&c.vibratoDepth, lo)
+		} else {
+			c.vibratoTick()
+		}
+	case v>>4 == 0xC && row:
+		c.panning = lo * 17
+	case v>>4 == 0xD && !row:
+		c.panning = clampInt(c.panning-lo, 0, 255)
+	case v>>4 == 0xE && !row:
+		c.panning = clampInt(c.panning+lo, 0, 255)
+	case v>>4 == 0xF:
+		if row {
+			remember(&c.portaSpeed, lo*16)
+		} else {
+			c.tonePorta()
+		}
+	}
+}
+
+// Works out the channel's output for the tick after effects have run.
+func (p *ModulePlayer) updateChannel(c *moduleChannel) {
+	var (
+		envVolume = 64
+		envPan    = 32
+		pan       float64
+		gain      float64
+	)
+	if c.instrument != nil {
+		if env := &c.instrument.VolumeEnvelope; env.Enabled {
+			envVolume = env.value(c.volumeTick)
+			c.volumeTick = env.next(c.volumeTick, c.keyOn)
+			if !c.keyOn {
+				if c.fadeout -= c.instrument.Fadeout; c.fadeout <= 0 {
+					c.fadeout = 0
+					c.active = false
+				}
+			}
+		}
+		if env := &c.instrument.PanningEnvelope; env.Enabled {
+			envPan = env.value(c.panTick)
+			c.panTick = env.next(c.panTick, c.keyOn)
+		}
+	}
+	period := clampPeriod(p.transpose(c.period+c.vibrato, c.arpeggio))
+	c.step = p.frequency(period) / float64(p.sampleRate)
+	pan = float64(c.panning) + float64(envPan-32)*(128-math.Abs(float64(c.panning)-128))/32
+	gain = float64(clampInt(c.volume+c.tremolo, 0, 64)) / 64 *
+		float64(envVolume) / 64 *
+		float64(c.fadeout) / moduleFadeMax *
+		float64(p.globalVolume) / 64 /
+		math.Sqrt(float64(len(p.channels)))
+	// Constant power panning, at full volume in the center.
+	c.gainLeft = float32(gain * math.Sqrt2 * math.Cos(pan/255*math.Pi/2))
+	c.gainRight = float32(gain * math.Sqrt2 * math.Sin(pan/255*math.Pi/2))
+}
+
+func (c *moduleChannel) tonePorta() {
+	var speed = float64(4 * c.portaSpeed)
+	if c.period < c.target {
+		c.period = math.Min(c.period+speed, c.target)
+	} else {
+		c.period = math.Max(c.period-speed, c.target)
+	}
+}
+
+func (c *moduleChannel) vibratoTick() {
+	c.vibrato = moduleSine(c.vibratoPos) * float64(c.vibratoDepth) * 8
+	c.vibratoPos = (c.vibratoPos + c.vibratoSpeed) & 63
+}
+
+func (c *moduleChannel) retrigger() {
+	if c.sample != nil {
+		c.position = 0
+		c.backwards = false
+		c.active = len(c.sample.Data) > 0
+	}
+}
+
+// Adds frames of the channel to out, or just advances it if out is nil.
+func (c *moduleChannel) mix(out []float32, frames int) {
+	if !c.active || c.sample == nil {
+		return
+	}
+	var (
+		data      = c.sample.Data
+		loopStart = float64(c.sample.LoopStart)
+		loopEnd   = float64(c.sample.LoopStart + c.sample.LoopLength)
+		looped    = c.sample.LoopLength > 0
+		end       = len(data)
+	)
+	if looped {
+		end = c.sample.LoopStart + c.sample.LoopLength
+	}
+	for i := 0; i < frames; i++ {
+		if out != nil {
+			var (
+				index = int(c.position)
+				frac  = float32(c.position - float64(index))
+				s0    = data[index]
+				s1    = s0
+			)
+			if index+1 < end {
+				s1 = data[index+1]
+			} else if looped && !c.sample.PingPong {
+				s1 = data[c.sample.LoopStart]
+			}
+			v := s0 + (s1-s0)*frac
+			out[i*2] += v * c.gainLeft
+			out[i*2+1] += v * c.gainRight
+		}
+		if c.backwards {
+			if c.position -= c.step; c.position < loopStart {
+				c.position = math.Min(2*loopStart-c.position, loopEnd-1)
+				c.backwards = false
+			}
+		} else if c.position += c.step; c.position >= float64(end) {
+			switch {
+			case !looped:
+				c.active = false
+				return
+			case c.sample.PingPong:
+				c.position = math.Max(2*(loopEnd-1)-c.position, loopStart)
+				c.backwards = true
+			default:
+				c.position = loopStart + math.Mod(c.position-loopStart, loopEnd-loopStart)
+			}
+		}
+	}
+}
+
+// Returns the sine of a 64 step vibrato or tremolo position.
+func moduleSine(pos int) float64 {
+	return math.Sin(float64(pos) * 2 * math.Pi / 64)
+}
+
+// Keeps the last non-zero parameter, as effects with zero parameters repeat
+// the previous one.
+func remember(memory *int, value int) {
+	if value != 0 {
+		*memory = value
+	}
+}
+
+// Returns the change from an up or down slide parameter, where the high
+// nibble slides up.
+func slideAmount(x int) int {
+	if x>>4 != 0 {
+		return x >> 4
+	}
+	return -(x & 0xF)
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func clampPeriod(period float64) float64 {
+	return math.Max(1, math.Min(period, 100000))
+}
@@ -0,0 +1,80 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"testing"
+)
+
+// One empty 16 row pattern at speed 6 and 125 BPM, which is 16*6 ticks of
+// 2.5/125 seconds each.
+func emptyModule() *Module {
+	return &Module{
+		Channels: 1,
+		Orders:   []int{0},
+		Patterns: []*ModulePattern{
+			{Rows: 16, Notes: make([]ModuleNote, 16)},
+		},
+		Speed: 6,
+		Tempo: 125,
+	}
+}
+
+func TestModulePlayerLength(t *testing.T) {
+	var p = NewModulePlayer(emptyModule(), 1000)
+	if got := p.Length(); got != 16*6*20 {
+		t.Fatalf("Length() = %v, want %v", got, 16*6*20)
+	}
+	p.SetTempo(250)
+	if got := p.Length(); got != 16*6*20 {
+		t.Errorf("Length() after speeding up mid-pass = %v, want %v", got, 16*6*20)
+	}
+	p.SetPosition(0)
+	if got := p.Tempo(); got != 250 {
+		t.Errorf("Tempo() after restarting = %v, want 250", got)
+	}
+	if got := p.Length(); got != 16*6*10 {
+		t.Errorf("Length() after restarting = %v, want %v", got, 16*6*10)
+	}
+	p.SetTempoScale(0.5)
+	p.SetSpeed(3)
+	p.SetPosition(0)
+	if got := p.Length(); got != 16*3*20 {
+		t.Errorf("Length() at half scale and speed 3 = %v, want %v", got, 16*3*20)
+	}
+	if got := p.Speed(); got != 3 {
+		t.Errorf("Speed() after restarting = %v, want 3", got)
+	}
+}
+
+func TestModulePlayerReadsToLength(t *testing.T) {
+	var (
+		p       = NewModulePlayer(emptyModule(), 1000)
+		samples = make([]float32, 256)
+		frames  int64
+	)
+	p.SetTempo(100)
+	p.SetPosition(0)
+	for {
+		n, err := p.Read(samples)
+		frames += int64(n / 2)
+		if err != nil {
+			break
+		}
+	}
+	if frames != p.Length() {
+		t.Errorf("Read %v frames, Length() = %v", frames, p.Length())
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"math/rand"
+	"time"
+)
+
+type Track struct {
+	Path      string
+	Volume    float32 // Zero plays at full volume.
+	Loop      bool
+	LoopStart time.Duration // Where looping restarts, after any intro.
+}
+
+// An ordered set of tracks for a MusicPlayer. Scenes which return playlists
+// with the same tracks and settings keep the music playing across scene
+// changes.
+type Playlist struct {
+	Tracks  []Track
+	Shuffle bool // Play tracks in a random order, reshuffled on each pass.
+	Loop    bool // Start again after the last track.
+	order   []int
+	next    int
+}
+
+func NewPlaylist(tracks ...Track) *Playlist {
+	return &Playlist{
+		Tracks: tracks,
+	}
+}
+
+// Returns the next track to play, or false at the end of a playlist which
+// doesn't loop.
+func (p *Playlist) nextTrack() (track Track, ok bool) {
+	if len(p.Tracks) == 0 {
+		return
+	}
+	if p.next >= len(p.order) {
+		if p.order != nil && !p.Loop {
+			return
+		}
+		p.order = make([]int, len(p.Tracks))
+		for i := range p.order {
+			p.order[i] = i
+		}
+		if p.Shuffle {
+			rand.Shuffle(len(p.order), func(i, j int) {
+				p.order[i], p.order[j] = p.order[j], p.order[i]
+			})
+		}
+		p.next = 0
+	}
+	track = p.Tracks[p.order[p.next]]
+	p.next++
+	ok = true
+	return
+}
+
+func (p *Playlist) sameAs(other *Playlist) bool {
+	if p == other {
+		return true
+	}
+	if p == nil || other == nil || p.Shuffle != other.Shuffle || p.Loop != other.Loop || len(p.Tracks) != len(other.Tracks) {
+		return false
+	}
+	for i := range p.Tracks {
+		if p.Tracks[i] != other.Tracks[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Playlist) reset() {
+	p.order = nil
+	p.next = 0
+}
+
+// Streams tracks from disk, crossfading when the track or playlist changes.
+// Call Update regularly to move through playlists.
+type MusicPlayer struct {
+	mixer    *Mixer
+	bus      *Bus
+	fade     time.Duration
+	voice    *Voice
+	track    *Track
+	playlist *Playlist
+}
+
+// Plays music on bus, or on the mixer's master bus if bus is nil. fade is the
+// crossfade used by playlists and scene changes.
+func NewMusicPlayer(mixer *Mixer, bus *Bus, fade time.Duration) *MusicPlayer {
+	if bus == nil {
+		bus = mixer.Master()
+	}
+	return &MusicPlayer{
+		mixer: mixer,
+		bus:   bus,
+		fade:  fade,
+	}
+}
+
+func (p *MusicPlayer) Bus() *Bus {
+	return p.bus
+}
+
+func (p *MusicPlayer) Fade() time.Duration {
+	return p.fade
+}
+
+func (p *MusicPlayer) SetFade(fade time.Duration) {
+	p.fade = fade
+}
+
+// Returns the track playing, or nil.
+func (p *MusicPlayer) Track() *Track {
+	return p.track
+}
+
+func (p *MusicPlayer) Playlist() *Playlist {
+	return p.playlist
+}
+
+// Crossfades to track over fade, stopping any playlist.
+func (p *MusicPlayer) Play(track Track, fade time.Duration) (err error) {
+	p.playlist = nil
+	return p.start(track, fade)
+}
+
+// Crossfades to the first track of playlist over fade. Does nothing if a
+// playlist with the same tracks and settings is already playing, so callers
+// may pass a new Playlist each time.
+func (p *MusicPlayer) PlayPlaylist(playlist *Playlist, fade time.Duration) (err error) {
+	var (
+		track Track
+		ok    bool
+	)
+	if p.playlist.sameAs(playlist) {
+		return
+	}
+	if playlist == nil {
+		p.Stop(fade)
+		return
+	}
+	p.playlist = playlist
+	playlist.reset()
+	if track, ok = playlist.nextTrack(); !ok {
+		p.fadeOut(fade)
+		return
+	}
+	return p.start(track, fade)
+}
+
+func (p *MusicPlayer) start(track Track, fade time.Duration) (err error) {
+	var (
+		stream Stream
+		volume = track.Volume
+	)
+	if stream, err = OpenStream(track.Path); err != nil {
+		return
+	}
+	p.fadeOut(fade)
+	if volume == 0 {
+		volume = 1
+	}
+	p.voice = p.mixer.play(newStreamSource(stream), p.bus, func(v *Voice) {
+		v.loop = track.Loop
+		v.setLoopStart(track.LoopStart)
+		v.volume = 0
+		v.fade(volume, fade, false)
+	})
+	p.track = &track
+	return
+}
+
+// Fades out the current track and any playlist over fade.
+func (p *MusicPlayer) Stop(fade time.Duration) {
+	p.playlist = nil
+	p.fadeOut(fade)
+}
+
+func (p *MusicPlayer) fadeOut(fade time.Duration) {
+	if p.voice != nil {
+		p.voice.FadeOut(fade)
+		p.voice = nil
+	}
+	p.track = nil
+}
+
+// Starts the next playlist track when the current one ends.
+func (p *MusicPlayer) Update() (err error) {
+	var (
+		track Track
+		ok    bool
+	)
+	if p.voice == nil || p.voice.Playing() || p.playlist == nil {
+		return
+	}
+	p.voice = nil
+	p.track = nil
+	if track, ok = p.playlist.nextTrack(); ok {
+		err = p.start(track, 0)
+	}
+	return
+}
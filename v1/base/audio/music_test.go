@@ -0,0 +1,104 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Writes one second WAV files named after each track into a temporary
+// directory and returns their paths.
+func testTracks(t *testing.T, names ...string) (paths []string) {
+	var dir = t.TempDir()
+	for _, name := range names {
+		var (
+			path  = filepath.Join(dir, name+".wav")
+			sound = &Sound{SampleRate: 10, Channels: 1, Samples: make([]float32, 10)}
+		)
+		if err := SaveWAV(path, sound); err != nil {
+			t.Fatalf("SaveWAV: %v", err)
+		}
+		paths = append(paths, path)
+	}
+	return
+}
+
+func TestMusicPlayerSamePlaylist(t *testing.T) {
+	var (
+		paths  = testTracks(t, "a", "b")
+		mixer  = NewMixer(10)
+		player = NewMusicPlayer(mixer, nil, time.Second)
+		scene  = func() *Playlist {
+			// Scenes often build their playlist on every call.
+			return &Playlist{Tracks: []Track{{Path: paths[0]}, {Path: paths[1]}}, Loop: true}
+		}
+	)
+	if err := player.PlayPlaylist(scene(), time.Second); err != nil {
+		t.Fatalf("PlayPlaylist: %v", err)
+	}
+	var voice = player.voice
+	for i := 0; i < 5; i++ {
+		mixLeft(mixer, 1)
+		if err := player.PlayPlaylist(scene(), time.Second); err != nil {
+			t.Fatalf("PlayPlaylist: %v", err)
+		}
+		if err := player.Update(); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+	}
+	if player.voice != voice || mixer.Voices() != 1 {
+		t.Errorf("Restarted an unchanged playlist, with %v voices", mixer.Voices())
+	}
+	if player.Track().Path != paths[0] {
+		t.Errorf("Playing %v, want %v", player.Track().Path, paths[0])
+	}
+}
+
+func TestMusicPlayerNewPlaylist(t *testing.T) {
+	var (
+		paths  = testTracks(t, "a", "b")
+		mixer  = NewMixer(10)
+		player = NewMusicPlayer(mixer, nil, time.Second)
+		tests  = []struct {
+			name     string
+			playlist *Playlist
+			path     string
+		}{
+			{"first", NewPlaylist(Track{Path: paths[0]}), paths[0]},
+			{"other track", NewPlaylist(Track{Path: paths[1]}), paths[1]},
+			{"other volume", NewPlaylist(Track{Path: paths[1], Volume: 0.5}), paths[1]},
+			{"looping", &Playlist{Tracks: []Track{{Path: paths[1], Volume: 0.5}}, Loop: true}, paths[1]},
+		}
+	)
+	for _, test := range tests {
+		var voice = player.voice
+		if err := player.PlayPlaylist(test.playlist, time.Second); err != nil {
+			t.Fatalf("%v: PlayPlaylist: %v", test.name, err)
+		}
+		if player.voice == voice {
+			t.Errorf("%v: kept the old voice", test.name)
+		}
+		if player.Playlist() != test.playlist || player.Track().Path != test.path {
+			t.Errorf("%v: playing %v", test.name, player.Track().Path)
+		}
+		mixLeft(mixer, 1)
+	}
+	// Earlier tracks are still fading out.
+	if mixer.Voices() != len(tests) {
+		t.Errorf("Got %v voices, want %v", mixer.Voices(), len(tests))
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"github.com/jfreymuth/oggvorbis"
+	"io"
+)
+
+func DecodeOgg(r io.Reader) (sound *Sound, err error) {
+	var (
+		samples []float32
+		format  *oggvorbis.Format
+	)
+	if samples, format, err = oggvorbis.ReadAll(r); err != nil {
+		return
+	}
+	sound = &Sound{
+		SampleRate: format.SampleRate,
+		Channels:   format.Channels,
+		Samples:    samples,
+	}
+	return
+}
@@ -0,0 +1,525 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"math"
+	"math/rand"
+)
+
+type Waveform int
+
+const (
+	WaveSquare Waveform = iota
+	WaveSawtooth
+	WaveSine
+	WaveNoise
+)
+
+// Parameters for a synthesized sound effect, following sfxr. Values range
+// from 0 to 1, or -1 to 1 for slides and other signed changes.
+type SFXParams struct {
+	Waveform Waveform
+	Volume   float32
+
+	AttackTime   float32
+	SustainTime  float32
+	SustainPunch float32 // Extra volume at the start of the sustain.
+	DecayTime    float32
+
+	StartFrequency float32
+	MinFrequency   float32 // Sound stops when a downward slide reaches it.
+	Slide          float32
+	DeltaSlide     float32
+	VibratoDepth   float32
+	VibratoSpeed   float32
+
+	ChangeAmount float32 // Arpeggio: jump in pitch, up when positive.
+	ChangeSpeed  float32 // How soon the jump happens.
+
+	SquareDuty float32
+	DutySweep  float32
+
+	RepeatSpeed float32 // Restarts the frequency envelope. Zero disables.
+
+	PhaserOffset float32
+	PhaserSweep  float32
+
+	LowPassCutoff      float32 // One disables the low pass filter.
+	LowPassCutoffSweep float32
+	LowPassResonance   float32
+	HighPassCutoff     float32
+	HighPassSweep      float32
+
+	NoiseSeed int64
+}
+
+func DefaultSFXParams() SFXParams {
+	return SFXParams{
+		Volume:         0.5,
+		StartFrequency: 0.3,
+		SustainTime:    0.3,
+		DecayTime:      0.4,
+		LowPassCutoff:  1,
+	}
+}
+
+// Random values as sfxr generates them.
+type sfxRand struct {
+	*rand.Rand
+}
+
+func newSFXRand(seed int64) sfxRand {
+	return sfxRand{rand.New(rand.NewSource(seed))}
+}
+
+// Returns a value from 0 to max.
+func (r sfxRand) f(max float32) float32 {
+	return r.Float32() * max
+}
+
+// Returns whether a one in n chance came up.
+func (r sfxRand) chance(n int) bool {
+	return r.Intn(n) == 0
+}
+
+func pow(v float32, e float64) float32 {
+	return float32(math.Pow(float64(v), e))
+}
+
+func PickupSFX(seed int64) (p SFXParams) {
+	var r = newSFXRand(seed)
+	p = DefaultSFXParams()
+	p.NoiseSeed = seed
+	p.StartFrequency = 0.4 + r.f(0.5)
+	p.SustainTime = r.f(0.1)
+	p.DecayTime = 0.1 + r.f(0.4)
+	p.SustainPunch = 0.3 + r.f(0.3)
+	if r.chance(2) {
+		p.ChangeSpeed = 0.5 + r.f(0.2)
+		p.ChangeAmount = 0.2 + r.f(0.4)
+	}
+	return
+}
+
+func LaserSFX(seed int64) (p SFXParams) {
+	var r = newSFXRand(seed)
+	p = DefaultSFXParams()
+	p.NoiseSeed = seed
+	p.Waveform = Waveform(r.Intn(3))
+	if p.Waveform == WaveSine && r.chance(2) {
+		p.Waveform = Waveform(r.Intn(2))
+	}
+	p.StartFrequency = 0.5 + r.f(0.5)
+	if p.MinFrequency = p.StartFrequency - 0.2 - r.f(0.6); p.MinFrequency < 0.2 {
+		p.MinFrequency = 0.2
+	}
+	p.Slide = -0.15 - r.f(0.2)
+	if r.chance(3) {
+		p.StartFrequency = 0.3 + r.f(0.6)
+		p.MinFrequency = r.f(0.1)
+		p.Slide = -0.35 - r.f(0.3)
+	}
+	if r.chance(2) {
+		p.SquareDuty = r.f(0.5)
+		p.DutySweep = r.f(0.2)
+	} else {
+		p.SquareDuty = 0.4 + r.f(0.5)
+		p.DutySweep = -r.f(0.7)
+	}
+	p.SustainTime = 0.1 + r.f(0.2)
+	p.DecayTime = r.f(0.4)
+	if r.chance(2) {
+		p.SustainPunch = r.f(0.3)
+	}
+	if r.chance(3) {
+		p.PhaserOffset = r.f(0.2)
+		p.PhaserSweep = -r.f(0.2)
+	}
+	if r.chance(2) {
+		p.HighPassCutoff = r.f(0.3)
+	}
+	return
+}
+
+func ExplosionSFX(seed int64) (p SFXParams) {
+	var r = newSFXRand(seed)
+	p = DefaultSFXParams()
+	p.NoiseSeed = seed
+	p.Waveform = WaveNoise
+	if r.chance(2) {
+		p.StartFrequency = 0.1 + r.f(0.4)
+		p.Slide = -0.1 + r.f(0.4)
+	} else {
+		p.StartFrequency = 0.2 + r.f(0.7)
+		p.Slide = -0.2 - r.f(0.2)
+	}
+	p.StartFrequency *= p.StartFrequency
+	if r.chance(5) {
+		p.Slide = 0
+	}
+	if r.chance(3) {
+		p.RepeatSpeed = 0.3 + r.f(0.5)
+	}
+	p.SustainTime = 0.1 + r.f(0.3)
+	p.DecayTime = r.f(0.5)
+	if r.chance(2) {
+		p.PhaserOffset = -0.3 + r.f(0.9)
+		p.PhaserSweep = -r.f(0.3)
+	}
+	p.SustainPunch = 0.2 + r.f(0.6)
+	if r.chance(2) {
+		p.VibratoDepth = r.f(0.7)
+		p.VibratoSpeed = r.f(0.6)
+	}
+	if r.chance(3) {
+		p.ChangeSpeed = 0.6 + r.f(0.3)
+		p.ChangeAmount = 0.8 - r.f(1.6)
+	}
+	return
+}
+
+func JumpSFX(seed int64) (p SFXParams) {
+	var r = newSFXRand(seed)
+	p = DefaultSFXParams()
+	p.NoiseSeed = seed
+	p.Waveform = WaveSquare
+	p.SquareDuty = r.f(0.6)
+	p.StartFrequency = 0.3 + r.f(0.3)
+	p.Slide = 0.1 + r.f(0.2)
+	p.SustainTime = 0.1 + r.f(0.3)
+	p.DecayTime = 0.1 + r.f(0.2)
+	if r.chance(2) {
+		p.HighPassCutoff = r.f(0.3)
+	}
+	if r.chance(2) {
+		p.LowPassCutoff = 1 - r.f(0.6)
+	}
+	return
+}
+
+func HitSFX(seed int64) (p SFXParams) {
+	var r = newSFXRand(seed)
+	p = DefaultSFXParams()
+	p.NoiseSeed = seed
+	p.Waveform = Waveform(r.Intn(3))
+	if p.Waveform == WaveSine {
+		p.Waveform = WaveNoise
+	}
+	if p.Waveform == WaveSquare {
+		p.SquareDuty = r.f(0.6)
+	}
+	p.StartFrequency = 0.2 + r.f(0.6)
+	p.Slide = -0.3 - r.f(0.4)
+	p.SustainTime = r.f(0.1)
+	p.DecayTime = 0.1 + r.f(0.2)
+	if r.chance(2) {
+		p.HighPassCutoff = r.f(0.3)
+	}
+	return
+}
+
+// Returns parameters with every value randomized, as with sfxr's randomize
+// button.
+func RandomSFX(seed int64) (p SFXParams) {
+	var (
+		r      = newSFXRand(seed)
+		signed = func() float32 { return r.f(2) - 1 }
+	)
+	p = DefaultSFXParams()
+	p.NoiseSeed = seed
+	p.Waveform = Waveform(r.Intn(4))
+	if p.StartFrequency = pow(signed(), 2); r.chance(2) {
+		p.StartFrequency = pow(signed(), 3) + 0.5
+	}
+	p.Slide = pow(signed(), 5)
+	if p.StartFrequency > 0.7 && p.Slide > 0.2 {
+		p.Slide = -p.Slide
+	}
+	if p.StartFrequency < 0.2 && p.Slide < -0.05 {
+		p.Slide = -p.Slide
+	}
+	p.DeltaSlide = pow(signed(), 3)
+	p.SquareDuty = signed()
+	p.DutySweep = pow(signed(), 3)
+	p.VibratoDepth = pow(signed(), 3)
+	p.VibratoSpeed = signed()
+	p.AttackTime = pow(signed(), 3)
+	p.SustainTime = pow(signed(), 2)
+	p.DecayTime = signed()
+	p.SustainPunch = pow(r.f(0.8), 2)
+	if p.AttackTime+p.SustainTime+p.DecayTime < 0.2 {
+		p.SustainTime += 0.2 + r.f(0.3)
+		p.DecayTime += 0.2 + r.f(0.3)
+	}
+	p.LowPassResonance = signed()
+	p.LowPassCutoff = 1 - pow(r.f(1), 3)
+	p.LowPassCutoffSweep = pow(signed(), 3)
+	if p.LowPassCutoff < 0.1 && p.LowPassCutoffSweep < -0.05 {
+		p.LowPassCutoffSweep = -p.LowPassCutoffSweep
+	}
+	p.HighPassCutoff = pow(r.f(1), 5)
+	p.HighPassSweep = pow(signed(), 5)
+	p.PhaserOffset = pow(signed(), 3)
+	p.PhaserSweep = pow(signed(), 3)
+	p.RepeatSpeed = signed()
+	p.ChangeSpeed = signed()
+	p.ChangeAmount = signed()
+	return p.clamped()
+}
+
+// Limits unsigned values to 0 to 1 and signed ones to -1 to 1.
+func (p SFXParams) clamped() SFXParams {
+	for _, v := range []*float32{
+		&p.AttackTime, &p.SustainTime, &p.SustainPunch, &p.DecayTime,
+		&p.StartFrequency, &p.MinFrequency, &p.VibratoDepth, &p.VibratoSpeed,
+		&p.ChangeSpeed, &p.SquareDuty, &p.RepeatSpeed, &p.LowPassCutoff,
+		&p.LowPassResonance, &p.HighPassCutoff,
+	} {
+		*v = clamp(*v, 0, 1)
+	}
+	for _, v := range []*float32{
+		&p.Slide, &p.DeltaSlide, &p.ChangeAmount, &p.DutySweep,
+		&p.PhaserOffset, &p.PhaserSweep, &p.LowPassCutoffSweep, &p.HighPassSweep,
+	} {
+		*v = clamp(*v, -1, 1)
+	}
+	return p
+}
+
+const sfxSampleRate = 44100
+
+// State of the synthesizer between samples.
+type sfxSynth struct {
+	p      SFXParams
+	noise  *rand.Rand
+	period float64
+	maxPer float64
+	slide  float64
+	dslide float64
+	duty   float32
+	dduty  float32
+	arpMod float64
+	arpT   int
+	arpLim int
+	phase  int
+	// Low and high pass filter.
+	fltp, fltdp, fltw, fltwd, fltdmp, fltphp, flthp, flthpd float32
+	vibPhase, vibSpeed, vibAmp                              float64
+	envVol                                                  float32
+	envStage, envTime                                       int
+	envLength                                               [3]int
+	phaserPhase, phaserDelta                                float32
+	phaserOffset, phaserPos                                 int
+	phaserBuffer                                            [1024]float32
+	noiseBuffer                                             [32]float32
+	repT, repLim                                            int
+}
+
+// Sets up the frequency envelope, and everything else unless restarting
+// for RepeatSpeed.
+func (s *sfxSynth) reset(restart bool) {
+	var p = s.p
+	s.period = 100 / float64(p.StartFrequency*p.StartFrequency+0.001)
+	s.maxPer = 100 / float64(p.MinFrequency*p.MinFrequency+0.001)
+	s.slide = 1 - math.Pow(float64(p.Slide), 3)*0.01
+	s.dslide = -math.Pow(float64(p.DeltaSlide), 3) * 0.000001
+	s.duty = 0.5 - p.SquareDuty*0.5
+	s.dduty = -p.DutySweep * 0.00005
+	if p.ChangeAmount >= 0 {
+		s.arpMod = 1 - math.Pow(float64(p.ChangeAmount), 2)*0.9
+	} else {
+		s.arpMod = 1 + math.Pow(float64(p.ChangeAmount), 2)*10
+	}
+	s.arpT = 0
+	if s.arpLim = int(math.Pow(float64(1-p.ChangeSpeed), 2)*20000 + 32); p.ChangeSpeed == 1 {
+		s.arpLim = 0
+	}
+	if restart {
+		return
+	}
+	s.phase = 0
+	s.fltp, s.fltdp = 0, 0
+	s.fltw = pow(p.LowPassCutoff, 3) * 0.1
+	s.fltwd = 1 + p.LowPassCutoffSweep*0.0001
+	if s.fltdmp = 5 / (1 + pow(p.LowPassResonance, 2)*20) * (0.01 + s.fltw); s.fltdmp > 0.8 {
+		s.fltdmp = 0.8
+	}
+	s.fltphp = 0
+	s.flthp = pow(p.HighPassCutoff, 2) * 0.1
+	s.flthpd = 1 + p.HighPassSweep*0.0003
+	s.vibPhase = 0
+	s.vibSpeed = math.Pow(float64(p.VibratoSpeed), 2) * 0.01
+	s.vibAmp = float64(p.VibratoDepth) * 0.5
+	s.envVol, s.envStage, s.envTime = 0, 0, 0
+	s.envLength = [3]int{
+		int(p.AttackTime * p.AttackTime * 100000),
+		int(p.SustainTime * p.SustainTime * 100000),
+		int(p.DecayTime * p.DecayTime * 100000),
+	}
+	// Avoid dividing by zero in empty stages.
+	for i := range s.envLength {
+		if s.envLength[i] < 1 {
+			s.envLength[i] = 1
+		}
+	}
+	if s.phaserPhase = pow(p.PhaserOffset, 2) * 1020; p.PhaserOffset < 0 {
+		s.phaserPhase = -s.phaserPhase
+	}
+	if s.phaserDelta = pow(p.PhaserSweep, 2); p.PhaserSweep < 0 {
+		s.phaserDelta = -s.phaserDelta
+	}
+	s.phaserOffset = abs(int(s.phaserPhase))
+	s.phaserPos = 0
+	s.phaserBuffer = [1024]float32{}
+	for i := range s.noiseBuffer {
+		s.noiseBuffer[i] = s.noise.Float32()*2 - 1
+	}
+	s.repT = 0
+	if s.repLim = int(math.Pow(float64(1-p.RepeatSpeed), 2)*20000 + 32); p.RepeatSpeed == 0 {
+		s.repLim = 0
+	}
+}
+
+// Returns the next sample, or false once the sound has finished.
+func (s *sfxSynth) sample() (out float32, ok bool) {
+	var (
+		p        = s.p
+		rfperiod float64
+		period   int
+	)
+	if s.repT++; s.repLim != 0 && s.repT >= s.repLim {
+		s.reset(true)
+	}
+	if s.arpT++; s.arpLim != 0 && s.arpT >= s.arpLim {
+		s.arpLim = 0
+		s.period *= s.arpMod
+	}
+	s.slide += s.dslide
+	s.period *= s.slide
+	if s.period > s.maxPer {
+		s.period = s.maxPer
+		if p.MinFrequency > 0 {
+			return
+		}
+	}
+	rfperiod = s.period
+	if s.vibAmp > 0 {
+		s.vibPhase += s.vibSpeed
+		rfperiod = s.period * (1 + math.Sin(s.vibPhase)*s.vibAmp)
+	}
+	if period = int(rfperiod); period < 8 {
+		period = 8
+	}
+	s.duty = clamp(s.duty+s.dduty, 0, 0.5)
+	if s.envTime++; s.envTime > s.envLength[s.envStage] {
+		s.envTime = 0
+		if s.envStage++; s.envStage == 3 {
+			return
+		}
+	}
+	switch s.envStage {
+	case 0:
+		s.envVol = float32(s.envTime) / float32(s.envLength[0])
+	case 1:
+		s.envVol = 1 + (1-float32(s.envTime)/float32(s.envLength[1]))*2*p.SustainPunch
+	case 2:
+		s.envVol = 1 - float32(s.envTime)/float32(s.envLength[2])
+	}
+	s.phaserPhase += s.phaserDelta
+	if s.phaserOffset = abs(int(s.phaserPhase)); s.phaserOffset > 1023 {
+		s.phaserOffset = 1023
+	}
+	if s.flthpd != 0 {
+		s.flthp = clamp(s.flthp*s.flthpd, 0.00001, 0.1)
+	}
+	// Supersample eight times.
+	for i := 0; i < 8; i++ {
+		var sample float32
+		if s.phase++; s.phase >= period {
+			s.phase %= period
+			if p.Waveform == WaveNoise {
+				for j := range s.noiseBuffer {
+					s.noiseBuffer[j] = s.noise.Float32()*2 - 1
+				}
+			}
+		}
+		fp := float32(s.phase) / float32(period)
+		switch p.Waveform {
+		case WaveSquare:
+			if sample = -0.5; fp < s.duty {
+				sample = 0.5
+			}
+		case WaveSawtooth:
+			sample = 1 - fp*2
+		case WaveSine:
+			sample = float32(math.Sin(float64(fp) * 2 * math.Pi))
+		case WaveNoise:
+			sample = s.noiseBuffer[s.phase*32/period]
+		}
+		pp := s.fltp
+		s.fltw = clamp(s.fltw*s.fltwd, 0, 0.1)
+		if p.LowPassCutoff != 1 {
+			s.fltdp += (sample - s.fltp) * s.fltw
+			s.fltdp -= s.fltdp * s.fltdmp
+		} else {
+			s.fltp = sample
+			s.fltdp = 0
+		}
+		s.fltp += s.fltdp
+		s.fltphp += s.fltp - pp
+		s.fltphp -= s.fltphp * s.flthp
+		sample = s.fltphp
+		s.phaserBuffer[s.phaserPos&1023] = sample
+		sample += s.phaserBuffer[(s.phaserPos-s.phaserOffset+1024)&1023]
+		s.phaserPos = (s.phaserPos + 1) & 1023
+		out += sample * s.envVol
+	}
+	// The phaser doubles the signal even when it has no offset.
+	out = clamp(out/8*p.Volume*0.5, -1, 1)
+	ok = true
+	return
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// Synthesizes the effect as a mono sound at 44.1kHz. The same parameters
+// always produce the same samples.
+func (p SFXParams) Generate() (sound *Sound) {
+	var (
+		synth = &sfxSynth{
+			p:     p.clamped(),
+			noise: rand.New(rand.NewSource(p.NoiseSeed)),
+		}
+		sample float32
+		ok     bool
+	)
+	synth.reset(false)
+	sound = &Sound{
+		SampleRate: sfxSampleRate,
+		Channels:   1,
+	}
+	for {
+		if sample, ok = synth.sample(); !ok {
+			return
+		}
+		sound.Samples = append(sound.Samples, sample)
+	}
+}
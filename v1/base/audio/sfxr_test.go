@@ -0,0 +1,104 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testSFXPresets = []struct {
+	name   string
+	preset func(seed int64) SFXParams
+}{
+	{"pickup", PickupSFX},
+	{"laser", LaserSFX},
+	{"explosion", ExplosionSFX},
+	{"jump", JumpSFX},
+	{"hit", HitSFX},
+	{"random", RandomSFX},
+	{"default", func(seed int64) SFXParams { return DefaultSFXParams() }},
+}
+
+func TestSFXPresetsAreAudible(t *testing.T) {
+	for _, test := range testSFXPresets {
+		for seed := int64(1); seed <= 8; seed++ {
+			var (
+				sound = test.preset(seed).Generate()
+				peak  float64
+			)
+			if sound.SampleRate != sfxSampleRate || sound.Channels != 1 || len(sound.Samples) == 0 {
+				t.Errorf("%v %v: %v samples of %v channels at %v Hz", test.name, seed,
+					len(sound.Samples), sound.Channels, sound.SampleRate)
+				continue
+			}
+			for i, sample := range sound.Samples {
+				if math.IsNaN(float64(sample)) || math.IsInf(float64(sample), 0) || sample < -1 || sample > 1 {
+					t.Errorf("%v %v: sample %v is %v", test.name, seed, i, sample)
+					break
+				}
+				peak = math.Max(peak, math.Abs(float64(sample)))
+			}
+			if peak < 0.01 {
+				t.Errorf("%v %v: silent, peaking at %v", test.name, seed, peak)
+			}
+		}
+	}
+}
+
+func TestSFXSameSeedSameSamples(t *testing.T) {
+	for _, test := range testSFXPresets {
+		var (
+			a = test.preset(42).Generate()
+			b = test.preset(42).Generate()
+		)
+		if !equalSamples(a.Samples, b.Samples) {
+			t.Errorf("%v: seed 42 gave different samples", test.name)
+		}
+	}
+	if equalSamples(ExplosionSFX(1).Generate().Samples, ExplosionSFX(2).Generate().Samples) {
+		t.Errorf("Different seeds gave the same explosion")
+	}
+}
+
+func TestSFXSaveWAV(t *testing.T) {
+	var (
+		path  = filepath.Join(t.TempDir(), "laser.wav")
+		sound = LaserSFX(7).Generate()
+	)
+	if err := SaveWAV(path, sound); err != nil {
+		t.Fatalf("SaveWAV: %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	decoded, err := DecodeWAV(f)
+	if err != nil {
+		t.Fatalf("DecodeWAV: %v", err)
+	}
+	if decoded.SampleRate != sound.SampleRate || decoded.Channels != 1 || len(decoded.Samples) != len(sound.Samples) {
+		t.Fatalf("Decoded %v samples at %v Hz, want %v at %v Hz",
+			len(decoded.Samples), decoded.SampleRate, len(sound.Samples), sound.SampleRate)
+	}
+	for i, sample := range sound.Samples {
+		if math.Abs(float64(decoded.Samples[i]-sample)) > 1.0/16384 {
+			t.Fatalf("Sample %v = %v, want %v", i, decoded.Samples[i], sample)
+		}
+	}
+}
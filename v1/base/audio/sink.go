@@ -0,0 +1,92 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"encoding/binary"
+	"os"
+)
+
+// Receives mixed interleaved stereo samples, such as a sound card or a file.
+type Sink interface {
+	Write(samples []float32) error
+	Close() error
+}
+
+// Discards samples, counting them.
+type NullSink struct {
+	Frames int
+}
+
+func NewNullSink() *NullSink {
+	return &NullSink{}
+}
+
+func (s *NullSink) Write(samples []float32) error {
+	s.Frames += len(samples) / 2
+	return nil
+}
+
+func (s *NullSink) Close() error {
+	return nil
+}
+
+// Records samples to a 16 bit stereo WAV file.
+type WAVSink struct {
+	file       *os.File
+	sampleRate int
+	size       int
+	buffer     []int16
+}
+
+func NewWAVSink(path string, sampleRate int) (s *WAVSink, err error) {
+	var file *os.File
+	if file, err = os.Create(path); err != nil {
+		return
+	}
+	// Sizes are filled in on Close.
+	if err = writeWAVHeader(file, sampleRate, 2, 0); err != nil {
+		file.Close()
+		return
+	}
+	s = &WAVSink{
+		file:       file,
+		sampleRate: sampleRate,
+	}
+	return
+}
+
+func (s *WAVSink) Write(samples []float32) (err error) {
+	s.buffer = pcm16(samples, s.buffer)
+	if err = binary.Write(s.file, binary.LittleEndian, s.buffer); err != nil {
+		return
+	}
+	s.size += len(s.buffer) * 2
+	return
+}
+
+func (s *WAVSink) Close() (err error) {
+	if s.file == nil {
+		return
+	}
+	if _, err = s.file.Seek(0, 0); err == nil {
+		err = writeWAVHeader(s.file, s.sampleRate, 2, s.size)
+	}
+	if e := s.file.Close(); err == nil {
+		err = e
+	}
+	s.file = nil
+	return
+}
@@ -0,0 +1,75 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Decoded PCM audio.
+type Sound struct {
+	SampleRate int
+	Channels   int
+	Samples    []float32 // Interleaved by channel, from -1 to 1.
+}
+
+// Loads a WAV or Ogg Vorbis file, chosen by extension.
+func LoadSound(p string) (sound *Sound, err error) {
+	var f *os.File
+	if f, err = os.Open(p); err != nil {
+		return
+	}
+	defer f.Close()
+	switch strings.ToLower(path.Ext(p)) {
+	case ".wav":
+		sound, err = DecodeWAV(f)
+	case ".ogg":
+		sound, err = DecodeOgg(f)
+	default:
+		err = fmt.Errorf("Unsupported sound format %v", p)
+	}
+	return
+}
+
+func (s *Sound) Frames() int {
+	if s.Channels == 0 {
+		return 0
+	}
+	return len(s.Samples) / s.Channels
+}
+
+func (s *Sound) Duration() time.Duration {
+	if s.SampleRate == 0 {
+		return 0
+	}
+	return time.Duration(s.Frames()) * time.Second / time.Duration(s.SampleRate)
+}
+
+// Returns the stereo samples of frame i. Mono sounds play in both channels
+// and channels past the second are dropped.
+func (s *Sound) frame(i int) (l, r float32) {
+	var base = i * s.Channels
+	l = s.Samples[base]
+	if s.Channels > 1 {
+		r = s.Samples[base+1]
+	} else {
+		r = l
+	}
+	return
+}
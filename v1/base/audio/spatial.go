@@ -0,0 +1,204 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pikkpoiss/gamejam/v1/base/core"
+)
+
+// Something in the world which makes sound, such as a render.Instance.
+type Emitter interface {
+	Position() mgl32.Vec3
+}
+
+// A fixed position in the world.
+type Point mgl32.Vec3
+
+func (p Point) Position() mgl32.Vec3 {
+	return mgl32.Vec3(p)
+}
+
+type Falloff int
+
+const (
+	FalloffLinear    Falloff = iota
+	FalloffQuadratic         // Drops quickly near the source.
+	FalloffInverse           // MinDistance / distance, cut off at MaxDistance.
+)
+
+// Distances are in world units.
+type SpatialConfig struct {
+	MinDistance float32 // Full volume within this distance.
+	MaxDistance float32 // Silent beyond this distance. Zero is 20.
+	Falloff     Falloff
+	// Overrides Falloff, mapping 0 at MinDistance to 1 at MaxDistance onto
+	// a volume.
+	Curve func(t float32) float32
+	// Horizontal distance at which sounds pan fully to one side. Zero uses
+	// MaxDistance.
+	PanWidth float32
+}
+
+func (c SpatialConfig) withDefaults() SpatialConfig {
+	if c.MaxDistance <= 0 {
+		c.MaxDistance = 20
+	}
+	return c
+}
+
+type spatialVoice struct {
+	voice   *Voice
+	emitter Emitter
+}
+
+// Pans and attenuates voices by their distance from a listener, usually the
+// camera. Pitch is never changed, so moving sources don't warble.
+type Spatializer struct {
+	mixer    *Mixer
+	cfg      SpatialConfig
+	listener mgl32.Vec3
+	voices   []spatialVoice
+}
+
+func NewSpatializer(mixer *Mixer, cfg SpatialConfig) *Spatializer {
+	return &Spatializer{
+		mixer: mixer,
+		cfg:   cfg.withDefaults(),
+	}
+}
+
+func (s *Spatializer) Config() SpatialConfig {
+	return s.cfg
+}
+
+func (s *Spatializer) SetConfig(cfg SpatialConfig) {
+	s.cfg = cfg.withDefaults()
+}
+
+func (s *Spatializer) Listener() mgl32.Vec3 {
+	return s.listener
+}
+
+// Plays sound from emitter, following it as it moves. bus may be nil as with
+// Mixer.Play.
+func (s *Spatializer) Play(sound *Sound, bus *Bus, emitter Emitter) (v *Voice) {
+	var position = emitter.Position()
+	v = s.mixer.play(sound, bus, func(v *Voice) {
+		v.sound = sound
+		s.place(v, position)
+	})
+	s.voices = append(s.voices, spatialVoice{
+		voice:   v,
+		emitter: emitter,
+	})
+	return
+}
+
+func (s *Spatializer) PlayAt(sound *Sound, bus *Bus, position mgl32.Vec3) *Voice {
+	return s.Play(sound, bus, Point(position))
+}
+
+// Positions a voice which is already playing, such as a stream.
+func (s *Spatializer) Attach(v *Voice, emitter Emitter) {
+	var position = emitter.Position()
+	s.voices = append(s.voices, spatialVoice{
+		voice:   v,
+		emitter: emitter,
+	})
+	s.mixer.lock.Lock()
+	s.place(v, position)
+	s.mixer.lock.Unlock()
+}
+
+// Returns the volume for a distance from the listener.
+func (s *Spatializer) attenuation(distance float32) float32 {
+	var (
+		min = s.cfg.MinDistance
+		max = s.cfg.MaxDistance
+		t   float32
+	)
+	if distance <= min {
+		return 1
+	}
+	if max <= min || distance >= max {
+		return 0
+	}
+	t = (distance - min) / (max - min)
+	switch {
+	case s.cfg.Curve != nil:
+		return clamp(s.cfg.Curve(t), 0, 1)
+	case s.cfg.Falloff == FalloffQuadratic:
+		return (1 - t) * (1 - t)
+	case s.cfg.Falloff == FalloffInverse:
+		if min <= 0 {
+			min = 1
+		}
+		return clamp(min/distance, 0, 1)
+	}
+	return 1 - t
+}
+
+// Sets the voice's attenuation and pan, culling it when out of range. Called
+// with the mixer locked, or before the voice is playing.
+func (s *Spatializer) place(v *Voice, position mgl32.Vec3) {
+	var (
+		delta = position.Sub(s.listener).Vec2()
+		width = s.cfg.PanWidth
+	)
+	if width <= 0 {
+		width = s.cfg.MaxDistance
+	}
+	v.distance = s.attenuation(delta.Len())
+	if width > 0 {
+		v.pan = clamp(delta.X()/width, -1, 1)
+	}
+	v.culled = v.distance == 0
+}
+
+// Moves the listener to the camera's center and repositions every voice.
+// Voices out of range are culled: one-shot sounds stop and looping ones are
+// skipped until they come back into range. Voices which start out of range
+// are silent until then.
+func (s *Spatializer) Update(camera *core.Camera) {
+	s.SetListener(camera.WorldCenter)
+}
+
+func (s *Spatializer) SetListener(listener mgl32.Vec3) {
+	var live = s.voices[:0]
+	s.listener = listener
+	s.mixer.lock.Lock()
+	defer s.mixer.lock.Unlock()
+	for _, sv := range s.voices {
+		if !sv.voice.playing {
+			continue
+		}
+		s.place(sv.voice, sv.emitter.Position())
+		if sv.voice.culled && !sv.voice.loop {
+			sv.voice.playing = false
+			continue
+		}
+		live = append(live, sv)
+	}
+	for i := len(live); i < len(s.voices); i++ {
+		s.voices[i] = spatialVoice{}
+	}
+	s.voices = live
+}
+
+// Returns the number of positioned voices still playing.
+func (s *Spatializer) Voices() int {
+	return len(s.voices)
+}
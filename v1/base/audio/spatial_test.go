@@ -0,0 +1,113 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"testing"
+)
+
+func TestSpatializerFalloff(t *testing.T) {
+	var tests = []struct {
+		name string
+		cfg  SpatialConfig
+		want []float32 // At distances 0, 2, 4, 6, 10 and 12.
+	}{
+		{"linear", SpatialConfig{MinDistance: 2, MaxDistance: 10}, []float32{1, 1, 0.75, 0.5, 0, 0}},
+		{"quadratic", SpatialConfig{MinDistance: 2, MaxDistance: 10, Falloff: FalloffQuadratic}, []float32{1, 1, 0.5625, 0.25, 0, 0}},
+		{"inverse", SpatialConfig{MinDistance: 2, MaxDistance: 10, Falloff: FalloffInverse}, []float32{1, 1, 0.5, 1.0 / 3, 0, 0}},
+		{"curve", SpatialConfig{MinDistance: 2, MaxDistance: 10, Curve: func(t float32) float32 {
+			return 2 - 4*t
+		}}, []float32{1, 1, 1, 0, 0, 0}},
+		{"zero config", SpatialConfig{}, []float32{1, 0.9, 0.8, 0.7, 0.5, 0.4}},
+	}
+	for _, test := range tests {
+		var s = NewSpatializer(NewMixer(10), test.cfg)
+		for i, distance := range []float32{0, 2, 4, 6, 10, 12} {
+			if got := s.attenuation(distance); !approx(got, test.want[i]) {
+				t.Errorf("%v: attenuation at %v = %v, want %v", test.name, distance, got, test.want[i])
+			}
+		}
+	}
+}
+
+func TestSpatializerPan(t *testing.T) {
+	var (
+		sound = &Sound{SampleRate: 10, Channels: 1, Samples: []float32{0.5, 0.5, 0.5, 0.5}}
+		tests = []struct {
+			name string
+			x    float32
+			pan  int // Sign of the pan.
+		}{
+			{"left", -4, -1},
+			{"center", 0, 0},
+			{"right", 4, 1},
+		}
+	)
+	for _, test := range tests {
+		var (
+			mixer = NewMixer(10)
+			s     = NewSpatializer(mixer, SpatialConfig{MaxDistance: 10})
+			out   = make([]float32, 2)
+		)
+		s.SetListener(mgl32.Vec3{1, 1, 0})
+		s.PlayAt(sound, nil, mgl32.Vec3{1 + test.x, 1, 0})
+		mixer.Mix(out)
+		var left, right = out[0], out[1]
+		switch {
+		case test.pan < 0 && !(left > right):
+			t.Errorf("%v: got %v left and %v right, want louder left", test.name, left, right)
+		case test.pan > 0 && !(right > left):
+			t.Errorf("%v: got %v left and %v right, want louder right", test.name, left, right)
+		case test.pan == 0 && !approx(left, right):
+			t.Errorf("%v: got %v left and %v right, want centered", test.name, left, right)
+		}
+		if left == 0 && right == 0 {
+			t.Errorf("%v: silent", test.name)
+		}
+	}
+}
+
+// Out of range, one-shot voices stop while looping ones wait silently until
+// they come back into range.
+func TestSpatializerCulling(t *testing.T) {
+	var (
+		sound   = &Sound{SampleRate: 10, Channels: 1, Samples: []float32{0.1, 0.1, 0.1, 0.1, 0.1, 0.1, 0.1, 0.1}}
+		mixer   = NewMixer(10)
+		s       = NewSpatializer(mixer, SpatialConfig{})
+		oneShot = s.PlayAt(sound, nil, mgl32.Vec3{5, 0, 0})
+		looping = s.PlayAt(sound, nil, mgl32.Vec3{-5, 0, 0})
+	)
+	looping.SetLoop(true)
+	if left := mixLeft(mixer, 1); left[0] == 0 {
+		t.Fatalf("Voices in range are silent")
+	}
+	s.SetListener(mgl32.Vec3{100, 0, 0})
+	if oneShot.Playing() {
+		t.Errorf("One-shot voice out of range still playing")
+	}
+	if !looping.Playing() || s.Voices() != 1 {
+		t.Errorf("Looping voice out of range stopped, %v voices left", s.Voices())
+	}
+	for _, sample := range mixLeft(mixer, 10) {
+		if sample != 0 {
+			t.Fatalf("Culled voice mixed %v", sample)
+		}
+	}
+	s.SetListener(mgl32.Vec3{0, 0, 0})
+	if left := mixLeft(mixer, 1); left[0] == 0 {
+		t.Errorf("Looping voice silent back in range")
+	}
+}
@@ -0,0 +1,336 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"fmt"
+	"github.com/jfreymuth/oggvorbis"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Decodes audio incrementally, such as music too long to keep in memory.
+type Stream interface {
+	SampleRate() int
+	Channels() int
+	// Total frames in the stream. Streams such as modules with a changed
+	// tempo may hit io.EOF sooner, which then marks the end.
+	Length() int64
+	// Fills samples, interleaved by channel, returning io.EOF at the end.
+	Read(samples []float32) (n int, err error)
+	// Moves to a frame so that the next Read starts there.
+	SetPosition(frame int64) error
+	Close() error
+}
+
+// Opens a WAV or Ogg Vorbis file for streaming, chosen by extension.
+func OpenStream(p string) (stream Stream, err error) {
+	var f *os.File
+	if f, err = os.Open(p); err != nil {
+		return
+	}
+	switch strings.ToLower(path.Ext(p)) {
+	case ".wav":
+		stream, err = NewWAVStream(f)
+	case ".ogg":
+		stream, err = NewOggStream(f)
+	default:
+		err = fmt.Errorf("Unsupported sound format %v", p)
+	}
+	if err != nil {
+		f.Close()
+	}
+	return
+}
+
+type oggStream struct {
+	reader *oggvorbis.Reader
+	source io.ReadSeeker
+}
+
+// Streams Ogg Vorbis data from r, which is closed with the stream if it's an
+// io.Closer.
+func NewOggStream(r io.ReadSeeker) (s Stream, err error) {
+	var reader *oggvorbis.Reader
+	if reader, err = oggvorbis.NewReader(r); err != nil {
+		return
+	}
+	s = &oggStream{
+		reader: reader,
+		source: r,
+	}
+	return
+}
+
+func (s *oggStream) SampleRate() int {
+	return s.reader.SampleRate()
+}
+
+func (s *oggStream) Channels() int {
+	return s.reader.Channels()
+}
+
+func (s *oggStream) Length() int64 {
+	return s.reader.Length()
+}
+
+func (s *oggStream) Read(samples []float32) (int, error) {
+	return s.reader.Read(samples)
+}
+
+func (s *oggStream) SetPosition(frame int64) error {
+	return s.reader.SetPosition(frame)
+}
+
+func (s *oggStream) Close() error {
+	return closeSource(s.source)
+}
+
+type wavStream struct {
+	format wavFormat
+	source io.ReadSeeker
+	start  int64 // Offset of the sample data.
+	size   int64
+	offset int64 // Bytes of sample data read.
+	buffer []byte
+}
+
+// Streams WAV data from r, which is closed with the stream if it's an
+// io.Closer.
+func NewWAVStream(r io.ReadSeeker) (s Stream, err error) {
+	var (
+		stream = &wavStream{source: r}
+		size   uint32
+	)
+	if stream.format, size, err = readWAVHeader(r); err != nil {
+		return
+	}
+	if stream.start, err = r.Seek(0, io.SeekCurrent); err != nil {
+		return
+	}
+	stream.size = int64(size)
+	s = stream
+	return
+}
+
+func (s *wavStream) SampleRate() int {
+	return int(s.format.SampleRate)
+}
+
+func (s *wavStream) Channels() int {
+	return int(s.format.Channels)
+}
+
+func (s *wavStream) frameSize() int64 {
+	return int64(s.format.sampleWidth()) * int64(s.format.Channels)
+}
+
+func (s *wavStream) Length() int64 {
+	return s.size / s.frameSize()
+}
+
+// Reads whole frames only.
+func (s *wavStream) Read(samples []float32) (n int, err error) {
+	var (
+		width     = s.format.sampleWidth()
+		frames    = int64(len(samples)) / int64(s.format.Channels)
+		size      = frames * s.frameSize()
+		read      int
+		decodeErr error
+	)
+	if remaining := s.size - s.offset; size > remaining {
+		size = remaining - remaining%s.frameSize()
+	}
+	if size <= 0 {
+		err = io.EOF
+		return
+	}
+	if int64(cap(s.buffer)) < size {
+		s.buffer = make([]byte, size)
+	}
+	read, err = io.ReadFull(s.source, s.buffer[:size])
+	s.offset += int64(read)
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	read -= read % width
+	if n, decodeErr = s.format.decode(s.buffer[:read], samples); decodeErr != nil {
+		err = decodeErr
+	}
+	return
+}
+
+func (s *wavStream) SetPosition(frame int64) (err error) {
+	var offset = frame * s.frameSize()
+	if offset > s.size {
+		offset = s.size
+	}
+	if _, err = s.source.Seek(s.start+offset, io.SeekStart); err != nil {
+		return
+	}
+	s.offset = offset
+	return
+}
+
+func (s *wavStream) Close() error {
+	return closeSource(s.source)
+}
+
+func closeSource(r io.Reader) error {
+	if c, ok := r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Frames decoded from a stream.
+type streamWindow struct {
+	samples []float32
+	start   int // Frame at samples[0].
+	count   int // Frames in samples.
+}
+
+func (w *streamWindow) contains(i int) bool {
+	return i >= w.start && i < w.start+w.count
+}
+
+// Random access to the frames of a stream through windows of decoded frames.
+// Mix loads the frames it will need before taking the mixer's lock, so only
+// the goroutine calling Mix uses it, apart from closing.
+type streamSource struct {
+	lock     sync.Mutex // Keeps closing from interrupting decoding.
+	stream   Stream
+	channels int
+	current  streamWindow // Where the stream was last read.
+	previous streamWindow // Kept across a seek, such as the end of a loop.
+	end      int          // Frame where Read hit io.EOF, or -1.
+	endOf    int64        // The stream's Length when it did, as modules can change.
+	closed   bool
+	err      error
+}
+
+const streamChunkFrames = 4096
+
+func newStreamSource(stream Stream) *streamSource {
+	return &streamSource{
+		stream:   stream,
+		channels: stream.Channels(),
+		end:      -1,
+	}
+}
+
+func (s *streamSource) rate() int {
+	return s.stream.SampleRate()
+}
+
+func (s *streamSource) length() int {
+	var n = s.stream.Length()
+	if s.end >= 0 && n == s.endOf && int64(s.end) < n {
+		return s.end
+	}
+	return int(n)
+}
+
+func (s *streamSource) frame(i int) (l, r float32) {
+	var w = &s.current
+	if !w.contains(i) {
+		if w = &s.previous; !w.contains(i) {
+			// Not loaded ahead, such as after a change of pitch.
+			s.load(i, i+1)
+			if w = &s.current; !w.contains(i) {
+				return
+			}
+		}
+	}
+	var base = (i - w.start) * s.channels
+	l = w.samples[base]
+	if s.channels > 1 {
+		r = w.samples[base+1]
+	} else {
+		r = l
+	}
+	return
+}
+
+// Decodes frames from up to to into the current window, unless a window
+// already has them. Stops early at the end of the stream.
+func (s *streamSource) load(from, to int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed || s.err != nil || from >= to {
+		return
+	}
+	if s.current.contains(from) && s.current.contains(to-1) ||
+		s.previous.contains(from) && s.previous.contains(to-1) {
+		return
+	}
+	var (
+		w    = &s.current
+		need = to - from
+	)
+	switch {
+	case w.contains(from):
+		// Keep the frames already decoded.
+		copy(w.samples, w.samples[(from-w.start)*s.channels:w.count*s.channels])
+		w.start, w.count = from, w.start+w.count-from
+	case from == w.start+w.count:
+		w.start, w.count = from, 0
+	default:
+		// Far away, such as after looping.
+		s.previous, s.current = s.current, s.previous
+		if s.err = s.stream.SetPosition(int64(from)); s.err != nil {
+			return
+		}
+		w.start, w.count = from, 0
+	}
+	if need < streamChunkFrames {
+		need = streamChunkFrames
+	}
+	if len(w.samples) < need*s.channels {
+		var grown = make([]float32, need*s.channels)
+		copy(grown, w.samples[:w.count*s.channels])
+		w.samples = grown
+	}
+	for w.start+w.count < to {
+		n, err := s.stream.Read(w.samples[w.count*s.channels:])
+		w.count += n / s.channels
+		if n == 0 {
+			if err == io.EOF {
+				s.end = w.start + w.count
+				s.endOf = s.stream.Length()
+				return
+			}
+			if err == nil {
+				err = fmt.Errorf("Stream returned nothing at frame %v of %v", w.start+w.count, s.length())
+			}
+			s.err = err
+			return
+		}
+	}
+}
+
+func (s *streamSource) failed() error {
+	return s.err
+}
+
+func (s *streamSource) close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	return s.stream.Close()
+}
@@ -0,0 +1,235 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+)
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+type wavFormat struct {
+	AudioFormat   uint16
+	Channels      uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+}
+
+// Decodes 8, 16, 24 or 32 bit integer PCM and 32 or 64 bit float WAV data.
+func DecodeWAV(r io.Reader) (sound *Sound, err error) {
+	var (
+		format wavFormat
+		size   uint32
+		data   []byte
+	)
+	if format, size, err = readWAVHeader(r); err != nil {
+		return
+	}
+	if data, err = ioutil.ReadAll(io.LimitReader(r, int64(size))); err != nil {
+		return
+	}
+	sound = &Sound{
+		SampleRate: int(format.SampleRate),
+		Channels:   int(format.Channels),
+		Samples:    make([]float32, len(data)/format.sampleWidth()),
+	}
+	if _, err = format.decode(data, sound.Samples); err != nil {
+		sound = nil
+		return
+	}
+	// Drop a trailing partial frame.
+	sound.Samples = sound.Samples[:sound.Frames()*sound.Channels]
+	return
+}
+
+// Reads chunks up to the start of the sample data, returning its format and
+// size in bytes.
+func readWAVHeader(r io.Reader) (format wavFormat, size uint32, err error) {
+	var (
+		header  [12]byte
+		chunk   [8]byte
+		hasFmt  bool
+		formatb []byte
+	)
+	if _, err = io.ReadFull(r, header[:]); err != nil {
+		return
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		err = fmt.Errorf("Not a WAV file")
+		return
+	}
+	for {
+		if _, err = io.ReadFull(r, chunk[:]); err != nil {
+			if err == io.EOF {
+				err = fmt.Errorf("No data chunk in WAV file")
+			}
+			return
+		}
+		size = binary.LittleEndian.Uint32(chunk[4:])
+		switch string(chunk[0:4]) {
+		case "fmt ":
+			formatb = make([]byte, size)
+			if _, err = io.ReadFull(r, formatb); err != nil {
+				return
+			}
+			if size < 16 {
+				err = fmt.Errorf("Short WAV format chunk")
+				return
+			}
+			format = wavFormat{
+				AudioFormat:   binary.LittleEndian.Uint16(formatb[0:]),
+				Channels:      binary.LittleEndian.Uint16(formatb[2:]),
+				SampleRate:    binary.LittleEndian.Uint32(formatb[4:]),
+				ByteRate:      binary.LittleEndian.Uint32(formatb[8:]),
+				BlockAlign:    binary.LittleEndian.Uint16(formatb[12:]),
+				BitsPerSample: binary.LittleEndian.Uint16(formatb[14:]),
+			}
+			// Extensible headers keep the real format in the sub-format GUID.
+			if format.AudioFormat == wavFormatExtensible && size >= 26 {
+				format.AudioFormat = binary.LittleEndian.Uint16(formatb[24:])
+			}
+			if format.Channels == 0 || format.sampleWidth() == 0 {
+				err = fmt.Errorf("Invalid WAV format %+v", format)
+				return
+			}
+			hasFmt = true
+		case "data":
+			if !hasFmt {
+				err = fmt.Errorf("WAV data before format chunk")
+			}
+			return
+		default:
+			if _, err = io.CopyN(ioutil.Discard, r, int64(size)); err != nil {
+				return
+			}
+		}
+		// Chunks are padded to an even length.
+		if size%2 == 1 {
+			if _, err = io.CopyN(ioutil.Discard, r, 1); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (f wavFormat) sampleWidth() int {
+	return int(f.BitsPerSample) / 8
+}
+
+// Converts whole samples from data into out, returning how many were written.
+func (f wavFormat) decode(data []byte, out []float32) (n int, err error) {
+	var width = f.sampleWidth()
+	for n = 0; n < len(out) && (n+1)*width <= len(data); n++ {
+		b := data[n*width:]
+		switch {
+		case f.AudioFormat == wavFormatPCM && width == 1:
+			out[n] = (float32(b[0]) - 128) / 128
+		case f.AudioFormat == wavFormatPCM && width == 2:
+			out[n] = float32(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+		case f.AudioFormat == wavFormatPCM && width == 3:
+			v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+			out[n] = float32(v) / (1 << 23)
+		case f.AudioFormat == wavFormatPCM && width == 4:
+			out[n] = float32(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+		case f.AudioFormat == wavFormatFloat && width == 4:
+			out[n] = math.Float32frombits(binary.LittleEndian.Uint32(b))
+		case f.AudioFormat == wavFormatFloat && width == 8:
+			out[n] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		default:
+			err = fmt.Errorf("Unsupported WAV format %v with %v bits", f.AudioFormat, f.BitsPerSample)
+			return
+		}
+	}
+	return
+}
+
+// Writes sound as 16 bit PCM.
+func EncodeWAV(w io.Writer, sound *Sound) (err error) {
+	if err = writeWAVHeader(w, sound.SampleRate, sound.Channels, len(sound.Samples)*2); err != nil {
+		return
+	}
+	return binary.Write(w, binary.LittleEndian, pcm16(sound.Samples, nil))
+}
+
+func SaveWAV(path string, sound *Sound) (err error) {
+	var f *os.File
+	if f, err = os.Create(path); err != nil {
+		return
+	}
+	if err = EncodeWAV(f, sound); err != nil {
+		f.Close()
+		return
+	}
+	return f.Close()
+}
+
+func writeWAVHeader(w io.Writer, sampleRate, channels, dataSize int) error {
+	var header = struct {
+		RIFF     [4]byte
+		Size     uint32
+		WAVE     [4]byte
+		FmtID    [4]byte
+		FmtSize  uint32
+		Format   wavFormat
+		DataID   [4]byte
+		DataSize uint32
+	}{
+		RIFF:    [4]byte{'R', 'I', 'F', 'F'},
+		Size:    uint32(36 + dataSize),
+		WAVE:    [4]byte{'W', 'A', 'V', 'E'},
+		FmtID:   [4]byte{'f', 'm', 't', ' '},
+		FmtSize: 16,
+		Format: wavFormat{
+			AudioFormat:   wavFormatPCM,
+			Channels:      uint16(channels),
+			SampleRate:    uint32(sampleRate),
+			ByteRate:      uint32(sampleRate * channels * 2),
+			BlockAlign:    uint16(channels * 2),
+			BitsPerSample: 16,
+		},
+		DataID:   [4]byte{'d', 'a', 't', 'a'},
+		DataSize: uint32(dataSize),
+	}
+	return binary.Write(w, binary.LittleEndian, &header)
+}
+
+// Converts samples to 16 bit integers, clipping, reusing out if it's large
+// enough.
+func pcm16(samples []float32, out []int16) []int16 {
+	if cap(out) < len(samples) {
+		out = make([]int16, len(samples))
+	}
+	out = out[:len(samples)]
+	for i, s := range samples {
+		if s > 1 {
+			s = 1
+		} else if s < -1 {
+			s = -1
+		}
+		out[i] = int16(s * math.MaxInt16)
+	}
+	return out
+}
@@ -0,0 +1,226 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+)
+
+// Builds a WAV file from a format chunk, an optional unknown chunk before the
+// data and the data.
+func testWAV(format []byte, extra []byte, data []byte) []byte {
+	var (
+		b     bytes.Buffer
+		chunk = func(id string, body []byte) {
+			b.WriteString(id)
+			binary.Write(&b, binary.LittleEndian, uint32(len(body)))
+			b.Write(body)
+			if len(body)%2 == 1 {
+				b.WriteByte(0)
+			}
+		}
+	)
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(0))
+	b.WriteString("WAVE")
+	if format != nil {
+		chunk("fmt ", format)
+	}
+	if extra != nil {
+		chunk("LIST", extra)
+	}
+	chunk("data", data)
+	return b.Bytes()
+}
+
+func testWAVFormat(audioFormat, channels, bits int) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, wavFormat{
+		AudioFormat:   uint16(audioFormat),
+		Channels:      uint16(channels),
+		SampleRate:    8000,
+		ByteRate:      uint32(8000 * channels * bits / 8),
+		BlockAlign:    uint16(channels * bits / 8),
+		BitsPerSample: uint16(bits),
+	})
+	return b.Bytes()
+}
+
+// An extensible format chunk whose sub-format is audioFormat.
+func testWAVExtensible(audioFormat, channels, bits int) []byte {
+	var b = bytes.NewBuffer(testWAVFormat(wavFormatExtensible, channels, bits))
+	binary.Write(b, binary.LittleEndian, uint16(22))
+	binary.Write(b, binary.LittleEndian, uint16(bits))
+	binary.Write(b, binary.LittleEndian, uint32(0))
+	binary.Write(b, binary.LittleEndian, uint16(audioFormat))
+	b.Write(make([]byte, 14))
+	return b.Bytes()
+}
+
+func littleEndian(values ...interface{}) []byte {
+	var b bytes.Buffer
+	for _, v := range values {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	return b.Bytes()
+}
+
+func TestDecodeWAV(t *testing.T) {
+	var tests = []struct {
+		name     string
+		data     []byte
+		channels int
+		want     []float32
+	}{
+		{
+			name:     "8 bit",
+			data:     testWAV(testWAVFormat(wavFormatPCM, 1, 8), nil, []byte{0, 128, 192}),
+			channels: 1,
+			want:     []float32{-1, 0, 0.5},
+		},
+		{
+			name:     "16 bit stereo",
+			data:     testWAV(testWAVFormat(wavFormatPCM, 2, 16), nil, littleEndian(int16(-32768), int16(16384))),
+			channels: 2,
+			want:     []float32{-1, 0.5},
+		},
+		{
+			name:     "24 bit",
+			data:     testWAV(testWAVFormat(wavFormatPCM, 1, 24), nil, []byte{0, 0, 0xc0, 0, 0, 0x40}),
+			channels: 1,
+			want:     []float32{-0.5, 0.5},
+		},
+		{
+			name:     "32 bit",
+			data:     testWAV(testWAVFormat(wavFormatPCM, 1, 32), nil, littleEndian(int32(-1<<30))),
+			channels: 1,
+			want:     []float32{-0.5},
+		},
+		{
+			name:     "float",
+			data:     testWAV(testWAVFormat(wavFormatFloat, 1, 32), nil, littleEndian(float32(0.25))),
+			channels: 1,
+			want:     []float32{0.25},
+		},
+		{
+			name:     "double",
+			data:     testWAV(testWAVFormat(wavFormatFloat, 1, 64), nil, littleEndian(float64(-0.25))),
+			channels: 1,
+			want:     []float32{-0.25},
+		},
+		{
+			name:     "extensible",
+			data:     testWAV(testWAVExtensible(wavFormatFloat, 1, 32), nil, littleEndian(float32(0.75))),
+			channels: 1,
+			want:     []float32{0.75},
+		},
+		{
+			name:     "odd chunk skipped",
+			data:     testWAV(testWAVFormat(wavFormatPCM, 1, 8), []byte{1, 2, 3}, []byte{128}),
+			channels: 1,
+			want:     []float32{0},
+		},
+		{
+			name:     "partial frame dropped",
+			data:     testWAV(testWAVFormat(wavFormatPCM, 2, 8), nil, []byte{128, 128, 0}),
+			channels: 2,
+			want:     []float32{0, 0},
+		},
+	}
+	for _, test := range tests {
+		sound, err := DecodeWAV(bytes.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		if sound.SampleRate != 8000 || sound.Channels != test.channels {
+			t.Errorf("%v: %v Hz with %v channels", test.name, sound.SampleRate, sound.Channels)
+		}
+		if !equalSamples(sound.Samples, test.want) {
+			t.Errorf("%v: samples = %v, want %v", test.name, sound.Samples, test.want)
+		}
+	}
+}
+
+func TestDecodeWAVErrors(t *testing.T) {
+	var (
+		pcm   = testWAVFormat(wavFormatPCM, 1, 16)
+		tests = []struct {
+			name string
+			data []byte
+		}{
+			{"not RIFF", append([]byte("RIFX"), testWAV(pcm, nil, nil)[4:]...)},
+			{"no format", testWAV(nil, nil, []byte{0, 0})},
+			{"short format", testWAV(pcm[:12], nil, []byte{0, 0})},
+			{"no channels", testWAV(testWAVFormat(wavFormatPCM, 0, 16), nil, []byte{0, 0})},
+			{"unsupported", testWAV(testWAVFormat(2, 1, 16), nil, []byte{0, 0})},
+			{"truncated", testWAV(pcm, nil, nil)[:30]},
+		}
+	)
+	for _, test := range tests {
+		if _, err := DecodeWAV(bytes.NewReader(test.data)); err == nil {
+			t.Errorf("%v: no error", test.name)
+		}
+	}
+}
+
+func TestEncodeWAV(t *testing.T) {
+	var (
+		sound = &Sound{SampleRate: 22050, Channels: 2, Samples: []float32{0, 0.5, -0.5, 2}}
+		b     bytes.Buffer
+	)
+	if err := EncodeWAV(&b, sound); err != nil {
+		t.Fatalf("EncodeWAV: %v", err)
+	}
+	decoded, err := DecodeWAV(&b)
+	if err != nil {
+		t.Fatalf("DecodeWAV: %v", err)
+	}
+	if decoded.SampleRate != 22050 || decoded.Channels != 2 || len(decoded.Samples) != 4 {
+		t.Fatalf("Decoded %v Hz, %v channels, %v samples", decoded.SampleRate, decoded.Channels, len(decoded.Samples))
+	}
+	for i, want := range []float32{0, 0.5, -0.5, 1} {
+		if math.Abs(float64(decoded.Samples[i]-want)) > 1.0/32768 {
+			t.Errorf("Sample %v = %v, want %v", i, decoded.Samples[i], want)
+		}
+	}
+}
+
+func TestWAVStream(t *testing.T) {
+	var data = testWAV(testWAVFormat(wavFormatPCM, 2, 8), nil, []byte{0, 64, 128, 192, 255, 255})
+	stream, err := NewWAVStream(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewWAVStream: %v", err)
+	}
+	if stream.Length() != 3 || stream.Channels() != 2 || stream.SampleRate() != 8000 {
+		t.Fatalf("Stream of %v frames, %v channels at %v Hz", stream.Length(), stream.Channels(), stream.SampleRate())
+	}
+	if err = stream.SetPosition(1); err != nil {
+		t.Fatalf("SetPosition: %v", err)
+	}
+	var samples = make([]float32, 3)
+	n, err := stream.Read(samples)
+	if n != 2 || err != nil || samples[0] != 0 || samples[1] != 0.5 {
+		t.Errorf("Read = %v, %v with %v, want the second frame", n, err, samples)
+	}
+	stream.Read(samples)
+	if n, err = stream.Read(samples); n != 0 || err != io.EOF {
+		t.Errorf("Read at the end = %v, %v, want io.EOF", n, err)
+	}
+}
@@ -0,0 +1,352 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	xmSignature     = "Extended Module: "
+	xmFlagLinear    = 1
+	xmEnvelopeOn    = 1
+	xmEnvelopeSus   = 2
+	xmEnvelopeLoop  = 4
+	xmSampleLoop    = 1
+	xmSamplePong    = 2
+	xmSample16Bit   = 16
+	xmSampleHeader  = 40
+	xmMaxEnvelope   = 12
+	xmPackedNote    = 1
+	xmPackedInst    = 2
+	xmPackedVolume  = 4
+	xmPackedEffect  = 8
+	xmPackedParam   = 16
+	xmPackedPresent = 0x80
+)
+
+// Reads little endian values from a byte slice, failing on reads past the
+// end.
+type xmReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *xmReader) bytes(n int) []byte {
+	if r.err != nil || r.pos+n > len(r.data) || n < 0 {
+		if r.err == nil {
+			r.err = fmt.Errorf("XM file truncated at %v", r.pos)
+		}
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+// Returns zero once truncated, as do u16 and u32.
+func (r *xmReader) u8() int {
+	if b := r.bytes(1); b != nil {
+		return int(b[0])
+	}
+	return 0
+}
+
+func (r *xmReader) u16() int {
+	if b := r.bytes(2); b != nil {
+		return int(binary.LittleEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (r *xmReader) u32() int {
+	if b := r.bytes(4); b != nil {
+		return int(binary.LittleEndian.Uint32(b))
+	}
+	return 0
+}
+
+// Returns the bytes left after the read position.
+func (r *xmReader) remaining() int {
+	if r.err != nil || r.pos > len(r.data) {
+		return 0
+	}
+	return len(r.data) - r.pos
+}
+
+func (r *xmReader) seek(pos int) {
+	if pos > len(r.data) && r.err == nil {
+		r.err = fmt.Errorf("XM file truncated at %v", pos)
+	}
+	r.pos = pos
+}
+
+// Decodes a FastTracker 2 extended module.
+func DecodeXM(in io.Reader) (module *Module, err error) {
+	var (
+		data        []byte
+		r           *xmReader
+		headerSize  int
+		songLength  int
+		patterns    int
+		instruments int
+	)
+	if data, err = ioutil.ReadAll(in); err != nil {
+		return
+	}
+	if len(data) < 60 || string(data[0:17]) != xmSignature {
+		err = fmt.Errorf("Not an XM file")
+		return
+	}
+	r = &xmReader{data: data, pos: 17}
+	module = &Module{Title: trimName(r.bytes(20))}
+	r.seek(60)
+	headerSize = r.u32()
+	songLength = r.u16()
+	module.Restart = r.u16()
+	module.Channels = r.u16()
+	patterns = r.u16()
+	instruments = r.u16()
+	module.LinearPeriods = r.u16()&xmFlagLinear != 0
+	module.Speed = r.u16()
+	module.Tempo = r.u16()
+	orders := r.bytes(256)
+	if err = r.err; err != nil {
+		module = nil
+		return
+	}
+	if songLength > 256 {
+		songLength = 256
+	}
+	for _, order := range orders[:songLength] {
+		module.Orders = append(module.Orders, int(order))
+	}
+	if module.Channels < 1 || module.Channels > 64 {
+		err = fmt.Errorf("Invalid XM channel count %v", module.Channels)
+		return
+	}
+	module.Panning = make([]int, module.Channels)
+	for i := range module.Panning {
+		module.Panning[i] = 128
+	}
+	r.seek(60 + headerSize)
+	for i := 0; i < patterns && r.err == nil; i++ {
+		module.Patterns = append(module.Patterns, readXMPattern(r, module.Channels))
+	}
+	for i := 0; i < instruments && r.err == nil; i++ {
+		module.Instruments = append(module.Instruments, readXMInstrument(r))
+	}
+	// Orders may name patterns which are missing, and so empty.
+	for _, order := range module.Orders {
+		for order >= len(module.Patterns) {
+			module.Patterns = append(module.Patterns, &ModulePattern{
+				Rows:  64,
+				Notes: make([]ModuleNote, 64*module.Channels),
+			})
+		}
+	}
+	if err = r.err; err != nil {
+		module = nil
+	}
+	return
+}
+
+func readXMPattern(r *xmReader, channels int) (pattern *ModulePattern) {
+	var (
+		start      = r.pos
+		headerSize = r.u32()
+		packedSize int
+		end        int
+	)
+	r.u8() // Packing type, always zero.
+	pattern = &ModulePattern{Rows: r.u16()}
+	packedSize = r.u16()
+	r.seek(start + headerSize)
+	end = r.pos + packedSize
+	pattern.Notes = make([]ModuleNote, pattern.Rows*channels)
+	if packedSize == 0 {
+		return
+	}
+	for i := range pattern.Notes {
+		if r.pos >= end {
+			break
+		}
+		var (
+			note  = &pattern.Notes[i]
+			flags = r.u8()
+		)
+		if flags&xmPackedPresent == 0 {
+			// Unpacked, so the rest of the note follows.
+			note.Note = uint8(flags)
+			flags = xmPackedInst | xmPackedVolume | xmPackedEffect | xmPackedParam
+		}
+		if flags&xmPackedNote != 0 {
+			note.Note = uint8(r.u8())
+		}
+		if flags&xmPackedInst != 0 {
+			note.Instrument = uint8(r.u8())
+		}
+		if flags&xmPackedVolume != 0 {
+			note.Volume = uint8(r.u8())
+		}
+		if flags&xmPackedEffect != 0 {
+			note.Effect = uint8(r.u8())
+		}
+		if flags&xmPackedParam != 0 {
+			note.Param = uint8(r.u8())
+		}
+	}
+	r.seek(end)
+	return
+}
+
+func readXMEnvelope(points []byte, count, sustain, loopStart, loopEnd, flags int) (e ModuleEnvelope) {
+	if count > xmMaxEnvelope {
+		count = xmMaxEnvelope
+	}
+	if count > len(points)/4 {
+		count = len(points) / 4
+	}
+	for i := 0; i < count; i++ {
+		e.Points = append(e.Points, ModuleEnvelopePoint{
+			Tick:  int(binary.LittleEndian.Uint16(points[i*4:])),
+			Value: int(binary.LittleEndian.Uint16(points[i*4+2:])),
+		})
+	}
+	e.Enabled = flags&xmEnvelopeOn != 0 && count > 0
+	e.Sustain = flags&xmEnvelopeSus != 0
+	e.SustainPoint = sustain
+	e.Loop = flags&xmEnvelopeLoop != 0
+	e.LoopStart = loopStart
+	e.LoopEnd = loopEnd
+	return
+}
+
+func readXMInstrument(r *xmReader) (inst *ModuleInstrument) {
+	var (
+		start      = r.pos
+		size       = r.u32()
+		samples    int
+		headerSize int
+		types      []int
+		lengths    []int
+	)
+	inst = &ModuleInstrument{Name: trimName(r.bytes(22))}
+	r.u8() // Type, always zero.
+	samples = r.u16()
+	if samples == 0 {
+		r.seek(start + size)
+		return
+	}
+	if headerSize = r.u32(); headerSize < xmSampleHeader {
+		headerSize = xmSampleHeader
+	}
+	for i, s := range r.bytes(moduleMaxNote) {
+		inst.SampleMap[i] = int(s)
+	}
+	var (
+		volPoints = r.bytes(48)
+		panPoints = r.bytes(48)
+		volCount  = r.u8()
+		panCount  = r.u8()
+		volSus    = r.u8()
+		volStart  = r.u8()
+		volEnd    = r.u8()
+		panSus    = r.u8()
+		panStart  = r.u8()
+		panEnd    = r.u8()
+		volFlags  = r.u8()
+		panFlags  = r.u8()
+	)
+	r.bytes(4) // Auto vibrato, which isn't supported.
+	inst.Fadeout = r.u16() * 2
+	inst.VolumeEnvelope = readXMEnvelope(volPoints, volCount, volSus, volStart, volEnd, volFlags)
+	inst.PanningEnvelope = readXMEnvelope(panPoints, panCount, panSus, panStart, panEnd, panFlags)
+	r.seek(start + size)
+	for i := 0; i < samples && r.err == nil; i++ {
+		var (
+			sampleStart = r.pos
+			length      = r.u32()
+			loopStart   = r.u32()
+			loopLength  = r.u32()
+			sample      = &ModuleSample{Volume: r.u8()}
+		)
+		sample.Finetune = int(int8(r.u8()))
+		types = append(types, r.u8())
+		sample.Panning = r.u8()
+		sample.RelativeNote = int(int8(r.u8()))
+		r.u8()
+		sample.Name = trimName(r.bytes(22))
+		if types[i]&xmSample16Bit != 0 {
+			length /= 2
+			loopStart /= 2
+			loopLength /= 2
+		}
+		if types[i]&(xmSampleLoop|xmSamplePong) != 0 && loopLength > 0 && loopStart < length {
+			if loopLength > length-loopStart {
+				loopLength = length - loopStart
+			}
+			sample.LoopStart = loopStart
+			sample.LoopLength = loopLength
+			sample.PingPong = types[i]&xmSamplePong != 0
+		}
+		if sample.Volume > 64 {
+			sample.Volume = 64
+		}
+		lengths = append(lengths, length)
+		inst.Samples = append(inst.Samples, sample)
+		r.seek(sampleStart + headerSize)
+	}
+	// Sample data follows all the headers, delta encoded. Lengths are checked
+	// against what is left before allocating, so a bad header can't ask for
+	// gigabytes.
+	for i, sample := range inst.Samples {
+		var size = lengths[i]
+		if types[i]&xmSample16Bit != 0 {
+			size *= 2
+		}
+		if size > r.remaining() {
+			if r.err == nil {
+				r.err = fmt.Errorf("XM sample of %v bytes at %v is past the end", size, r.pos)
+			}
+			return
+		}
+		sample.Data = make([]float32, lengths[i])
+		if types[i]&xmSample16Bit != 0 {
+			var (
+				b   = r.bytes(size)
+				acc int16
+			)
+			for j := range sample.Data {
+				acc += int16(binary.LittleEndian.Uint16(b[j*2:]))
+				sample.Data[j] = float32(acc) / 32768
+			}
+		} else {
+			var (
+				b   = r.bytes(size)
+				acc int8
+			)
+			for j := range sample.Data {
+				acc += int8(b[j])
+				sample.Data[j] = float32(acc) / 128
+			}
+		}
+	}
+	return
+}
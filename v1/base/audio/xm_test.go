@@ -0,0 +1,116 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// Builds an XM with one channel, no patterns and one instrument holding an
+// 8 bit sample whose header claims length bytes, followed by data.
+func testXM(length, loopStart, loopLength uint32, data []byte) []byte {
+	var (
+		b   bytes.Buffer
+		u8  = func(v uint8) { b.WriteByte(v) }
+		u16 = func(v uint16) { binary.Write(&b, binary.LittleEndian, v) }
+		u32 = func(v uint32) { binary.Write(&b, binary.LittleEndian, v) }
+	)
+	b.WriteString(xmSignature)
+	b.Write(make([]byte, 20)) // Title.
+	u8(0x1a)
+	b.Write(make([]byte, 20)) // Tracker.
+	u16(0x104)
+	u32(276)
+	u16(1) // Song length.
+	u16(0) // Restart.
+	u16(1) // Channels.
+	u16(0) // Patterns.
+	u16(1) // Instruments.
+	u16(xmFlagLinear)
+	u16(6)
+	u16(125)
+	b.Write(make([]byte, 256)) // Orders.
+	u32(243)
+	b.Write(make([]byte, 22)) // Name.
+	u8(0)
+	u16(1) // Samples.
+	u32(xmSampleHeader)
+	b.Write(make([]byte, moduleMaxNote+48+48+10+2+4+2))
+	u32(length)
+	u32(loopStart)
+	u32(loopLength)
+	u8(64)
+	u8(0)
+	u8(xmSampleLoop)
+	u8(128)
+	u8(0)
+	u8(0)
+	b.Write(make([]byte, 22))
+	b.Write(data)
+	return b.Bytes()
+}
+
+func TestDecodeXMSample(t *testing.T) {
+	module, err := DecodeXM(bytes.NewReader(testXM(4, 2, 100, []byte{16, 16, 0xf0, 0xf0})))
+	if err != nil {
+		t.Fatalf("DecodeXM: %v", err)
+	}
+	var sample = module.Instruments[0].Samples[0]
+	if want := []float32{0.125, 0.25, 0.125, 0}; !equalSamples(sample.Data, want) {
+		t.Errorf("Data = %v, want %v", sample.Data, want)
+	}
+	if sample.LoopStart != 2 || sample.LoopLength != 2 {
+		t.Errorf("Loop = %v+%v, want 2+2", sample.LoopStart, sample.LoopLength)
+	}
+}
+
+func TestDecodeXMBadLength(t *testing.T) {
+	var tests = []struct {
+		name                          string
+		length, loopStart, loopLength uint32
+	}{
+		{"huge length", 0xffffffff, 0, 0},
+		{"past the end", 5, 0, 0},
+		{"huge loop", 0xfffffff0, 0xfffffff0, 0xfffffff0},
+	}
+	for _, test := range tests {
+		if _, err := DecodeXM(bytes.NewReader(testXM(test.length, test.loopStart, test.loopLength, []byte{1, 2, 3, 4}))); err == nil {
+			t.Errorf("%v: no error", test.name)
+		}
+	}
+}
+
+func TestDecodeXMTruncated(t *testing.T) {
+	var data = testXM(4, 0, 0, []byte{1, 2, 3, 4})
+	for n := 60; n < len(data); n++ {
+		if _, err := DecodeXM(bytes.NewReader(data[:n])); err == nil {
+			t.Errorf("Truncated to %v bytes: no error", n)
+		}
+	}
+}
+
+func equalSamples(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collision
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Whatever a collider is attached to, such as a render.Instance. Bodies with
+// a Rotation() float32 method in degrees also rotate their colliders.
+type Body interface {
+	Position() mgl32.Vec3
+}
+
+type rotatedBody interface {
+	Rotation() float32
+}
+
+// A body which isn't drawn, for walls, sensors and other invisible things.
+type Transform struct {
+	position mgl32.Vec3
+	rotation float32
+}
+
+func NewTransform(position mgl32.Vec3) *Transform {
+	return &Transform{position: position}
+}
+
+func (t *Transform) Position() mgl32.Vec3 {
+	return t.position
+}
+
+func (t *Transform) SetPosition(p mgl32.Vec3) {
+	t.position = p
+}
+
+func (t *Transform) Rotation() float32 {
+	return t.rotation
+}
+
+func (t *Transform) SetRotation(r float32) {
+	t.rotation = r
+}
+
+const AllLayers uint32 = 0xFFFFFFFF
+
+// A shape attached to a body. Two colliders only collide if each one's Mask
+// includes a layer of the other, and never if they share a body.
+type Collider struct {
+	Body    Body
+	Shape   Shape
+	Offset  mgl32.Vec2 // From the body's position, rotating with it.
+	Layer   uint32     // Bits of the layers the collider is in.
+	Mask    uint32     // Bits of the layers it collides with.
+	Trigger bool       // Reports overlaps but isn't solid.
+	Data    interface{}
+	id      uint64
+	world   *World
+	placed  placedShape // As of the last World.Step.
+}
+
+// Returns a collider in layer 1 which collides with all layers.
+func NewCollider(body Body, shape Shape) *Collider {
+	return &Collider{
+		Body:  body,
+		Shape: shape,
+		Layer: 1,
+		Mask:  AllLayers,
+	}
+}
+
+// Returns the collider's world position, including its offset.
+func (c *Collider) Position() mgl32.Vec2 {
+	var p = c.Body.Position()
+	return mgl32.Vec2{p.X(), p.Y()}.Add(rotate(c.Offset, c.rotation()))
+}
+
+func (c *Collider) rotation() float32 {
+	if r, ok := c.Body.(rotatedBody); ok {
+		return r.Rotation()
+	}
+	return 0
+}
+
+// Returns the world space bounds at the body's current position.
+func (c *Collider) Bounds() AABB {
+	return c.place().bounds
+}
+
+func (c *Collider) place() placedShape {
+	return c.Shape.place(c.Position(), c.rotation())
+}
+
+func (c *Collider) collides(o *Collider) bool {
+	return c.Mask&o.Layer != 0 && o.Mask&c.Layer != 0 && c.Body != o.Body
+}
+
+// Returns whether a query with mask should see the collider.
+func (c *Collider) matches(mask uint32, triggers bool) bool {
+	return c.Layer&mask != 0 && (triggers || !c.Trigger)
+}
@@ -0,0 +1,238 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collision
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"math"
+)
+
+// Tolerance for points lying on a face.
+const contactSlop = 1e-4
+
+// How two shapes overlap. The normal points from the first shape to the
+// second, and moving the second by normal*depth separates them.
+type manifold struct {
+	normal mgl32.Vec2
+	depth  float32
+	points []mgl32.Vec2 // One or two points in world space.
+}
+
+// Separating axis tests between any two placed shapes.
+func collide(a, b *placedShape) (m manifold, ok bool) {
+	switch {
+	case a.circle && b.circle:
+		return collideCircles(a, b)
+	case a.circle:
+		if m, ok = collidePolygonCircle(b, a); ok {
+			m.normal = m.normal.Mul(-1)
+		}
+		return
+	case b.circle:
+		return collidePolygonCircle(a, b)
+	}
+	return collidePolygons(a, b)
+}
+
+func collideCircles(a, b *placedShape) (m manifold, ok bool) {
+	var (
+		delta    = b.center.Sub(a.center)
+		distance = delta.Len()
+		radius   = a.radius + b.radius
+	)
+	if distance >= radius {
+		return
+	}
+	m.normal = mgl32.Vec2{0, 1}
+	if distance > 0 {
+		m.normal = delta.Mul(1 / distance)
+	}
+	m.depth = radius - distance
+	m.points = []mgl32.Vec2{a.center.Add(m.normal.Mul(a.radius - m.depth/2))}
+	ok = true
+	return
+}
+
+// The normal points from the polygon p to the circle c.
+func collidePolygonCircle(p, c *placedShape) (m manifold, ok bool) {
+	var (
+		separation = float32(math.Inf(-1))
+		face       int
+		closest    mgl32.Vec2
+		best       = float32(math.Inf(1))
+		delta      mgl32.Vec2
+		distance   float32
+	)
+	for i, normal := range p.normals {
+		s := normal.Dot(c.center.Sub(p.points[i]))
+		if s > c.radius {
+			return
+		}
+		if s > separation {
+			separation, face = s, i
+		}
+	}
+	if separation <= 0 {
+		// The center is inside, so push out through the nearest face.
+		m.normal = p.normals[face]
+		m.depth = c.radius - separation
+		m.points = []mgl32.Vec2{c.center.Sub(m.normal.Mul(separation))}
+		ok = true
+		return
+	}
+	for i, a := range p.points {
+		q := closestOnSegment(a, p.points[(i+1)%len(p.points)], c.center)
+		if d := q.Sub(c.center).Len(); d < best {
+			closest, best = q, d
+		}
+	}
+	if best >= c.radius {
+		return
+	}
+	delta, distance = c.center.Sub(closest), best
+	m.normal = p.normals[face]
+	if distance > 0 {
+		m.normal = delta.Mul(1 / distance)
+	}
+	m.depth = c.radius - distance
+	m.points = []mgl32.Vec2{closest}
+	ok = true
+	return
+}
+
+func closestOnSegment(a, b, p mgl32.Vec2) mgl32.Vec2 {
+	var (
+		ab = b.Sub(a)
+		t  = p.Sub(a).Dot(ab) / ab.Dot(ab)
+	)
+	if t <= 0 {
+		return a
+	}
+	if t >= 1 {
+		return b
+	}
+	return a.Add(ab.Mul(t))
+}
+
+func collidePolygons(a, b *placedShape) (m manifold, ok bool) {
+	m.depth = float32(math.Inf(1))
+	for _, normals := range [][]mgl32.Vec2{a.normals, b.normals} {
+		for _, axis := range normals {
+			minA, maxA := a.project(axis)
+			minB, maxB := b.project(axis)
+			overlap := min32(maxA-minB, maxB-minA)
+			if overlap <= 0 {
+				return
+			}
+			if overlap < m.depth {
+				m.depth, m.normal = overlap, axis
+			}
+		}
+	}
+	if m.normal.Dot(b.center.Sub(a.center)) < 0 {
+		m.normal = m.normal.Mul(-1)
+	}
+	if m.points = clipContacts(a, b, m.normal); len(m.points) == 0 {
+		m.points = []mgl32.Vec2{support(b, m.normal.Mul(-1))}
+	}
+	ok = true
+	return
+}
+
+// Returns the point of s furthest along direction.
+func support(s *placedShape, direction mgl32.Vec2) (point mgl32.Vec2) {
+	var best = float32(math.Inf(-1))
+	for _, p := range s.points {
+		if d := p.Dot(direction); d > best {
+			point, best = p, d
+		}
+	}
+	return
+}
+
+type edge struct {
+	a, b mgl32.Vec2 // Counterclockwise around the polygon.
+}
+
+// Returns the edge of s most nearly facing along normal.
+func bestEdge(s *placedShape, normal mgl32.Vec2) edge {
+	var (
+		count = len(s.points)
+		index int
+		best  = float32(math.Inf(-1))
+	)
+	for i, p := range s.points {
+		if d := p.Dot(normal); d > best {
+			index, best = i, d
+		}
+	}
+	var (
+		v    = s.points[index]
+		next = s.points[(index+1)%count]
+		prev = s.points[(index+count-1)%count]
+	)
+	if v.Sub(prev).Normalize().Dot(normal) <= v.Sub(next).Normalize().Dot(normal) {
+		return edge{prev, v}
+	}
+	return edge{v, next}
+}
+
+// Finds up to two contact points by clipping the incident edge of one polygon
+// against the sides of the reference edge of the other.
+func clipContacts(a, b *placedShape, normal mgl32.Vec2) (points []mgl32.Vec2) {
+	var (
+		ref = bestEdge(a, normal)
+		inc = bestEdge(b, normal.Mul(-1))
+	)
+	if abs32(ref.b.Sub(ref.a).Normalize().Dot(normal)) > abs32(inc.b.Sub(inc.a).Normalize().Dot(normal)) {
+		ref, inc = inc, ref
+	}
+	var (
+		direction = ref.b.Sub(ref.a).Normalize()
+		outward   = mgl32.Vec2{direction.Y(), -direction.X()}
+		face      = outward.Dot(ref.a)
+		clipped   []mgl32.Vec2
+	)
+	if clipped = clipSegment(inc.a, inc.b, direction, direction.Dot(ref.a)); len(clipped) < 2 {
+		return
+	}
+	if clipped = clipSegment(clipped[0], clipped[1], direction.Mul(-1), -direction.Dot(ref.b)); len(clipped) < 2 {
+		return
+	}
+	for _, p := range clipped {
+		if outward.Dot(p) <= face+contactSlop {
+			points = append(points, p)
+		}
+	}
+	return
+}
+
+// Returns the part of segment ab where point.Dot(normal) >= offset.
+func clipSegment(a, b, normal mgl32.Vec2, offset float32) (points []mgl32.Vec2) {
+	var (
+		da = normal.Dot(a) - offset
+		db = normal.Dot(b) - offset
+	)
+	if da >= 0 {
+		points = append(points, a)
+	}
+	if db >= 0 {
+		points = append(points, b)
+	}
+	if da*db < 0 {
+		points = append(points, a.Add(b.Sub(a).Mul(da/(da-db))))
+	}
+	return
+}
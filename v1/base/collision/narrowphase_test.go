@@ -0,0 +1,111 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collision
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"testing"
+)
+
+func near(a, b mgl32.Vec2) bool {
+	return a.ApproxEqualThreshold(b, 1e-4)
+}
+
+func polygon(t *testing.T, points ...mgl32.Vec2) *Polygon {
+	var p, err = NewPolygon(points...)
+	if err != nil {
+		t.Fatalf("NewPolygon: %v", err)
+	}
+	return p
+}
+
+func TestCollide(t *testing.T) {
+	var (
+		diamond = polygon(t, mgl32.Vec2{0, -1}, mgl32.Vec2{1, 0}, mgl32.Vec2{0, 1}, mgl32.Vec2{-1, 0})
+		square  = polygon(t, mgl32.Vec2{-1, -1}, mgl32.Vec2{1, -1}, mgl32.Vec2{1, 1}, mgl32.Vec2{-1, 1})
+	)
+	var tests = []struct {
+		name     string
+		a, b     Shape
+		at       mgl32.Vec2 // Where b is, with a at the origin.
+		rotation float32    // Of b, in degrees.
+		hit      bool
+		normal   mgl32.Vec2
+		depth    float32
+		points   int
+	}{
+		{"circles", Circle{Radius: 1}, Circle{Radius: 1}, mgl32.Vec2{1.5, 0}, 0, true, mgl32.Vec2{1, 0}, 0.5, 1},
+		{"circles apart", Circle{Radius: 1}, Circle{Radius: 1}, mgl32.Vec2{2.5, 0}, 0, false, mgl32.Vec2{}, 0, 0},
+		{"box and circle", NewAABB(2, 2), Circle{Radius: 1}, mgl32.Vec2{0, 1.75}, 0, true, mgl32.Vec2{0, 1}, 0.25, 1},
+		{"circle and box", Circle{Radius: 1}, NewAABB(2, 2), mgl32.Vec2{-1.75, 0}, 0, true, mgl32.Vec2{-1, 0}, 0.25, 1},
+		{"box corner and circle apart", NewAABB(2, 2), Circle{Radius: 1}, mgl32.Vec2{1.8, 1.8}, 0, false, mgl32.Vec2{}, 0, 0},
+		{"boxes", NewAABB(2, 2), NewAABB(2, 2), mgl32.Vec2{1.5, 0.5}, 0, true, mgl32.Vec2{1, 0}, 0.5, 2},
+		{"boxes apart", NewAABB(2, 2), NewAABB(2, 2), mgl32.Vec2{0, 2.5}, 0, false, mgl32.Vec2{}, 0, 0},
+		{"box and diamond", NewAABB(2, 2), diamond, mgl32.Vec2{0, 1.5}, 0, true, mgl32.Vec2{0, 1}, 0.5, 1},
+		{"box and rotated box", NewAABB(2, 2), square, mgl32.Vec2{2.2, 0}, 45, true, mgl32.Vec2{1, 0}, 1.41421 - 1.2, 1},
+		{"box and rotated box apart", NewAABB(2, 2), square, mgl32.Vec2{2.5, 0}, 45, false, mgl32.Vec2{}, 0, 0},
+	}
+	for _, test := range tests {
+		var (
+			a = test.a.place(mgl32.Vec2{}, 0)
+			b = test.b.place(test.at, test.rotation)
+		)
+		m, ok := collide(&a, &b)
+		if ok != test.hit {
+			t.Errorf("%v: hit = %v, want %v", test.name, ok, test.hit)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if !near(m.normal, test.normal) {
+			t.Errorf("%v: normal = %v, want %v", test.name, m.normal, test.normal)
+		}
+		if d := m.depth - test.depth; d > 1e-4 || d < -1e-4 {
+			t.Errorf("%v: depth = %v, want %v", test.name, m.depth, test.depth)
+		}
+		if len(m.points) != test.points {
+			t.Errorf("%v: %v contact points %v, want %v", test.name, len(m.points), m.points, test.points)
+		}
+	}
+}
+
+func TestNewPolygon(t *testing.T) {
+	var tests = []struct {
+		name   string
+		points []mgl32.Vec2
+		ok     bool
+	}{
+		{"clockwise", []mgl32.Vec2{{0, 0}, {0, 1}, {1, 0}}, true},
+		{"too few", []mgl32.Vec2{{0, 0}, {1, 0}}, false},
+		{"collinear", []mgl32.Vec2{{0, 0}, {1, 0}, {2, 0}, {1, 1}}, false},
+		{"concave", []mgl32.Vec2{{0, 0}, {2, 0}, {1, 0.5}, {2, 2}, {0, 2}}, false},
+	}
+	for _, test := range tests {
+		p, err := NewPolygon(test.points...)
+		if (err == nil) != test.ok {
+			t.Errorf("%v: error %v", test.name, err)
+			continue
+		}
+		if p == nil {
+			continue
+		}
+		// Counterclockwise, so the first turn is to the left.
+		var points = p.Points()
+		if cross2(points[1].Sub(points[0]), points[2].Sub(points[1])) <= 0 {
+			t.Errorf("%v: points %v aren't counterclockwise", test.name, points)
+		}
+	}
+}
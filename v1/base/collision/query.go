@@ -0,0 +1,226 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collision
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"math"
+	"sort"
+)
+
+// Limits shape casts over long distances.
+const maxCastSteps = 1024
+
+type RaycastHit struct {
+	Collider *Collider
+	Point    mgl32.Vec2
+	Normal   mgl32.Vec2 // Of the surface hit, facing the ray.
+	Distance float32
+}
+
+// Returns the nearest collider in mask along the ray from origin. Rays
+// starting inside a shape don't hit it.
+func (w *World) Raycast(origin, direction mgl32.Vec2, distance float32, mask uint32) (hit RaycastHit, ok bool) {
+	var hits = w.raycast(origin, direction, distance, mask)
+	if len(hits) > 0 {
+		hit, ok = hits[0], true
+	}
+	return
+}
+
+// Returns every collider in mask along the ray, nearest first.
+func (w *World) RaycastAll(origin, direction mgl32.Vec2, distance float32, mask uint32) []RaycastHit {
+	return w.raycast(origin, direction, distance, mask)
+}
+
+func (w *World) raycast(origin, direction mgl32.Vec2, distance float32, mask uint32) (hits []RaycastHit) {
+	var (
+		end    mgl32.Vec2
+		bounds AABB
+	)
+	if direction.Len() == 0 {
+		return
+	}
+	direction = direction.Normalize()
+	end = origin.Add(direction.Mul(distance))
+	bounds = AABB{Min: origin, Max: origin}.Union(AABB{Min: end, Max: end})
+	for _, c := range w.candidates(bounds) {
+		if !c.matches(mask, w.cfg.QueryTriggers) {
+			continue
+		}
+		placed := c.place()
+		if t, normal, ok := raycastShape(&placed, origin, direction, distance); ok {
+			hits = append(hits, RaycastHit{
+				Collider: c,
+				Point:    origin.Add(direction.Mul(t)),
+				Normal:   normal,
+				Distance: t,
+			})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Distance < hits[j].Distance
+	})
+	return
+}
+
+// Returns the distance along a ray with unit direction to s, and the surface
+// normal there.
+func raycastShape(s *placedShape, origin, direction mgl32.Vec2, distance float32) (t float32, normal mgl32.Vec2, ok bool) {
+	if s.circle {
+		var (
+			m    = origin.Sub(s.center)
+			b    = m.Dot(direction)
+			c    = m.Dot(m) - s.radius*s.radius
+			disc = b*b - c
+		)
+		if c <= 0 || disc < 0 {
+			return
+		}
+		if t = -b - float32(math.Sqrt(float64(disc))); t < 0 || t > distance {
+			return
+		}
+		normal = origin.Add(direction.Mul(t)).Sub(s.center).Normalize()
+		ok = true
+		return
+	}
+	var exit = distance
+	for i, n := range s.normals {
+		var (
+			num = n.Dot(s.points[i].Sub(origin))
+			den = n.Dot(direction)
+		)
+		if den == 0 {
+			if num < 0 {
+				// Parallel to and outside this edge.
+				return t, normal, false
+			}
+			continue
+		}
+		if edge := num / den; den < 0 {
+			if edge > t {
+				t, normal, ok = edge, n, true
+			}
+		} else if edge < exit {
+			exit = edge
+		}
+		if t > exit {
+			return t, normal, false
+		}
+	}
+	return
+}
+
+type ShapeCastHit struct {
+	Collider *Collider
+	Point    mgl32.Vec2
+	Normal   mgl32.Vec2 // Of the surface hit, facing the cast shape.
+	Position mgl32.Vec2 // Where the shape stops, just short of touching.
+	Fraction float32    // Of the way from the start to the end.
+}
+
+// Moves shape from one position to another and returns the first collider
+// in mask it would touch. A shape overlapping something at the start hits it
+// with a fraction of zero.
+func (w *World) ShapeCast(shape Shape, from, to mgl32.Vec2, mask uint32) (hit ShapeCastHit, ok bool) {
+	var (
+		delta  = to.Sub(from)
+		start  = shape.place(from, 0)
+		swept  = start.bounds.Union(shape.place(to, 0).bounds)
+		size   = start.bounds.Size()
+		step   = max32(min32(size.X(), size.Y())/2, contactSlop)
+		steps  = int(math.Ceil(float64(delta.Len() / step)))
+		best   = float32(math.Inf(1))
+		placed placedShape
+	)
+	if steps > maxCastSteps {
+		steps = maxCastSteps
+	}
+	for _, c := range w.candidates(swept) {
+		if !c.matches(mask, w.cfg.QueryTriggers) {
+			continue
+		}
+		if placed = c.place(); !swept.Overlaps(placed.bounds) {
+			continue
+		}
+		if t, m, found := castShape(shape, from, delta, steps, &placed); found && t < best {
+			best, ok = t, true
+			hit = ShapeCastHit{
+				Collider: c,
+				Point:    manifoldPoint(m),
+				Normal:   m.normal.Mul(-1),
+				Position: from.Add(delta.Mul(t)),
+				Fraction: t,
+			}
+		}
+	}
+	return
+}
+
+// Steps the shape towards other, then bisects to find the last fraction
+// before they touch and how they touch just after.
+func castShape(shape Shape, from, delta mgl32.Vec2, steps int, other *placedShape) (t float32, m manifold, ok bool) {
+	var overlap = func(fraction float32) (manifold, bool) {
+		placed := shape.place(from.Add(delta.Mul(fraction)), 0)
+		return collide(&placed, other)
+	}
+	if m, ok = overlap(0); ok {
+		return
+	}
+	for i := 1; i <= steps; i++ {
+		hi := float32(i) / float32(steps)
+		if m, ok = overlap(hi); !ok {
+			t = hi
+			continue
+		}
+		for j := 0; j < 16; j++ {
+			mid := (t + hi) / 2
+			if next, touching := overlap(mid); touching {
+				hi, m = mid, next
+			} else {
+				t = mid
+			}
+		}
+		return
+	}
+	return 0, m, false
+}
+
+func manifoldPoint(m manifold) (p mgl32.Vec2) {
+	for _, point := range m.points {
+		p = p.Add(point)
+	}
+	return p.Mul(1 / float32(len(m.points)))
+}
+
+// Returns the colliders in mask which shape would overlap at position.
+func (w *World) Overlap(shape Shape, position mgl32.Vec2, mask uint32) (found []*Collider) {
+	var placed = shape.place(position, 0)
+	for _, c := range w.candidates(placed.bounds) {
+		if !c.matches(mask, w.cfg.QueryTriggers) {
+			continue
+		}
+		other := c.place()
+		if _, ok := collide(&placed, &other); ok {
+			found = append(found, c)
+		}
+	}
+	return
+}
+
+// Returns the colliders in mask containing point.
+func (w *World) QueryPoint(point mgl32.Vec2, mask uint32) []*Collider {
+	return w.Overlap(Circle{Radius: contactSlop}, point, mask)
+}
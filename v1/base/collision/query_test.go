@@ -0,0 +1,105 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collision
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"testing"
+)
+
+// A 2x2 box at x 10 in layer 1, a circle of radius 1 at x 20 in layer 2, and
+// a box trigger at x 5.
+func queryWorld(triggers bool) (w *World, box, circle, trigger *Collider) {
+	w = NewWorld(WorldConfig{QueryTriggers: triggers})
+	box = NewCollider(NewTransform(mgl32.Vec3{10, 0, 0}), NewAABB(2, 2))
+	circle = NewCollider(NewTransform(mgl32.Vec3{20, 0, 0}), Circle{Radius: 1})
+	circle.Layer = 2
+	trigger = NewCollider(NewTransform(mgl32.Vec3{5, 0, 0}), NewAABB(2, 2))
+	trigger.Trigger = true
+	w.Add(box)
+	w.Add(circle)
+	w.Add(trigger)
+	return
+}
+
+func TestRaycast(t *testing.T) {
+	var (
+		w, box, circle, _ = queryWorld(false)
+		tests             = []struct {
+			name      string
+			origin    mgl32.Vec2
+			direction mgl32.Vec2
+			distance  float32
+			mask      uint32
+			hit       *Collider
+			point     mgl32.Vec2
+			normal    mgl32.Vec2
+		}{
+			{"nearest", mgl32.Vec2{0, 0}, mgl32.Vec2{2, 0}, 100, AllLayers, box, mgl32.Vec2{9, 0}, mgl32.Vec2{-1, 0}},
+			{"masked", mgl32.Vec2{0, 0}, mgl32.Vec2{1, 0}, 100, 2, circle, mgl32.Vec2{19, 0}, mgl32.Vec2{-1, 0}},
+			{"from behind", mgl32.Vec2{30, 0}, mgl32.Vec2{-1, 0}, 100, AllLayers, circle, mgl32.Vec2{21, 0}, mgl32.Vec2{1, 0}},
+			{"from above", mgl32.Vec2{10.5, 5}, mgl32.Vec2{0, -1}, 100, AllLayers, box, mgl32.Vec2{10.5, 1}, mgl32.Vec2{0, 1}},
+			{"inside", mgl32.Vec2{10, 0}, mgl32.Vec2{1, 0}, 100, AllLayers, circle, mgl32.Vec2{19, 0}, mgl32.Vec2{-1, 0}},
+			{"too short", mgl32.Vec2{0, 0}, mgl32.Vec2{1, 0}, 8.5, AllLayers, nil, mgl32.Vec2{}, mgl32.Vec2{}},
+			{"missed", mgl32.Vec2{0, 2}, mgl32.Vec2{1, 0}, 100, AllLayers, nil, mgl32.Vec2{}, mgl32.Vec2{}},
+			{"no direction", mgl32.Vec2{0, 0}, mgl32.Vec2{}, 100, AllLayers, nil, mgl32.Vec2{}, mgl32.Vec2{}},
+		}
+	)
+	for _, test := range tests {
+		hit, ok := w.Raycast(test.origin, test.direction, test.distance, test.mask)
+		if ok != (test.hit != nil) || hit.Collider != test.hit {
+			t.Errorf("%v: hit %v, %v", test.name, hit.Collider, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if !near(hit.Point, test.point) || !near(hit.Normal, test.normal) {
+			t.Errorf("%v: hit %v facing %v, want %v facing %v", test.name, hit.Point, hit.Normal, test.point, test.normal)
+		}
+		if want := hit.Point.Sub(test.origin).Len(); hit.Distance-want > 1e-4 || want-hit.Distance > 1e-4 {
+			t.Errorf("%v: distance %v, want %v", test.name, hit.Distance, want)
+		}
+	}
+}
+
+func TestRaycastAll(t *testing.T) {
+	var (
+		w, box, circle, trigger = queryWorld(true)
+		hits                    = w.RaycastAll(mgl32.Vec2{30, 0}, mgl32.Vec2{-1, 0}, 100, AllLayers)
+		want                    = []*Collider{circle, box, trigger}
+	)
+	if len(hits) != len(want) {
+		t.Fatalf("%v hits, want %v", len(hits), len(want))
+	}
+	for i, hit := range hits {
+		if hit.Collider != want[i] {
+			t.Errorf("Hit %v is %v, want %v", i, hit.Collider, want[i])
+		}
+	}
+}
+
+func TestOverlap(t *testing.T) {
+	var w, box, circle, _ = queryWorld(false)
+	if found := w.Overlap(NewAABB(24, 1), mgl32.Vec2{12, 0}, AllLayers); len(found) != 2 || found[0] != box || found[1] != circle {
+		t.Errorf("Overlap found %v, want the box and the circle", found)
+	}
+	if found := w.QueryPoint(mgl32.Vec2{20.5, 0.5}, AllLayers); len(found) != 1 || found[0] != circle {
+		t.Errorf("QueryPoint found %v, want the circle", found)
+	}
+	if found := w.QueryPoint(mgl32.Vec2{5, 0}, AllLayers); len(found) != 0 {
+		t.Errorf("QueryPoint found %v in a trigger", found)
+	}
+}
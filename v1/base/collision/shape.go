@@ -0,0 +1,226 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collision
+
+import (
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"math"
+)
+
+// Shapes are defined around their collider's position, in world units.
+// Implemented by AABB, Circle and *Polygon.
+type Shape interface {
+	// Returns the shape moved to position and rotated by degrees.
+	place(position mgl32.Vec2, rotation float32) placedShape
+}
+
+// An axis aligned box, which stays aligned when its body rotates.
+type AABB struct {
+	Min mgl32.Vec2
+	Max mgl32.Vec2
+}
+
+// Returns a w by h box centered on the origin.
+func NewAABB(w, h float32) AABB {
+	return AABB{
+		Min: mgl32.Vec2{-w / 2, -h / 2},
+		Max: mgl32.Vec2{w / 2, h / 2},
+	}
+}
+
+func (b AABB) Size() mgl32.Vec2 {
+	return b.Max.Sub(b.Min)
+}
+
+func (b AABB) Center() mgl32.Vec2 {
+	return b.Min.Add(b.Max).Mul(0.5)
+}
+
+func (b AABB) Overlaps(o AABB) bool {
+	return b.Min.X() <= o.Max.X() && o.Min.X() <= b.Max.X() &&
+		b.Min.Y() <= o.Max.Y() && o.Min.Y() <= b.Max.Y()
+}
+
+func (b AABB) Union(o AABB) AABB {
+	return AABB{
+		Min: mgl32.Vec2{min32(b.Min.X(), o.Min.X()), min32(b.Min.Y(), o.Min.Y())},
+		Max: mgl32.Vec2{max32(b.Max.X(), o.Max.X()), max32(b.Max.Y(), o.Max.Y())},
+	}
+}
+
+func (b AABB) place(position mgl32.Vec2, rotation float32) placedShape {
+	var (
+		min = b.Min.Add(position)
+		max = b.Max.Add(position)
+	)
+	return newPlacedPolygon([]mgl32.Vec2{
+		min,
+		mgl32.Vec2{max.X(), min.Y()},
+		max,
+		mgl32.Vec2{min.X(), max.Y()},
+	})
+}
+
+type Circle struct {
+	Center mgl32.Vec2
+	Radius float32
+}
+
+func (c Circle) place(position mgl32.Vec2, rotation float32) (s placedShape) {
+	s.circle = true
+	s.center = position.Add(rotate(c.Center, rotation))
+	s.radius = c.Radius
+	s.bounds = AABB{
+		Min: s.center.Sub(mgl32.Vec2{c.Radius, c.Radius}),
+		Max: s.center.Add(mgl32.Vec2{c.Radius, c.Radius}),
+	}
+	return
+}
+
+// A convex polygon, which rotates with its body.
+type Polygon struct {
+	points []mgl32.Vec2
+}
+
+// Points may wind either way, but the polygon must be convex with no
+// repeated or collinear points.
+func NewPolygon(points ...mgl32.Vec2) (p *Polygon, err error) {
+	var (
+		count = len(points)
+		sign  float32
+		cross float32
+	)
+	if count < 3 {
+		err = fmt.Errorf("Polygon needs at least 3 points, got %v", count)
+		return
+	}
+	for i := range points {
+		var (
+			a = points[i]
+			b = points[(i+1)%count]
+			c = points[(i+2)%count]
+		)
+		if cross = cross2(b.Sub(a), c.Sub(b)); cross == 0 {
+			err = fmt.Errorf("Polygon has repeated or collinear points at %v", (i+1)%count)
+			return
+		}
+		if sign == 0 {
+			sign = cross
+		} else if (sign > 0) != (cross > 0) {
+			err = fmt.Errorf("Polygon is not convex at point %v", (i+1)%count)
+			return
+		}
+	}
+	p = &Polygon{points: make([]mgl32.Vec2, count)}
+	for i, point := range points {
+		if sign < 0 {
+			// Store counterclockwise, so edge normals point outwards.
+			p.points[count-1-i] = point
+		} else {
+			p.points[i] = point
+		}
+	}
+	return
+}
+
+// Returns the points in counterclockwise order.
+func (p *Polygon) Points() []mgl32.Vec2 {
+	return p.points
+}
+
+func (p *Polygon) place(position mgl32.Vec2, rotation float32) placedShape {
+	var points = make([]mgl32.Vec2, len(p.points))
+	for i, point := range p.points {
+		points[i] = position.Add(rotate(point, rotation))
+	}
+	return newPlacedPolygon(points)
+}
+
+// A shape in world space.
+type placedShape struct {
+	circle  bool
+	center  mgl32.Vec2 // Of the circle, or the average of the points.
+	radius  float32
+	points  []mgl32.Vec2 // Counterclockwise.
+	normals []mgl32.Vec2 // Outward normal of the edge starting at each point.
+	bounds  AABB
+}
+
+func newPlacedPolygon(points []mgl32.Vec2) (s placedShape) {
+	var count = len(points)
+	s.points = points
+	s.normals = make([]mgl32.Vec2, count)
+	s.bounds = AABB{Min: points[0], Max: points[0]}
+	for i, p := range points {
+		edge := points[(i+1)%count].Sub(p)
+		s.normals[i] = mgl32.Vec2{edge.Y(), -edge.X()}.Normalize()
+		s.center = s.center.Add(p)
+		s.bounds = s.bounds.Union(AABB{Min: p, Max: p})
+	}
+	s.center = s.center.Mul(1 / float32(count))
+	return
+}
+
+// Returns the range of the shape's projection onto axis.
+func (s *placedShape) project(axis mgl32.Vec2) (min, max float32) {
+	if s.circle {
+		c := s.center.Dot(axis)
+		return c - s.radius, c + s.radius
+	}
+	min, max = s.points[0].Dot(axis), s.points[0].Dot(axis)
+	for _, p := range s.points[1:] {
+		d := p.Dot(axis)
+		min, max = min32(min, d), max32(max, d)
+	}
+	return
+}
+
+// Rotates v counterclockwise by degrees, as render.Instance does.
+func rotate(v mgl32.Vec2, degrees float32) mgl32.Vec2 {
+	if degrees == 0 {
+		return v
+	}
+	var (
+		rad      = float64(mgl32.DegToRad(degrees))
+		sin, cos = float32(math.Sin(rad)), float32(math.Cos(rad))
+	)
+	return mgl32.Vec2{v.X()*cos - v.Y()*sin, v.X()*sin + v.Y()*cos}
+}
+
+func cross2(a, b mgl32.Vec2) float32 {
+	return a.X()*b.Y() - a.Y()*b.X()
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func abs32(a float32) float32 {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
@@ -0,0 +1,277 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collision
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"math"
+	"sort"
+)
+
+// A pair of colliders found overlapping by World.Step. Normal points from A
+// to B, and moving B by Normal*Depth separates them.
+type Contact struct {
+	A      *Collider
+	B      *Collider
+	Normal mgl32.Vec2
+	Depth  float32
+	Points []mgl32.Vec2 // One or two points in world space.
+}
+
+// Returns the average of the contact points.
+func (c Contact) Point() mgl32.Vec2 {
+	return manifoldPoint(manifold{points: c.Points})
+}
+
+// Returns whether either collider is a trigger, so nothing should be solid.
+func (c Contact) Trigger() bool {
+	return c.A.Trigger || c.B.Trigger
+}
+
+// Sent on the first step two colliders overlap.
+type BeginEvent struct {
+	Contact
+}
+
+// Sent on each later step they still overlap.
+type StayEvent struct {
+	Contact
+}
+
+// Sent on the first step they no longer overlap, or after either is removed.
+// The contact holds the last overlap seen.
+type EndEvent struct {
+	Contact
+}
+
+// Receives BeginEvent, StayEvent and EndEvent values.
+type EventHandler func(event interface{})
+
+type WorldConfig struct {
+	CellSize      float32 // Of the broadphase grid, in world units. Defaults to 4.
+	QueryTriggers bool    // Whether raycasts and other queries hit triggers.
+}
+
+func (c WorldConfig) withDefaults() WorldConfig {
+	if c.CellSize <= 0 {
+		c.CellSize = 4
+	}
+	return c
+}
+
+type cell struct {
+	x, y int
+}
+
+type pairKey struct {
+	a, b uint64
+}
+
+// Finds overlapping colliders with a spatial hash broadphase and separating
+// axis tests. Contacts and events are ordered by when colliders were added,
+// so that results are deterministic. Queries test colliders where their
+// bodies are now, but find them by where they were at the last Step.
+type World struct {
+	cfg       WorldConfig
+	colliders []*Collider
+	nextID    uint64
+	cells     map[cell][]*Collider
+	dirty     bool // Colliders were added or removed since cells were built.
+	contacts  []Contact
+	touching  map[pairKey]bool
+	handler   EventHandler
+}
+
+func NewWorld(cfg WorldConfig) *World {
+	return &World{
+		cfg:      cfg.withDefaults(),
+		nextID:   1,
+		cells:    map[cell][]*Collider{},
+		touching: map[pairKey]bool{},
+	}
+}
+
+func (w *World) Config() WorldConfig {
+	return w.cfg
+}
+
+// Sends overlap events from Step to handler, such as the Notify method of a
+// scene's event bus.
+func (w *World) SetEventHandler(handler EventHandler) {
+	w.handler = handler
+}
+
+func (w *World) Add(c *Collider) {
+	if c.world == w {
+		return
+	}
+	if c.world != nil {
+		c.world.Remove(c)
+	}
+	c.world = w
+	c.id = w.nextID
+	w.nextID++
+	w.colliders = append(w.colliders, c)
+	w.dirty = true
+}
+
+// Removes c, ending its contacts on the next Step.
+func (w *World) Remove(c *Collider) {
+	if c.world != w {
+		return
+	}
+	for i, other := range w.colliders {
+		if other == c {
+			copy(w.colliders[i:], w.colliders[i+1:])
+			w.colliders[len(w.colliders)-1] = nil
+			w.colliders = w.colliders[:len(w.colliders)-1]
+			break
+		}
+	}
+	c.world = nil
+	w.dirty = true
+}
+
+func (w *World) Colliders() []*Collider {
+	return w.colliders
+}
+
+// Returns the overlaps found by the last Step, including triggers.
+func (w *World) Contacts() []Contact {
+	return w.contacts
+}
+
+func (w *World) cellRange(bounds AABB) (min, max cell) {
+	var size = w.cfg.CellSize
+	min = cell{int(math.Floor(float64(bounds.Min.X() / size))), int(math.Floor(float64(bounds.Min.Y() / size)))}
+	max = cell{int(math.Floor(float64(bounds.Max.X() / size))), int(math.Floor(float64(bounds.Max.Y() / size)))}
+	return
+}
+
+// Places every collider at its body's position and hashes it into cells.
+func (w *World) rebuild() {
+	for key := range w.cells {
+		delete(w.cells, key)
+	}
+	for _, c := range w.colliders {
+		c.placed = c.place()
+		min, max := w.cellRange(c.placed.bounds)
+		for y := min.y; y <= max.y; y++ {
+			for x := min.x; x <= max.x; x++ {
+				w.cells[cell{x, y}] = append(w.cells[cell{x, y}], c)
+			}
+		}
+	}
+	w.dirty = false
+}
+
+// Returns colliders in cells overlapping bounds, ordered by id. Positions are
+// those of the last Step.
+func (w *World) candidates(bounds AABB) (found []*Collider) {
+	var seen = map[*Collider]bool{}
+	if w.dirty {
+		w.rebuild()
+	}
+	min, max := w.cellRange(bounds)
+	for y := min.y; y <= max.y; y++ {
+		for x := min.x; x <= max.x; x++ {
+			for _, c := range w.cells[cell{x, y}] {
+				if !seen[c] {
+					seen[c] = true
+					found = append(found, c)
+				}
+			}
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].id < found[j].id
+	})
+	return
+}
+
+// Returns pairs sharing a cell with overlapping bounds, ordered by id.
+func (w *World) pairs() (pairs [][2]*Collider) {
+	var seen = map[pairKey]bool{}
+	for _, a := range w.colliders {
+		min, max := w.cellRange(a.placed.bounds)
+		for y := min.y; y <= max.y; y++ {
+			for x := min.x; x <= max.x; x++ {
+				for _, b := range w.cells[cell{x, y}] {
+					key := pairKey{a.id, b.id}
+					if b.id <= a.id || seen[key] {
+						continue
+					}
+					seen[key] = true
+					if a.placed.bounds.Overlaps(b.placed.bounds) && a.collides(b) {
+						pairs = append(pairs, [2]*Collider{a, b})
+					}
+				}
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0].id != pairs[j][0].id {
+			return pairs[i][0].id < pairs[j][0].id
+		}
+		return pairs[i][1].id < pairs[j][1].id
+	})
+	return
+}
+
+// Finds overlaps at the bodies' current positions and sends begin, stay and
+// end events. Call once per update, after bodies have moved.
+func (w *World) Step() {
+	var (
+		previous = w.contacts
+		touching = map[pairKey]bool{}
+		contacts []Contact
+	)
+	w.rebuild()
+	for _, pair := range w.pairs() {
+		a, b := pair[0], pair[1]
+		m, ok := collide(&a.placed, &b.placed)
+		if !ok {
+			continue
+		}
+		contact := Contact{
+			A:      a,
+			B:      b,
+			Normal: m.normal,
+			Depth:  m.depth,
+			Points: m.points,
+		}
+		key := pairKey{a.id, b.id}
+		touching[key] = true
+		contacts = append(contacts, contact)
+		if w.touching[key] {
+			w.notify(StayEvent{contact})
+		} else {
+			w.notify(BeginEvent{contact})
+		}
+	}
+	for _, contact := range previous {
+		if !touching[pairKey{contact.A.id, contact.B.id}] {
+			w.notify(EndEvent{contact})
+		}
+	}
+	w.contacts = contacts
+	w.touching = touching
+}
+
+func (w *World) notify(event interface{}) {
+	if w.handler != nil {
+		w.handler(event)
+	}
+}
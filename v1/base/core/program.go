@@ -30,6 +30,14 @@ func (u *Uniform) Mat4(m mgl32.Mat4) {
 	gl.UniformMatrix4fv(u.location, 1, false, &m[0])
 }
 
+func (u *Uniform) Float(f float32) {
+	gl.Uniform1f(u.location, f)
+}
+
+func (u *Uniform) Vec4(v mgl32.Vec4) {
+	gl.Uniform4f(u.location, v[0], v[1], v[2], v[3])
+}
+
 type Program struct {
 	vao     uint32
 	program uint32
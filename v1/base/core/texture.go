@@ -19,6 +19,7 @@ import (
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
 	"image"
+	"image/draw"
 )
 
 type TextureSmoothing int
@@ -82,6 +83,41 @@ func (t *Texture) Delete() {
 	}
 }
 
+// Replaces rect of the texture with the same region of img, which must have
+// the size the texture was created with.
+func (t *Texture) Update(img image.Image, rect image.Rectangle) (err error) {
+	var (
+		bounds = img.Bounds()
+		height = int(t.OriginalSize.Y())
+		sub    *image.RGBA
+		data   *bytes.Buffer
+	)
+	if rect = rect.Intersect(bounds); rect.Empty() {
+		return
+	}
+	sub = image.NewRGBA(rect)
+	draw.Draw(sub, rect, img, rect.Min, draw.Src)
+	if data, err = imageBytes(sub); err != nil {
+		return
+	}
+	// Texture rows are stored bottom to top.
+	gl.BindTexture(gl.TEXTURE_2D, t.id)
+	gl.TexSubImage2D(
+		gl.TEXTURE_2D,
+		0,
+		int32(rect.Min.X-bounds.Min.X),
+		int32(height-(rect.Max.Y-bounds.Min.Y)),
+		int32(rect.Dx()),
+		int32(rect.Dy()),
+		gl.RGBA,
+		gl.UNSIGNED_INT_8_8_8_8,
+		gl.Ptr(data.Bytes()),
+	)
+	gl.GenerateMipmap(gl.TEXTURE_2D)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return
+}
+
 func getGLTexture(img image.Image, smoothing TextureSmoothing) (t uint32, err error) {
 	var (
 		data   *bytes.Buffer
@@ -0,0 +1,230 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loaders
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pikkpoiss/gamejam/v1/base/core"
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+	"image"
+	"io/ioutil"
+	"path"
+	"time"
+)
+
+type asepriteFrame struct {
+	texturePackerFrame
+	Duration int `json:"duration"`
+}
+
+type asepriteFrameTag struct {
+	Name      string `json:"name"`
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+	Direction string `json:"direction"`
+}
+
+type asepriteSliceKey struct {
+	Frame  int                     `json:"frame"`
+	Bounds texturePackerIntCoords  `json:"bounds"`
+	Center *texturePackerIntCoords `json:"center"`
+	Pivot  *texturePackerIntCoords `json:"pivot"`
+}
+
+type asepriteSlice struct {
+	Name  string             `json:"name"`
+	Color string             `json:"color"`
+	Data  string             `json:"data"`
+	Keys  []asepriteSliceKey `json:"keys"`
+}
+
+type asepriteMeta struct {
+	Image     string             `json:"image"`
+	Format    string             `json:"format"`
+	Size      texturePackerSize  `json:"size"`
+	Scale     string             `json:"scale"`
+	FrameTags []asepriteFrameTag `json:"frameTags"`
+	Slices    []asepriteSlice    `json:"slices"`
+}
+
+type asepriteJSON struct {
+	Frames json.RawMessage `json:"frames"`
+	Meta   asepriteMeta    `json:"meta"`
+}
+
+// Aseprite exports frames either as an array or as a hash keyed by filename.
+// Frame tags refer to frames by index, so order must be preserved.
+func parseAsepriteFrames(data json.RawMessage) (frames []asepriteFrame, err error) {
+	err = decodeFrames(data, func(key string, raw json.RawMessage) (err error) {
+		var frame asepriteFrame
+		if err = json.Unmarshal(raw, &frame); err != nil {
+			return
+		}
+		if key != "" {
+			frame.Filename = key
+		}
+		frames = append(frames, frame)
+		return
+	})
+	return
+}
+
+func parseAsepriteDirection(direction string) (out sprites.AnimationDirection, err error) {
+	switch direction {
+	case "", "forward":
+		out = sprites.AnimationForward
+	case "reverse":
+		out = sprites.AnimationReverse
+	case "pingpong":
+		out = sprites.AnimationPingPong
+	case "pingpong_reverse":
+		out = sprites.AnimationPingPongReverse
+	default:
+		err = fmt.Errorf("Unknown animation direction %v", direction)
+	}
+	return
+}
+
+type AsepriteLoader struct {
+}
+
+func NewAsepriteLoader() *AsepriteLoader {
+	return &AsepriteLoader{}
+}
+
+func (l *AsepriteLoader) Load(jsonPath string, smoothing core.TextureSmoothing) (sheet *sprites.Sheet, err error) {
+	var (
+		dir         string
+		data        []byte
+		texturePath string
+		parsed      asepriteJSON
+		frames      []asepriteFrame
+		texture     *core.Texture
+	)
+	dir = path.Dir(jsonPath)
+	if data, err = ioutil.ReadFile(jsonPath); err != nil {
+		return
+	}
+	if err = json.Unmarshal(data, &parsed); err != nil {
+		return
+	}
+	if frames, err = parseAsepriteFrames(parsed.Frames); err != nil {
+		return
+	}
+	sheet = sprites.NewSheet()
+	for _, frame := range frames {
+		sheet.AddSpriteData(frame.Filename, frame.spriteData())
+	}
+	if err = l.addAnimations(sheet, frames, parsed.Meta.FrameTags); err != nil {
+		return
+	}
+	if err = l.addSlices(sheet, frames, parsed.Meta.Slices); err != nil {
+		return
+	}
+	texturePath = path.Join(dir, parsed.Meta.Image)
+	if texture, err = core.LoadTexture(texturePath, smoothing); err != nil {
+		return
+	}
+	sheet.SetTexture(texture)
+	return
+}
+
+func (l *AsepriteLoader) addAnimations(sheet *sprites.Sheet, frames []asepriteFrame, tags []asepriteFrameTag) (err error) {
+	var (
+		animation *sprites.Animation
+		direction sprites.AnimationDirection
+		i         int
+	)
+	for _, tag := range tags {
+		if tag.From < 0 || tag.To >= len(frames) || tag.From > tag.To {
+			err = fmt.Errorf("Frame tag %v has invalid range %v-%v", tag.Name, tag.From, tag.To)
+			return
+		}
+		if direction, err = parseAsepriteDirection(tag.Direction); err != nil {
+			return
+		}
+		animation = sprites.NewAnimation(tag.Name, direction)
+		for i = tag.From; i <= tag.To; i++ {
+			animation.AddFrame(
+				frames[i].Filename,
+				time.Duration(frames[i].Duration)*time.Millisecond,
+			)
+		}
+		if err = sheet.AddAnimation(animation); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Slice keys apply from their frame up to the frame of the next key. Aseprite
+// gives slice bounds relative to the untrimmed frame, so they are shifted by
+// each frame's trim offset to be relative to the sprite. Centers and pivots
+// are relative to the slice bounds and need no adjustment.
+func (l *AsepriteLoader) addSlices(sheet *sprites.Sheet, frames []asepriteFrame, slices []asepriteSlice) (err error) {
+	var (
+		key    asepriteSliceKey
+		next   int
+		i      int
+		j      int
+		sprite *sprites.Sprite
+		out    sprites.SpriteSlice
+	)
+	for _, slice := range slices {
+		for i, key = range slice.Keys {
+			if key.Frame < 0 || key.Frame >= len(frames) {
+				err = fmt.Errorf("Slice %v references invalid frame %v", slice.Name, key.Frame)
+				return
+			}
+			next = len(frames)
+			if i+1 < len(slice.Keys) {
+				next = slice.Keys[i+1].Frame
+			}
+			out = sprites.SpriteSlice{
+				Name:   slice.Name,
+				Bounds: asepriteRect(key.Bounds),
+				Color:  slice.Color,
+				Data:   slice.Data,
+			}
+			if key.Center != nil {
+				out.Center = asepriteRect(*key.Center)
+				out.HasCenter = true
+			}
+			if key.Pivot != nil {
+				out.Pivot = image.Pt(key.Pivot.X, key.Pivot.Y)
+				out.HasPivot = true
+			}
+			for j = key.Frame; j < next && j < len(frames); j++ {
+				if sprite, err = sheet.Sprite(frames[j].Filename); err != nil {
+					return
+				}
+				sprite.SetSlice(frames[j].trimSlice(out))
+			}
+		}
+	}
+	return
+}
+
+func (f asepriteFrame) trimSlice(slice sprites.SpriteSlice) sprites.SpriteSlice {
+	if f.Trimmed {
+		slice.Bounds = slice.Bounds.Sub(image.Pt(f.SpriteSourceSize.X, f.SpriteSourceSize.Y))
+	}
+	return slice
+}
+
+func asepriteRect(c texturePackerIntCoords) image.Rectangle {
+	return image.Rect(c.X, c.Y, c.X+c.W, c.Y+c.H)
+}
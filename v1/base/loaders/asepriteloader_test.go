@@ -0,0 +1,77 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loaders
+
+import (
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+	"image"
+	"testing"
+)
+
+func TestAsepriteSlicesFollowTrim(t *testing.T) {
+	var (
+		frames = []asepriteFrame{
+			{texturePackerFrame: texturePackerFrame{
+				Filename: "untrimmed",
+				Frame:    texturePackerIntCoords{W: 16, H: 16},
+			}},
+			{texturePackerFrame: texturePackerFrame{
+				Filename:         "trimmed",
+				Frame:            texturePackerIntCoords{X: 16, W: 10, H: 12},
+				Trimmed:          true,
+				SpriteSourceSize: texturePackerIntCoords{X: 3, Y: 2, W: 10, H: 12},
+				SourceSize:       texturePackerSize{W: 16, H: 16},
+			}},
+		}
+		slices = []asepriteSlice{{
+			Name: "hitbox",
+			Keys: []asepriteSliceKey{{
+				Frame:  0,
+				Bounds: texturePackerIntCoords{X: 4, Y: 5, W: 6, H: 7},
+				Center: &texturePackerIntCoords{X: 1, Y: 1, W: 4, H: 5},
+				Pivot:  &texturePackerIntCoords{X: 3, Y: 7},
+			}},
+		}}
+		sheet = sprites.NewSheet()
+		tests = []struct {
+			sprite string
+			bounds image.Rectangle
+		}{
+			{"untrimmed", image.Rect(4, 5, 10, 12)},
+			{"trimmed", image.Rect(1, 3, 7, 10)},
+		}
+	)
+	for _, frame := range frames {
+		sheet.AddSpriteData(frame.Filename, frame.spriteData())
+	}
+	if err := NewAsepriteLoader().addSlices(sheet, frames, slices); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		var (
+			sprite, _  = sheet.Sprite(test.sprite)
+			slice, err = sprite.Slice("hitbox")
+		)
+		if err != nil {
+			t.Fatalf("%v: %v", test.sprite, err)
+		}
+		if slice.Bounds != test.bounds {
+			t.Errorf("%v: got bounds %v, want %v", test.sprite, slice.Bounds, test.bounds)
+		}
+		if slice.Center != image.Rect(1, 1, 5, 6) || slice.Pivot != image.Pt(3, 7) {
+			t.Errorf("%v: center %v and pivot %v moved", test.sprite, slice.Center, slice.Pivot)
+		}
+	}
+}
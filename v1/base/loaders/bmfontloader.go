@@ -0,0 +1,357 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loaders
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pikkpoiss/gamejam/v1/base/core"
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+	"image"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type bmfontInfo struct {
+	Face string `xml:"face,attr"`
+	Size int    `xml:"size,attr"`
+}
+
+type bmfontCommon struct {
+	LineHeight int `xml:"lineHeight,attr"`
+	Base       int `xml:"base,attr"`
+	ScaleW     int `xml:"scaleW,attr"`
+	ScaleH     int `xml:"scaleH,attr"`
+}
+
+type bmfontPage struct {
+	ID   int    `xml:"id,attr"`
+	File string `xml:"file,attr"`
+}
+
+type bmfontChar struct {
+	ID       int `xml:"id,attr"`
+	X        int `xml:"x,attr"`
+	Y        int `xml:"y,attr"`
+	Width    int `xml:"width,attr"`
+	Height   int `xml:"height,attr"`
+	XOffset  int `xml:"xoffset,attr"`
+	YOffset  int `xml:"yoffset,attr"`
+	XAdvance int `xml:"xadvance,attr"`
+	Page     int `xml:"page,attr"`
+}
+
+type bmfontKerning struct {
+	First  int `xml:"first,attr"`
+	Second int `xml:"second,attr"`
+	Amount int `xml:"amount,attr"`
+}
+
+type bmfontDescription struct {
+	XMLName  xml.Name        `xml:"font"`
+	Info     bmfontInfo      `xml:"info"`
+	Common   bmfontCommon    `xml:"common"`
+	Pages    []bmfontPage    `xml:"pages>page"`
+	Chars    []bmfontChar    `xml:"chars>char"`
+	Kernings []bmfontKerning `xml:"kernings>kerning"`
+}
+
+// Splits a line of the text format into its tag and key=value attributes.
+// Values may be quoted and contain spaces.
+func parseBMFontLine(line string) (tag string, attrs map[string]string, err error) {
+	var (
+		i     int
+		key   string
+		value string
+		end   int
+	)
+	attrs = map[string]string{}
+	line = strings.TrimSpace(line)
+	if i = strings.IndexByte(line, ' '); i == -1 {
+		tag = line
+		return
+	}
+	tag, line = line[:i], strings.TrimSpace(line[i:])
+	for line != "" {
+		if i = strings.IndexByte(line, '='); i == -1 {
+			err = fmt.Errorf("Expected key=value in %v", line)
+			return
+		}
+		key, line = line[:i], line[i+1:]
+		if strings.HasPrefix(line, "\"") {
+			if end = strings.IndexByte(line[1:], '"'); end == -1 {
+				err = fmt.Errorf("Unterminated quote in %v", line)
+				return
+			}
+			value, line = line[1:end+1], line[end+2:]
+		} else {
+			if end = strings.IndexByte(line, ' '); end == -1 {
+				end = len(line)
+			}
+			value, line = line[:end], line[end:]
+		}
+		attrs[key] = value
+		line = strings.TrimSpace(line)
+	}
+	return
+}
+
+// Reads the named integer attributes into dests, ignoring missing ones.
+func bmfontInts(attrs map[string]string, names []string, dests ...*int) (err error) {
+	for i, name := range names {
+		if value, exists := attrs[name]; exists {
+			if *dests[i], err = strconv.Atoi(value); err != nil {
+				err = fmt.Errorf("Invalid %v: %v", name, value)
+				return
+			}
+		}
+	}
+	return
+}
+
+func parseBMFontText(data []byte) (desc bmfontDescription, err error) {
+	var (
+		tag   string
+		attrs map[string]string
+		char  bmfontChar
+		kern  bmfontKerning
+		page  bmfontPage
+	)
+	for n, line := range strings.Split(string(data), "\n") {
+		if tag, attrs, err = parseBMFontLine(line); err != nil {
+			err = fmt.Errorf("Line %v: %v", n+1, err)
+			return
+		}
+		switch tag {
+		case "info":
+			desc.Info.Face = attrs["face"]
+			err = bmfontInts(attrs, []string{"size"}, &desc.Info.Size)
+		case "common":
+			err = bmfontInts(
+				attrs,
+				[]string{"lineHeight", "base", "scaleW", "scaleH"},
+				&desc.Common.LineHeight,
+				&desc.Common.Base,
+				&desc.Common.ScaleW,
+				&desc.Common.ScaleH,
+			)
+		case "page":
+			page = bmfontPage{File: attrs["file"]}
+			err = bmfontInts(attrs, []string{"id"}, &page.ID)
+			desc.Pages = append(desc.Pages, page)
+		case "char":
+			char = bmfontChar{}
+			err = bmfontInts(
+				attrs,
+				[]string{"id", "x", "y", "width", "height", "xoffset", "yoffset", "xadvance", "page"},
+				&char.ID, &char.X, &char.Y, &char.Width, &char.Height,
+				&char.XOffset, &char.YOffset, &char.XAdvance, &char.Page,
+			)
+			desc.Chars = append(desc.Chars, char)
+		case "kerning":
+			kern = bmfontKerning{}
+			err = bmfontInts(
+				attrs,
+				[]string{"first", "second", "amount"},
+				&kern.First, &kern.Second, &kern.Amount,
+			)
+			desc.Kernings = append(desc.Kernings, kern)
+		}
+		if err != nil {
+			err = fmt.Errorf("Line %v: %v", n+1, err)
+			return
+		}
+	}
+	return
+}
+
+type BMFontGlyph struct {
+	ID      rune
+	Bounds  image.Rectangle // Region of the page image.
+	Offset  image.Point     // From the top left of the line to the top left of the glyph.
+	Advance int
+	Page    int
+}
+
+type BMFontKerning struct {
+	First  rune
+	Second rune
+	Amount int
+}
+
+type bmfontPair struct {
+	first  rune
+	second rune
+}
+
+// A pre-rendered bitmap font. Implements text.GlyphSource.
+type BMFont struct {
+	Face       string
+	Size       int
+	lineHeight int
+	base       int
+	glyphs     map[rune]BMFontGlyph
+	kerning    map[bmfontPair]int
+	sheet      *sprites.Sheet
+}
+
+func (f *BMFont) LineHeight() float32 {
+	return float32(f.lineHeight)
+}
+
+func (f *BMFont) Ascent() float32 {
+	return float32(f.base)
+}
+
+func (f *BMFont) Descent() float32 {
+	return float32(f.lineHeight - f.base)
+}
+
+func (f *BMFont) Advance(r rune) float32 {
+	return float32(f.glyphs[r].Advance)
+}
+
+func (f *BMFont) Kern(a, b rune) float32 {
+	return float32(f.kerning[bmfontPair{a, b}])
+}
+
+// Returns the metrics for r, with ok false if the font doesn't contain it.
+func (f *BMFont) Metrics(r rune) (glyph BMFontGlyph, ok bool) {
+	glyph, ok = f.glyphs[r]
+	return
+}
+
+// Returns every kerning pair, sorted by first and then second rune.
+func (f *BMFont) KerningPairs() (pairs []BMFontKerning) {
+	for pair, amount := range f.kerning {
+		pairs = append(pairs, BMFontKerning{pair.first, pair.second, amount})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].First != pairs[j].First {
+			return pairs[i].First < pairs[j].First
+		}
+		return pairs[i].Second < pairs[j].Second
+	})
+	return
+}
+
+func (f *BMFont) Glyph(r rune) (sprite *sprites.Sprite, offset image.Point, ok bool, err error) {
+	var glyph BMFontGlyph
+	if glyph, ok = f.glyphs[r]; !ok || glyph.Bounds.Empty() {
+		ok = false
+		return
+	}
+	if sprite, err = f.sheet.Sprite(string(r)); err != nil {
+		return
+	}
+	offset = glyph.Offset.Sub(image.Pt(0, f.base))
+	return
+}
+
+func (f *BMFont) Sheet() *sprites.Sheet {
+	return f.sheet
+}
+
+// Glyphs are all added when the font is loaded.
+func (f *BMFont) Upload() error {
+	return nil
+}
+
+func (f *BMFont) Delete() {
+	f.sheet.Delete()
+}
+
+// Loads fonts in the text or XML .fnt formats written by BMFont, Hiero and
+// similar tools. Each glyph is a sprite keyed by the string of its rune.
+type BMFontLoader struct {
+}
+
+func NewBMFontLoader() *BMFontLoader {
+	return &BMFontLoader{}
+}
+
+func (l *BMFontLoader) Load(fntPath string, smoothing core.TextureSmoothing) (font *BMFont, err error) {
+	var (
+		data    []byte
+		desc    bmfontDescription
+		texture *core.Texture
+		glyph   BMFontGlyph
+		sprite  sprites.SpriteData
+	)
+	if data, err = ioutil.ReadFile(fntPath); err != nil {
+		return
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		err = xml.Unmarshal(data, &desc)
+	} else {
+		desc, err = parseBMFontText(data)
+	}
+	if err != nil {
+		err = fmt.Errorf("%v: %v", fntPath, err)
+		return
+	}
+	if len(desc.Pages) == 0 {
+		err = fmt.Errorf("%v: No pages", fntPath)
+		return
+	}
+	font = &BMFont{
+		Face:       desc.Info.Face,
+		Size:       desc.Info.Size,
+		lineHeight: desc.Common.LineHeight,
+		base:       desc.Common.Base,
+		glyphs:     map[rune]BMFontGlyph{},
+		kerning:    map[bmfontPair]int{},
+		sheet:      sprites.NewSheet(),
+	}
+	if font.Size < 0 {
+		// Negative sizes ask for matching character height rather than cell height.
+		font.Size = -font.Size
+	}
+	for _, page := range desc.Pages {
+		if texture, err = core.LoadTexture(path.Join(path.Dir(fntPath), page.File), smoothing); err != nil {
+			font.Delete()
+			return
+		}
+		font.sheet.SetPageTexture(page.ID, texture)
+	}
+	for _, char := range desc.Chars {
+		glyph = BMFontGlyph{
+			ID:      rune(char.ID),
+			Bounds:  image.Rect(char.X, char.Y, char.X+char.Width, char.Y+char.Height),
+			Offset:  image.Pt(char.XOffset, char.YOffset),
+			Advance: char.XAdvance,
+			Page:    char.Page,
+		}
+		font.glyphs[glyph.ID] = glyph
+		if glyph.Bounds.Empty() {
+			continue
+		}
+		sprite = sprites.NewSpriteData(
+			mgl32.Vec2{float32(char.Width), float32(char.Height)},
+			mgl32.Vec2{float32(char.X), float32(char.Y)},
+		)
+		sprite.Page = char.Page
+		font.sheet.AddSpriteData(string(glyph.ID), sprite)
+	}
+	for _, kern := range desc.Kernings {
+		font.kerning[bmfontPair{rune(kern.First), rune(kern.Second)}] = kern.Amount
+	}
+	return
+}
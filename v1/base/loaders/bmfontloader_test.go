@@ -0,0 +1,105 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loaders
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+const testBMFontText = `info face="Pixel Sans" size=-16 bold=0 charset="" padding=0,0,0,0
+common lineHeight=18 base=14 scaleW=128 scaleH=64 pages=2 packed=0
+page id=0 file="pixel_0.png"
+page id=1 file="pixel 1.png"
+chars count=3
+char id=65   x=0     y=0     width=9     height=12    xoffset=0     yoffset=2     xadvance=10    page=0  chnl=15
+char id=86   x=10    y=0     width=9     height=12    xoffset=-1    yoffset=2     xadvance=9     page=1  chnl=15
+char id=32   x=0     y=0     width=0     height=0     xoffset=0     yoffset=0     xadvance=5     page=0  chnl=15
+kernings count=1
+kerning first=65  second=86  amount=-2
+`
+
+const testBMFontXML = `<?xml version="1.0"?>
+<font>
+  <info face="Pixel Sans" size="-16"/>
+  <common lineHeight="18" base="14" scaleW="128" scaleH="64" pages="2"/>
+  <pages>
+    <page id="0" file="pixel_0.png"/>
+    <page id="1" file="pixel 1.png"/>
+  </pages>
+  <chars count="3">
+    <char id="65" x="0" y="0" width="9" height="12" xoffset="0" yoffset="2" xadvance="10" page="0"/>
+    <char id="86" x="10" y="0" width="9" height="12" xoffset="-1" yoffset="2" xadvance="9" page="1"/>
+    <char id="32" x="0" y="0" width="0" height="0" xoffset="0" yoffset="0" xadvance="5" page="0"/>
+  </chars>
+  <kernings count="1">
+    <kerning first="65" second="86" amount="-2"/>
+  </kernings>
+</font>
+`
+
+var testBMFontDescription = bmfontDescription{
+	Info:   bmfontInfo{Face: "Pixel Sans", Size: -16},
+	Common: bmfontCommon{LineHeight: 18, Base: 14, ScaleW: 128, ScaleH: 64},
+	Pages: []bmfontPage{
+		{ID: 0, File: "pixel_0.png"},
+		{ID: 1, File: "pixel 1.png"},
+	},
+	Chars: []bmfontChar{
+		{ID: 65, Width: 9, Height: 12, YOffset: 2, XAdvance: 10},
+		{ID: 86, X: 10, Width: 9, Height: 12, XOffset: -1, YOffset: 2, XAdvance: 9, Page: 1},
+		{ID: 32, XAdvance: 5},
+	},
+	Kernings: []bmfontKerning{{First: 65, Second: 86, Amount: -2}},
+}
+
+func TestParseBMFontText(t *testing.T) {
+	desc, err := parseBMFontText([]byte(testBMFontText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(desc, testBMFontDescription) {
+		t.Errorf("Got %+v, want %+v", desc, testBMFontDescription)
+	}
+}
+
+func TestParseBMFontXMLMatchesText(t *testing.T) {
+	var desc bmfontDescription
+	if err := xml.Unmarshal([]byte(testBMFontXML), &desc); err != nil {
+		t.Fatal(err)
+	}
+	desc.XMLName = xml.Name{}
+	if !reflect.DeepEqual(desc, testBMFontDescription) {
+		t.Errorf("Got %+v, want %+v", desc, testBMFontDescription)
+	}
+}
+
+func TestParseBMFontTextErrors(t *testing.T) {
+	var tests = []struct {
+		data string
+		err  string
+	}{
+		{"info face=\"Pixel\ncommon lineHeight=18", "Line 1: Unterminated quote in \"Pixel"},
+		{"common lineHeight=18\nchar id=65 x", "Line 2: Expected key=value in x"},
+		{"char id=65 width=wide", "Line 1: Invalid width: wide"},
+	}
+	for _, test := range tests {
+		_, err := parseBMFontText([]byte(test.data))
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: got error %v, want %v", test.data, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loaders
+
+import (
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/pikkpoiss/gamejam/v1/base/core"
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+	"image"
+	"image/png"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+const defaultDirectoryPageSize = 2048
+
+type DirectoryConfig struct {
+	PageWidth     int // Maximum size of each page, 2048 if zero.
+	PageHeight    int
+	Padding       int // Empty pixels between images.
+	Extrude       int // Pixels of repeated edge around each image.
+	Strategy      sprites.PackingStrategy
+	AllowRotation bool
+	Trim          bool // Crop transparent borders from images.
+}
+
+// Pages start small and grow in powers of two up to the page size.
+func (c DirectoryConfig) packingConfig() sprites.PackingConfig {
+	var initial = func(max int) int {
+		if max < 256 {
+			return max
+		}
+		return 256
+	}
+	if c.PageWidth <= 0 {
+		c.PageWidth = defaultDirectoryPageSize
+	}
+	if c.PageHeight <= 0 {
+		c.PageHeight = defaultDirectoryPageSize
+	}
+	return sprites.PackingConfig{
+		Width:         initial(c.PageWidth),
+		Height:        initial(c.PageHeight),
+		MaxWidth:      c.PageWidth,
+		MaxHeight:     c.PageHeight,
+		Strategy:      c.Strategy,
+		AllowRotation: c.AllowRotation,
+		Padding:       c.Padding,
+		Extrude:       c.Extrude,
+		Trim:          c.Trim,
+	}
+}
+
+type DirectoryLoader struct {
+}
+
+func NewDirectoryLoader() *DirectoryLoader {
+	return &DirectoryLoader{}
+}
+
+// Reads every PNG under root. Keys are paths relative to root without the
+// extension, so root/enemies/bat.png becomes enemies/bat.
+func (l *DirectoryLoader) ReadImages(fsys fs.FS, root string) (images []sprites.PackImage, err error) {
+	err = fs.WalkDir(fsys, root, func(p string, entry fs.DirEntry, err error) error {
+		var (
+			file fs.File
+			img  image.Image
+			key  string
+		)
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.ToLower(path.Ext(p)) != ".png" {
+			return nil
+		}
+		if file, err = fsys.Open(p); err != nil {
+			return err
+		}
+		defer file.Close()
+		if img, err = png.Decode(file); err != nil {
+			return fmt.Errorf("%v: %v", p, err)
+		}
+		key = p
+		if root != "." {
+			key = strings.TrimPrefix(key, root+"/")
+		}
+		key = strings.TrimSuffix(key, path.Ext(key))
+		images = append(images, sprites.PackImage{Key: key, Image: img})
+		return nil
+	})
+	return
+}
+
+// Packs every PNG under root into as many pages as needed.
+func (l *DirectoryLoader) Pack(fsys fs.FS, root string, cfg DirectoryConfig) (pages []*sprites.PackedSheet, err error) {
+	var images []sprites.PackImage
+	if images, err = l.ReadImages(fsys, root); err != nil {
+		return
+	}
+	if pages, err = sprites.PackPages(images, cfg.packingConfig()); err != nil {
+		return
+	}
+	if glog.V(1) {
+		for _, page := range pages {
+			glog.Infof("Packed page: %v", page.Stats())
+		}
+	}
+	return
+}
+
+// Packs every PNG under root and uploads the pages as a single sheet.
+func (l *DirectoryLoader) Load(fsys fs.FS, root string, cfg DirectoryConfig, smoothing core.TextureSmoothing) (sheet *sprites.Sheet, err error) {
+	var (
+		pages   []*sprites.PackedSheet
+		page    *sprites.PackedSheet
+		sprite  *sprites.Sprite
+		data    sprites.SpriteData
+		texture *core.Texture
+		i       int
+		key     string
+	)
+	if pages, err = l.Pack(fsys, root, cfg); err != nil {
+		return
+	}
+	if len(pages) == 0 {
+		err = fmt.Errorf("No PNG images found in %v", root)
+		return
+	}
+	sheet = sprites.NewSheet()
+	for i, page = range pages {
+		if texture, err = core.GetTexture(page.Image(), smoothing); err != nil {
+			return
+		}
+		sheet.SetPageTexture(i, texture)
+		for _, key = range page.Keys() {
+			if sprite, err = page.Sprite(key); err != nil {
+				return
+			}
+			data = sprite.Data()
+			data.Page = i
+			sheet.AddSpriteData(key, data)
+		}
+		page.Delete()
+	}
+	return
+}
+
+func (l *DirectoryLoader) LoadDir(dir string, cfg DirectoryConfig, smoothing core.TextureSmoothing) (sheet *sprites.Sheet, err error) {
+	return l.Load(os.DirFS(dir), ".", cfg, smoothing)
+}
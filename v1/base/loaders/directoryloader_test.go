@@ -0,0 +1,44 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loaders
+
+import (
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+	"image"
+	"testing"
+)
+
+func TestDirectoryConfigDefaultPageSize(t *testing.T) {
+	var (
+		cfg    = DirectoryConfig{}.packingConfig()
+		images = []sprites.PackImage{
+			{Key: "a", Image: image.NewRGBA(image.Rect(0, 0, 300, 20))},
+			{Key: "b", Image: image.NewRGBA(image.Rect(0, 0, 16, 16))},
+		}
+	)
+	if cfg.MaxWidth != 2048 || cfg.MaxHeight != 2048 {
+		t.Errorf("Got maximum page size %vx%v", cfg.MaxWidth, cfg.MaxHeight)
+	}
+	if cfg.Width != 256 || cfg.Height != 256 {
+		t.Errorf("Got initial page size %vx%v", cfg.Width, cfg.Height)
+	}
+	if _, err := sprites.PackPages(images, cfg); err != nil {
+		t.Error(err)
+	}
+	cfg = DirectoryConfig{PageWidth: 64, PageHeight: 128}.packingConfig()
+	if cfg.Width != 64 || cfg.Height != 128 || cfg.MaxWidth != 64 || cfg.MaxHeight != 128 {
+		t.Errorf("Got %+v for a small page", cfg)
+	}
+}
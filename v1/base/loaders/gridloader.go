@@ -0,0 +1,118 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loaders
+
+import (
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pikkpoiss/gamejam/v1/base/core"
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+	"image"
+)
+
+type GridConfig struct {
+	Name            string // Prefix for generated sprite keys.
+	CellWidth       int
+	CellHeight      int
+	Margin          int  // Pixels around the outside of the grid.
+	Spacing         int  // Pixels between neighboring cells.
+	RowColumnKeys   bool // Generate name_r_c keys instead of name_i.
+	SkipTransparent bool // Don't add cells with no visible pixels.
+}
+
+// Returns the key for the cell in row r and column c. Cells are numbered
+// left to right, top to bottom, and skipped cells keep their number.
+func (c GridConfig) Key(r, col, cols int) string {
+	if c.RowColumnKeys {
+		return fmt.Sprintf("%v_%v_%v", c.Name, r, col)
+	}
+	return fmt.Sprintf("%v_%v", c.Name, r*cols+col)
+}
+
+type GridLoader struct {
+}
+
+func NewGridLoader() *GridLoader {
+	return &GridLoader{}
+}
+
+func (l *GridLoader) Load(pngPath string, cfg GridConfig, smoothing core.TextureSmoothing) (sheet *sprites.Sheet, err error) {
+	var img image.Image
+	if img, err = core.LoadPNG(pngPath); err != nil {
+		return
+	}
+	return l.LoadImage(img, cfg, smoothing)
+}
+
+func (l *GridLoader) LoadImage(img image.Image, cfg GridConfig, smoothing core.TextureSmoothing) (sheet *sprites.Sheet, err error) {
+	var (
+		bounds  = img.Bounds()
+		texture *core.Texture
+		rows    int
+		cols    int
+		r       int
+		c       int
+		cell    image.Rectangle
+	)
+	if cfg.CellWidth <= 0 || cfg.CellHeight <= 0 {
+		err = fmt.Errorf("Invalid cell size %vx%v", cfg.CellWidth, cfg.CellHeight)
+		return
+	}
+	cols = (bounds.Dx() - 2*cfg.Margin + cfg.Spacing) / (cfg.CellWidth + cfg.Spacing)
+	rows = (bounds.Dy() - 2*cfg.Margin + cfg.Spacing) / (cfg.CellHeight + cfg.Spacing)
+	if cols <= 0 || rows <= 0 {
+		err = fmt.Errorf("Image is too small for a %vx%v cell", cfg.CellWidth, cfg.CellHeight)
+		return
+	}
+	sheet = sprites.NewSheet()
+	for r = 0; r < rows; r++ {
+		for c = 0; c < cols; c++ {
+			cell = image.Rect(
+				cfg.Margin+c*(cfg.CellWidth+cfg.Spacing),
+				cfg.Margin+r*(cfg.CellHeight+cfg.Spacing),
+				cfg.Margin+c*(cfg.CellWidth+cfg.Spacing)+cfg.CellWidth,
+				cfg.Margin+r*(cfg.CellHeight+cfg.Spacing)+cfg.CellHeight,
+			).Add(bounds.Min)
+			if cfg.SkipTransparent && isTransparent(img, cell) {
+				continue
+			}
+			sheet.AddSprite(
+				cfg.Key(r, c, cols),
+				mgl32.Vec2{float32(cfg.CellWidth), float32(cfg.CellHeight)},
+				mgl32.Vec2{
+					float32(cell.Min.X - bounds.Min.X),
+					float32(cell.Min.Y - bounds.Min.Y),
+				},
+			)
+		}
+	}
+	if texture, err = core.GetTexture(img, smoothing); err != nil {
+		return
+	}
+	sheet.SetTexture(texture)
+	return
+}
+
+func isTransparent(img image.Image, rect image.Rectangle) bool {
+	var x, y int
+	for y = rect.Min.Y; y < rect.Max.Y; y++ {
+		for x = rect.Min.X; x < rect.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,236 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loaders
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pikkpoiss/gamejam/v1/base/core"
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type libGDXRegion struct {
+	name    string
+	index   int
+	rotated bool
+	x, y    int
+	w, h    int
+	origW   int
+	origH   int
+	offX    int
+	offY    int
+	hasOrig bool
+}
+
+func newLibGDXRegion(name string) *libGDXRegion {
+	return &libGDXRegion{
+		name:  name,
+		index: -1,
+	}
+}
+
+// Regions with an index are numbered animation frames which share a name, so
+// they are keyed as name_index.
+func (r *libGDXRegion) key() string {
+	if r.index >= 0 {
+		return fmt.Sprintf("%v_%v", r.name, r.index)
+	}
+	return r.name
+}
+
+// libGDX offsets are measured from the bottom left of the original image and
+// rotated regions are turned counter-clockwise.
+func (r *libGDXRegion) spriteData(page int) (data sprites.SpriteData) {
+	data = sprites.NewSpriteData(
+		mgl32.Vec2{float32(r.w), float32(r.h)},
+		mgl32.Vec2{float32(r.x), float32(r.y)},
+	)
+	data.Rotated = r.rotated
+	data.RotatedCCW = r.rotated
+	data.Page = page
+	if r.hasOrig {
+		data.SourceSize = mgl32.Vec2{float32(r.origW), float32(r.origH)}
+		data.TrimOffset = mgl32.Vec2{
+			float32(r.offX),
+			float32(r.origH - r.offY - r.h),
+		}
+	}
+	return
+}
+
+func (r *libGDXRegion) set(field string, values []int, raw string) (err error) {
+	var count = map[string]int{
+		"xy":      2,
+		"size":    2,
+		"orig":    2,
+		"offset":  2,
+		"bounds":  4,
+		"offsets": 4,
+		"index":   1,
+	}[field]
+	if field == "rotate" {
+		switch raw {
+		case "true", "90":
+			r.rotated = true
+		case "false", "0":
+			r.rotated = false
+		default:
+			err = fmt.Errorf("Unsupported rotation %v for region %v", raw, r.name)
+		}
+		return
+	}
+	if count == 0 {
+		return // Ignore fields such as split and pad.
+	}
+	if len(values) != count {
+		err = fmt.Errorf("Expected %v values for %v in region %v", count, field, r.name)
+		return
+	}
+	switch field {
+	case "xy":
+		r.x, r.y = values[0], values[1]
+	case "size":
+		r.w, r.h = values[0], values[1]
+	case "bounds":
+		r.x, r.y, r.w, r.h = values[0], values[1], values[2], values[3]
+	case "orig":
+		r.origW, r.origH = values[0], values[1]
+		r.hasOrig = true
+	case "offset":
+		r.offX, r.offY = values[0], values[1]
+	case "offsets":
+		r.offX, r.offY = values[0], values[1]
+		r.origW, r.origH = values[2], values[3]
+		r.hasOrig = true
+	case "index":
+		r.index = values[0]
+	}
+	return
+}
+
+type libGDXPage struct {
+	image   string
+	regions []*libGDXRegion
+}
+
+func parseLibGDXValues(raw string) (values []int) {
+	var (
+		value int
+		err   error
+	)
+	for _, part := range strings.Split(raw, ",") {
+		if value, err = strconv.Atoi(strings.TrimSpace(part)); err != nil {
+			return nil
+		}
+		values = append(values, value)
+	}
+	return
+}
+
+// Parses both the legacy format, with indented region fields such as xy and
+// size, and the compact format introduced in libGDX 1.9.13, with bounds and
+// offsets.
+func parseLibGDXAtlas(data []byte) (pages []*libGDXPage, err error) {
+	var (
+		scanner = bufio.NewScanner(bytes.NewReader(data))
+		page    *libGDXPage
+		region  *libGDXRegion
+		line    string
+		field   string
+		raw     string
+		lineNum int
+		colon   int
+	)
+	for scanner.Scan() {
+		lineNum++
+		line = strings.TrimSpace(scanner.Text())
+		if line == "" {
+			page = nil
+			region = nil
+			continue
+		}
+		if colon = strings.Index(line, ":"); colon == -1 {
+			if page == nil {
+				page = &libGDXPage{image: line}
+				pages = append(pages, page)
+			} else {
+				region = newLibGDXRegion(line)
+				page.regions = append(page.regions, region)
+			}
+			continue
+		}
+		if page == nil {
+			continue // Header fields before the first page.
+		}
+		if region == nil {
+			continue // Page fields such as size, format and filter.
+		}
+		field = strings.TrimSpace(line[:colon])
+		raw = strings.TrimSpace(line[colon+1:])
+		if err = region.set(field, parseLibGDXValues(raw), raw); err != nil {
+			err = fmt.Errorf("Line %v: %v", lineNum, err)
+			return
+		}
+	}
+	err = scanner.Err()
+	return
+}
+
+type LibGDXLoader struct {
+}
+
+func NewLibGDXLoader() *LibGDXLoader {
+	return &LibGDXLoader{}
+}
+
+func (l *LibGDXLoader) Load(atlasPath string, smoothing core.TextureSmoothing) (sheet *sprites.Sheet, err error) {
+	var (
+		dir     string
+		data    []byte
+		pages   []*libGDXPage
+		page    *libGDXPage
+		region  *libGDXRegion
+		texture *core.Texture
+		i       int
+	)
+	dir = path.Dir(atlasPath)
+	if data, err = ioutil.ReadFile(atlasPath); err != nil {
+		return
+	}
+	if pages, err = parseLibGDXAtlas(data); err != nil {
+		return
+	}
+	if len(pages) == 0 {
+		err = fmt.Errorf("No pages in atlas %v", atlasPath)
+		return
+	}
+	sheet = sprites.NewSheet()
+	for i, page = range pages {
+		if texture, err = core.LoadTexture(path.Join(dir, page.image), smoothing); err != nil {
+			return
+		}
+		sheet.SetPageTexture(i, texture)
+		for _, region = range page.regions {
+			sheet.AddSpriteData(region.key(), region.spriteData(i))
+		}
+	}
+	return
+}
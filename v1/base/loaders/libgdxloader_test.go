@@ -0,0 +1,103 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loaders
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"testing"
+)
+
+const testLibGDXLegacy = `
+sheet.png
+size: 64,64
+format: RGBA8888
+filter: Nearest,Nearest
+repeat: none
+walk
+  rotate: true
+  xy: 2, 4
+  size: 10, 6
+  orig: 16, 16
+  offset: 3, 4
+  index: 1
+coin
+  rotate: false
+  xy: 20, 4
+  size: 8, 8
+  orig: 8, 8
+  offset: 0, 0
+  index: -1
+`
+
+const testLibGDXCompact = `sheet.png
+size:64,64
+filter:Linear,Linear
+walk
+bounds:2,4,10,6
+offsets:3,4,16,16
+rotate:90
+index:1
+coin
+bounds:20,4,8,8
+`
+
+func TestParseLibGDXAtlas(t *testing.T) {
+	for _, data := range []string{testLibGDXLegacy, testLibGDXCompact} {
+		pages, err := parseLibGDXAtlas([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pages) != 1 || pages[0].image != "sheet.png" || len(pages[0].regions) != 2 {
+			t.Fatalf("Got pages %+v", pages)
+		}
+		var (
+			walk = pages[0].regions[0]
+			coin = pages[0].regions[1]
+			data = walk.spriteData(0)
+		)
+		if walk.key() != "walk_1" || coin.key() != "coin" {
+			t.Errorf("Got keys %v and %v", walk.key(), coin.key())
+		}
+		if !data.Rotated || !data.RotatedCCW {
+			t.Errorf("walk should be rotated counter-clockwise")
+		}
+		if walk.x != 2 || walk.y != 4 || walk.w != 10 || walk.h != 6 {
+			t.Errorf("Got walk bounds %v,%v %vx%v", walk.x, walk.y, walk.w, walk.h)
+		}
+		if data.SourceSize != (mgl32.Vec2{16, 16}) {
+			t.Errorf("Got source size %v", data.SourceSize)
+		}
+		// The offset is from the bottom left, so the top is 16-4-6 = 6.
+		if data.TrimOffset != (mgl32.Vec2{3, 6}) {
+			t.Errorf("Got trim offset %v", data.TrimOffset)
+		}
+		if coin.rotated || coin.x != 20 || coin.w != 8 {
+			t.Errorf("Got coin %+v", coin)
+		}
+	}
+}
+
+func TestParseLibGDXAtlasErrors(t *testing.T) {
+	var tests = []string{
+		"sheet.png\nwalk\n  rotate: 45\n",
+		"sheet.png\nwalk\n  xy: 1\n",
+		"sheet.png\nwalk\n  bounds: 1, 2, 3\n",
+	}
+	for _, data := range tests {
+		if _, err := parseLibGDXAtlas([]byte(data)); err == nil {
+			t.Errorf("Expected an error for %q", data)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loaders
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pikkpoiss/gamejam/v1/base/core"
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+	"io/ioutil"
+	"path"
+)
+
+type sparrowSubTexture struct {
+	Name        string   `xml:"name,attr"`
+	X           float32  `xml:"x,attr"`
+	Y           float32  `xml:"y,attr"`
+	Width       float32  `xml:"width,attr"`
+	Height      float32  `xml:"height,attr"`
+	FrameX      float32  `xml:"frameX,attr"`
+	FrameY      float32  `xml:"frameY,attr"`
+	FrameWidth  *float32 `xml:"frameWidth,attr"`
+	FrameHeight *float32 `xml:"frameHeight,attr"`
+	PivotX      *float32 `xml:"pivotX,attr"`
+	PivotY      *float32 `xml:"pivotY,attr"`
+	Rotated     bool     `xml:"rotated,attr"`
+}
+
+// Sparrow sizes describe the region in the atlas, so rotated sprites have
+// their width and height swapped. Frame offsets are negative and pivots are
+// in pixels.
+func (t sparrowSubTexture) spriteData() (data sprites.SpriteData) {
+	var bounds = mgl32.Vec2{t.Width, t.Height}
+	if t.Rotated {
+		bounds = mgl32.Vec2{t.Height, t.Width}
+	}
+	data = sprites.NewSpriteData(bounds, mgl32.Vec2{t.X, t.Y})
+	data.Rotated = t.Rotated
+	if t.FrameWidth != nil && t.FrameHeight != nil {
+		data.TrimOffset = mgl32.Vec2{-t.FrameX, -t.FrameY}
+		data.SourceSize = mgl32.Vec2{*t.FrameWidth, *t.FrameHeight}
+	}
+	if t.PivotX != nil && data.SourceSize.X() > 0 {
+		data.Pivot[0] = *t.PivotX / data.SourceSize.X()
+	}
+	if t.PivotY != nil && data.SourceSize.Y() > 0 {
+		data.Pivot[1] = *t.PivotY / data.SourceSize.Y()
+	}
+	return
+}
+
+type sparrowTextureAtlas struct {
+	XMLName     xml.Name            `xml:"TextureAtlas"`
+	ImagePath   string              `xml:"imagePath,attr"`
+	SubTextures []sparrowSubTexture `xml:"SubTexture"`
+}
+
+type SparrowLoader struct {
+}
+
+func NewSparrowLoader() *SparrowLoader {
+	return &SparrowLoader{}
+}
+
+func (l *SparrowLoader) Load(xmlPath string, smoothing core.TextureSmoothing) (sheet *sprites.Sheet, err error) {
+	var (
+		dir         string
+		data        []byte
+		texturePath string
+		parsed      sparrowTextureAtlas
+		texture     *core.Texture
+	)
+	dir = path.Dir(xmlPath)
+	if data, err = ioutil.ReadFile(xmlPath); err != nil {
+		return
+	}
+	if err = xml.Unmarshal(data, &parsed); err != nil {
+		return
+	}
+	if parsed.ImagePath == "" {
+		err = fmt.Errorf("No imagePath in atlas %v", xmlPath)
+		return
+	}
+	texturePath = path.Join(dir, parsed.ImagePath)
+	if texture, err = core.LoadTexture(texturePath, smoothing); err != nil {
+		return
+	}
+	sheet = sprites.NewSheet()
+	sheet.SetTexture(texture)
+	for _, subTexture := range parsed.SubTextures {
+		sheet.AddSpriteData(subTexture.Name, subTexture.spriteData())
+	}
+	return
+}
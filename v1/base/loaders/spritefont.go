@@ -0,0 +1,134 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loaders
+
+import (
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+	"image"
+	"unicode"
+)
+
+type SpriteFontConfig struct {
+	LetterSpacing float32 // Extra pixels after each glyph.
+	LineHeight    float32 // Pixels between baselines. Zero uses the tallest sprite.
+	SpaceWidth    float32 // Advance of unmapped whitespace. Zero uses the widest sprite.
+	Monospace     bool    // Every glyph advances by the widest sprite.
+}
+
+// Draws text with the sprites of a TextMapping, each sitting on the baseline
+// and advancing by its own width. Implements text.GlyphSource.
+type SpriteFont struct {
+	mapping *TextMapping
+	cfg     SpriteFontConfig
+	maxW    float32
+	maxH    float32
+}
+
+// Glyph sizes are measured from the runes mapped at the time of the call.
+func NewSpriteFont(mapping *TextMapping, cfg SpriteFontConfig) *SpriteFont {
+	var (
+		font = &SpriteFont{
+			mapping: mapping,
+			cfg:     cfg,
+		}
+		size = mapping.defaultSprite.Data().Bounds
+	)
+	font.maxW, font.maxH = size.X(), size.Y()
+	for _, sprite := range mapping.mapping {
+		size = sprite.Data().Bounds
+		if size.X() > font.maxW {
+			font.maxW = size.X()
+		}
+		if size.Y() > font.maxH {
+			font.maxH = size.Y()
+		}
+	}
+	return font
+}
+
+func (f *SpriteFont) Config() SpriteFontConfig {
+	return f.cfg
+}
+
+func (f *SpriteFont) SetConfig(cfg SpriteFontConfig) {
+	f.cfg = cfg
+}
+
+func (f *SpriteFont) LineHeight() float32 {
+	if f.cfg.LineHeight > 0 {
+		return f.cfg.LineHeight
+	}
+	return f.maxH
+}
+
+func (f *SpriteFont) Ascent() float32 {
+	return f.maxH
+}
+
+// Sprites sit on the baseline, so nothing descends below it.
+func (f *SpriteFont) Descent() float32 {
+	return 0
+}
+
+// Unmapped whitespace is drawn as a gap instead of the default sprite.
+func (f *SpriteFont) sprite(r rune) (sprite *sprites.Sprite, ok bool) {
+	if sprite, ok = f.mapping.Lookup(r); ok {
+		return
+	}
+	if unicode.IsSpace(r) {
+		return
+	}
+	return f.mapping.defaultSprite, true
+}
+
+func (f *SpriteFont) Advance(r rune) float32 {
+	var sprite, ok = f.sprite(r)
+	switch {
+	case f.cfg.Monospace:
+		return f.maxW + f.cfg.LetterSpacing
+	case ok:
+		return sprite.Data().Bounds.X() + f.cfg.LetterSpacing
+	case f.cfg.SpaceWidth > 0:
+		return f.cfg.SpaceWidth + f.cfg.LetterSpacing
+	}
+	return f.maxW + f.cfg.LetterSpacing
+}
+
+func (f *SpriteFont) Kern(a, b rune) float32 {
+	return 0
+}
+
+func (f *SpriteFont) Glyph(r rune) (sprite *sprites.Sprite, offset image.Point, ok bool, err error) {
+	var height float32
+	if sprite, ok = f.sprite(r); !ok {
+		return
+	}
+	height = sprite.Data().Bounds.Y()
+	offset = image.Pt(0, -int(height))
+	if f.cfg.Monospace {
+		// Center narrow glyphs in their cell.
+		offset.X = int((f.maxW - sprite.Data().Bounds.X()) / 2)
+	}
+	return
+}
+
+func (f *SpriteFont) Sheet() *sprites.Sheet {
+	return f.mapping.sheet
+}
+
+// The sheet is loaded elsewhere.
+func (f *SpriteFont) Upload() error {
+	return nil
+}
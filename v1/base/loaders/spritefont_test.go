@@ -0,0 +1,98 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loaders
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+	"github.com/pikkpoiss/gamejam/v1/base/text"
+	"image"
+	"testing"
+)
+
+// Maps A and i to sprites 8 and 3 pixels wide, with a 6 pixel default.
+func testSpriteFontMapping(t *testing.T) (mapping *TextMapping) {
+	var (
+		sheet = sprites.NewSheet()
+		err   error
+	)
+	sheet.AddSpriteData("A", sprites.NewSpriteData(mgl32.Vec2{8, 10}, mgl32.Vec2{0, 0}))
+	sheet.AddSpriteData("i", sprites.NewSpriteData(mgl32.Vec2{3, 10}, mgl32.Vec2{8, 0}))
+	sheet.AddSpriteData("unknown", sprites.NewSpriteData(mgl32.Vec2{6, 8}, mgl32.Vec2{11, 0}))
+	if mapping, err = NewTextMapping(sheet, "unknown"); err != nil {
+		t.Fatal(err)
+	}
+	if err = mapping.Set('A', "A"); err != nil {
+		t.Fatal(err)
+	}
+	if err = mapping.Set('i', "i"); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestSpriteFontAdvance(t *testing.T) {
+	var (
+		mapping = testSpriteFontMapping(t)
+		tests   = []struct {
+			name  string
+			cfg   SpriteFontConfig
+			width float32
+		}{
+			// Unmapped spaces use the widest sprite and other runes the default.
+			{"zero config", SpriteFontConfig{}, 8 + 3 + 8 + 6},
+			{"spacing", SpriteFontConfig{LetterSpacing: 1, SpaceWidth: 2}, 9 + 4 + 3 + 7},
+			{"monospace", SpriteFontConfig{Monospace: true}, 4 * 8},
+			{"monospace spacing", SpriteFontConfig{Monospace: true, LetterSpacing: 1}, 4 * 9},
+		}
+	)
+	for _, test := range tests {
+		var font = NewSpriteFont(mapping, test.cfg)
+		if width := text.Measure(font, "Ai x"); width != test.width {
+			t.Errorf("%v: got width %v, want %v", test.name, width, test.width)
+		}
+		if font.LineHeight() != 10 || font.Ascent() != 10 || font.Descent() != 0 {
+			t.Errorf("%v: got line height %v", test.name, font.LineHeight())
+		}
+	}
+}
+
+func TestSpriteFontGlyphOffsets(t *testing.T) {
+	var (
+		mapping = testSpriteFontMapping(t)
+		tests   = []struct {
+			r         rune
+			monospace bool
+			offset    image.Point
+			ok        bool
+		}{
+			{'A', false, image.Pt(0, -10), true},
+			{'i', false, image.Pt(0, -10), true},
+			{'i', true, image.Pt(2, -10), true},
+			{'x', false, image.Pt(0, -8), true},
+			{' ', false, image.Point{}, false},
+		}
+	)
+	for _, test := range tests {
+		var font = NewSpriteFont(mapping, SpriteFontConfig{Monospace: test.monospace})
+		_, offset, ok, err := font.Glyph(test.r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != test.ok || offset != test.offset {
+			t.Errorf("%q monospace %v: got %v %v, want %v %v", test.r, test.monospace, offset, ok, test.offset, test.ok)
+		}
+	}
+}
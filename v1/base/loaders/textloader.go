@@ -23,23 +23,19 @@ import (
 )
 
 type TextMapping struct {
-	defaultSprite int
-	mapping       map[rune]int
+	defaultSprite *sprites.Sprite
+	mapping       map[rune]*sprites.Sprite
 	sheet         *sprites.Sheet
 }
 
 func NewTextMapping(sheet *sprites.Sheet, defaultSprite string) (out *TextMapping, err error) {
-	var (
-		sprite *sprites.Sprite
-	)
 	out = &TextMapping{
 		sheet:   sheet,
-		mapping: map[rune]int{},
+		mapping: map[rune]*sprites.Sprite{},
 	}
-	if sprite, err = sheet.Sprite(defaultSprite); err != nil {
+	if out.defaultSprite, err = sheet.Sprite(defaultSprite); err != nil {
 		return
 	}
-	out.defaultSprite = sprite.Index()
 	return
 
 }
@@ -51,18 +47,32 @@ func (m *TextMapping) Set(r rune, key string) (err error) {
 	if sprite, err = m.sheet.Sprite(key); err != nil {
 		return
 	}
-	m.mapping[r] = sprite.Index()
+	m.mapping[r] = sprite
 	return
 }
 
 func (m *TextMapping) Get(r rune) (index int) {
-	var exists bool
-	if index, exists = m.mapping[r]; !exists {
-		index = m.defaultSprite
+	return m.Sprite(r).Index()
+}
+
+// Returns the sprite for r, or the default sprite if r isn't mapped.
+func (m *TextMapping) Sprite(r rune) *sprites.Sprite {
+	if sprite, exists := m.mapping[r]; exists {
+		return sprite
 	}
+	return m.defaultSprite
+}
+
+// Returns the sprite for r, with ok false if r isn't mapped.
+func (m *TextMapping) Lookup(r rune) (sprite *sprites.Sprite, ok bool) {
+	sprite, ok = m.mapping[r]
 	return
 }
 
+func (m *TextMapping) Sheet() *sprites.Sheet {
+	return m.sheet
+}
+
 type TextLoader struct {
 }
 
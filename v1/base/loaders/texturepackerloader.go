@@ -15,7 +15,9 @@
 package loaders
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/pikkpoiss/gamejam/v1/base/core"
 	"github.com/pikkpoiss/gamejam/v1/base/sprites"
@@ -24,37 +26,140 @@ import (
 )
 
 type texturePackerFloatCoords struct {
-	X float32 `json:x,omitempty`
-	Y float32 `json:y,omitempty`
+	X float32 `json:"x"`
+	Y float32 `json:"y"`
 }
 
 type texturePackerIntCoords struct {
-	X int `json:x,omitempty`
-	Y int `json:y,omitempty`
-	W int `json:w,omitempty`
-	H int `json:h,omitempty`
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type texturePackerSize struct {
+	W int `json:"w"`
+	H int `json:"h"`
 }
 
 type texturePackerFrame struct {
-	Filename         string                   `json:filename`
-	Frame            texturePackerIntCoords   `json:frame`
-	Rotated          bool                     `json:rotated`
-	Trimmed          bool                     `json:trimmed`
-	SpriteSourceSize texturePackerIntCoords   `json:spriteSourceSize`
-	SourceSize       texturePackerIntCoords   `json:sourceSize`
-	Pivot            texturePackerFloatCoords `json:pivot`
+	Filename         string                    `json:"filename"`
+	Frame            texturePackerIntCoords    `json:"frame"`
+	Rotated          bool                      `json:"rotated"`
+	Trimmed          bool                      `json:"trimmed"`
+	SpriteSourceSize texturePackerIntCoords    `json:"spriteSourceSize"`
+	SourceSize       texturePackerSize         `json:"sourceSize"`
+	Pivot            *texturePackerFloatCoords `json:"pivot"`
+}
+
+// Converts a parsed frame into sprite data. The frame size is the size of
+// the trimmed sprite before rotation; a rotated frame occupies a region of
+// the texture with width and height swapped.
+func (f texturePackerFrame) spriteData() (data sprites.SpriteData) {
+	data = sprites.NewSpriteData(
+		mgl32.Vec2{
+			float32(f.Frame.W),
+			float32(f.Frame.H),
+		},
+		mgl32.Vec2{
+			float32(f.Frame.X),
+			float32(f.Frame.Y),
+		},
+	)
+	data.Rotated = f.Rotated
+	if f.Trimmed {
+		data.TrimOffset = mgl32.Vec2{
+			float32(f.SpriteSourceSize.X),
+			float32(f.SpriteSourceSize.Y),
+		}
+		data.SourceSize = mgl32.Vec2{
+			float32(f.SourceSize.W),
+			float32(f.SourceSize.H),
+		}
+	}
+	if f.Pivot != nil {
+		data.Pivot = mgl32.Vec2{f.Pivot.X, f.Pivot.Y}
+	}
+	return
 }
 
 type texturePackerMeta struct {
-	Image  string                 `json:image`
-	Format string                 `json:format`
-	Size   texturePackerIntCoords `json:size`
-	Scale  string                 `json:scale`
+	App               string            `json:"app,omitempty"`
+	Version           string            `json:"version,omitempty"`
+	Image             string            `json:"image"`
+	Format            string            `json:"format"`
+	Size              texturePackerSize `json:"size"`
+	Scale             string            `json:"scale"`
+	RelatedMultiPacks []string          `json:"related_multi_packs,omitempty"`
+}
+
+// A single page of a multi-texture atlas.
+type texturePackerTexture struct {
+	Image  string            `json:"image"`
+	Format string            `json:"format"`
+	Size   texturePackerSize `json:"size"`
+	Frames json.RawMessage   `json:"frames"`
+}
+
+type texturePackerJSON struct {
+	Frames   json.RawMessage        `json:"frames"`
+	Meta     texturePackerMeta      `json:"meta"`
+	Textures []texturePackerTexture `json:"textures"`
 }
 
-type texturePackerJSONArray struct {
-	Frames []texturePackerFrame `json:frames`
-	Meta   texturePackerMeta    `json:meta`
+// Calls decode for each frame in either the JSON-array format, where key is
+// empty, or the JSON-hash format, where key is the frame filename. Frames in
+// a hash are visited in document order.
+func decodeFrames(data json.RawMessage, decode func(key string, raw json.RawMessage) error) (err error) {
+	var (
+		decoder *json.Decoder
+		token   json.Token
+		key     string
+		ok      bool
+		raw     json.RawMessage
+		list    []json.RawMessage
+	)
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		err = fmt.Errorf("No frames in input data")
+		return
+	}
+	if data[0] == '[' {
+		if err = json.Unmarshal(data, &list); err != nil {
+			return
+		}
+		for _, raw = range list {
+			if err = decode("", raw); err != nil {
+				return
+			}
+		}
+		return
+	}
+	decoder = json.NewDecoder(bytes.NewReader(data))
+	if token, err = decoder.Token(); err != nil {
+		return
+	}
+	if token != json.Delim('{') {
+		err = fmt.Errorf("Frames must be an array or an object")
+		return
+	}
+	for decoder.More() {
+		if token, err = decoder.Token(); err != nil {
+			return
+		}
+		if key, ok = token.(string); !ok {
+			err = fmt.Errorf("Invalid frame key %v", token)
+			return
+		}
+		raw = nil
+		if err = decoder.Decode(&raw); err != nil {
+			return
+		}
+		if err = decode(key, raw); err != nil {
+			return
+		}
+	}
+	return
 }
 
 type TexturePackerLoader struct {
@@ -64,14 +169,41 @@ func NewTexturePackerLoader() *TexturePackerLoader {
 	return &TexturePackerLoader{}
 }
 
+// Loads a JSON-array or JSON-hash export. Multipack exports are loaded as a
+// single sheet with one page per texture, either from a multi-texture file
+// or by following the related_multi_packs entries in the metadata.
 func (l *TexturePackerLoader) Load(jsonPath string, smoothing core.TextureSmoothing) (sheet *sprites.Sheet, err error) {
+	return l.LoadMultipack([]string{jsonPath}, smoothing)
+}
+
+// Loads several exports into a single sheet, one page per texture.
+func (l *TexturePackerLoader) LoadMultipack(jsonPaths []string, smoothing core.TextureSmoothing) (sheet *sprites.Sheet, err error) {
+	var (
+		loaded   = map[string]bool{}
+		jsonPath string
+	)
+	sheet = sprites.NewSheet()
+	for _, jsonPath = range jsonPaths {
+		if err = l.loadFile(sheet, jsonPath, smoothing, loaded); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (l *TexturePackerLoader) loadFile(sheet *sprites.Sheet, jsonPath string, smoothing core.TextureSmoothing, loaded map[string]bool) (err error) {
 	var (
-		dir         string
-		data        []byte
-		texturePath string
-		parsed      texturePackerJSONArray
-		texture     *core.Texture
+		dir     string
+		data    []byte
+		parsed  texturePackerJSON
+		texture texturePackerTexture
+		related string
 	)
+	jsonPath = path.Clean(jsonPath)
+	if loaded[jsonPath] {
+		return
+	}
+	loaded[jsonPath] = true
 	dir = path.Dir(jsonPath)
 	if data, err = ioutil.ReadFile(jsonPath); err != nil {
 		return
@@ -79,24 +211,49 @@ func (l *TexturePackerLoader) Load(jsonPath string, smoothing core.TextureSmooth
 	if err = json.Unmarshal([]byte(data), &parsed); err != nil {
 		return
 	}
-	sheet = sprites.NewSheet()
-	for _, frame := range parsed.Frames {
-		sheet.AddSprite(
-			frame.Filename,
-			mgl32.Vec2{
-				float32(frame.Frame.W),
-				float32(frame.Frame.H),
-			},
-			mgl32.Vec2{
-				float32(frame.Frame.X),
-				float32(frame.Frame.Y),
-			},
-		)
+	if len(parsed.Textures) > 0 {
+		for _, texture = range parsed.Textures {
+			if err = l.addPage(sheet, path.Join(dir, texture.Image), texture.Frames, smoothing); err != nil {
+				return
+			}
+		}
+		return
+	}
+	if err = l.addPage(sheet, path.Join(dir, parsed.Meta.Image), parsed.Frames, smoothing); err != nil {
+		return
+	}
+	for _, related = range parsed.Meta.RelatedMultiPacks {
+		if err = l.loadFile(sheet, path.Join(dir, related), smoothing, loaded); err != nil {
+			return
+		}
 	}
-	texturePath = path.Join(dir, parsed.Meta.Image)
+	return
+}
+
+func (l *TexturePackerLoader) addPage(sheet *sprites.Sheet, texturePath string, frames json.RawMessage, smoothing core.TextureSmoothing) (err error) {
+	var (
+		page    = sheet.PageCount()
+		texture *core.Texture
+	)
 	if texture, err = core.LoadTexture(texturePath, smoothing); err != nil {
 		return
 	}
-	sheet.SetTexture(texture)
+	sheet.SetPageTexture(page, texture)
+	err = decodeFrames(frames, func(key string, raw json.RawMessage) (err error) {
+		var (
+			frame texturePackerFrame
+			data  sprites.SpriteData
+		)
+		if err = json.Unmarshal(raw, &frame); err != nil {
+			return
+		}
+		if key != "" {
+			frame.Filename = key
+		}
+		data = frame.spriteData()
+		data.Page = page
+		sheet.AddSpriteData(frame.Filename, data)
+		return
+	})
 	return
 }
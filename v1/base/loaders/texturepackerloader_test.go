@@ -0,0 +1,121 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loaders
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// Collects frame names and x positions in the order decodeFrames visits them.
+func decodeFrameNames(data string) (names []string, xs []int, err error) {
+	err = decodeFrames(json.RawMessage(data), func(key string, raw json.RawMessage) (err error) {
+		var frame texturePackerFrame
+		if err = json.Unmarshal(raw, &frame); err != nil {
+			return
+		}
+		if key != "" {
+			frame.Filename = key
+		}
+		names = append(names, frame.Filename)
+		xs = append(xs, frame.Frame.X)
+		return
+	})
+	return
+}
+
+func TestDecodeFrames(t *testing.T) {
+	var tests = []struct {
+		name  string
+		data  string
+		names []string
+		xs    []int
+		err   bool
+	}{
+		{
+			name: "array",
+			data: `[
+				{"filename": "b.png", "frame": {"x": 10, "y": 0, "w": 4, "h": 4}},
+				{"filename": "a.png", "frame": {"x": 20, "y": 0, "w": 4, "h": 4}}
+			]`,
+			names: []string{"b.png", "a.png"},
+			xs:    []int{10, 20},
+		},
+		{
+			name: "hash keeps document order",
+			data: `{
+				"z.png": {"frame": {"x": 1, "y": 0, "w": 4, "h": 4}},
+				"a.png": {"frame": {"x": 2, "y": 0, "w": 4, "h": 4}},
+				"m.png": {"frame": {"x": 3, "y": 0, "w": 4, "h": 4}}
+			}`,
+			names: []string{"z.png", "a.png", "m.png"},
+			xs:    []int{1, 2, 3},
+		},
+		{name: "empty", data: "  ", err: true},
+		{name: "scalar", data: `"frames"`, err: true},
+		{name: "bad frame", data: `{"a.png": 3}`, err: true},
+	}
+	for _, test := range tests {
+		names, xs, err := decodeFrameNames(test.data)
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(names, test.names) || !reflect.DeepEqual(xs, test.xs) {
+			t.Errorf("%v: got %v at %v, want %v at %v", test.name, names, xs, test.names, test.xs)
+		}
+	}
+}
+
+func TestDecodeMultipackTextures(t *testing.T) {
+	var (
+		data = `{
+			"textures": [
+				{"image": "sheet-0.png", "frames": [
+					{"filename": "a.png", "frame": {"x": 0, "y": 0, "w": 4, "h": 4}}
+				]},
+				{"image": "sheet-1.png", "frames": {
+					"b.png": {"frame": {"x": 4, "y": 0, "w": 4, "h": 4}},
+					"c.png": {"frame": {"x": 8, "y": 0, "w": 4, "h": 4}}
+				}}
+			],
+			"meta": {"app": "test"}
+		}`
+		parsed texturePackerJSON
+		pages  = [][]string{{"a.png"}, {"b.png", "c.png"}}
+	)
+	if err := json.Unmarshal([]byte(data), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Textures) != len(pages) {
+		t.Fatalf("Got %v textures, want %v", len(parsed.Textures), len(pages))
+	}
+	for i, texture := range parsed.Textures {
+		names, _, err := decodeFrameNames(string(texture.Frames))
+		if err != nil {
+			t.Fatalf("%v: %v", texture.Image, err)
+		}
+		if !reflect.DeepEqual(names, pages[i]) {
+			t.Errorf("%v: got %v, want %v", texture.Image, names, pages[i])
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loaders
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pikkpoiss/gamejam/v1/base/core"
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+type texturePackerJSONArray struct {
+	Frames []texturePackerFrame `json:"frames"`
+	Meta   texturePackerMeta    `json:"meta"`
+}
+
+func newTexturePackerFrame(key string, data sprites.SpriteData) texturePackerFrame {
+	var (
+		w       = int(data.Bounds.X())
+		h       = int(data.Bounds.Y())
+		trimX   = int(data.TrimOffset.X())
+		trimY   = int(data.TrimOffset.Y())
+		sourceW = int(data.SourceSize.X())
+		sourceH = int(data.SourceSize.Y())
+	)
+	return texturePackerFrame{
+		Filename: key,
+		Frame: texturePackerIntCoords{
+			X: int(data.Offset.X()),
+			Y: int(data.Offset.Y()),
+			W: w,
+			H: h,
+		},
+		Rotated:          data.Rotated,
+		Trimmed:          trimX != 0 || trimY != 0 || w != sourceW || h != sourceH,
+		SpriteSourceSize: texturePackerIntCoords{X: trimX, Y: trimY, W: w, H: h},
+		SourceSize:       texturePackerSize{W: sourceW, H: sourceH},
+		Pivot: &texturePackerFloatCoords{
+			X: data.Pivot.X(),
+			Y: data.Pivot.Y(),
+		},
+	}
+}
+
+// Writes packed sheets as PNG images and JSON-array descriptions which
+// TexturePackerLoader can read.
+type TexturePackerWriter struct {
+}
+
+func NewTexturePackerWriter() *TexturePackerWriter {
+	return &TexturePackerWriter{}
+}
+
+// Writes a single page to jsonPath, with the image alongside it. Multiple
+// pages are written as jsonPath with a -0, -1, ... suffix, and each file
+// lists the others in related_multi_packs.
+func (w *TexturePackerWriter) Write(jsonPath string, pages []*sprites.PackedSheet) (err error) {
+	var (
+		base      = strings.TrimSuffix(jsonPath, filepath.Ext(jsonPath))
+		jsonPaths = make([]string, len(pages))
+		i         int
+		j         int
+		related   []string
+	)
+	if len(pages) == 0 {
+		err = fmt.Errorf("No pages to write")
+		return
+	}
+	for i = range pages {
+		jsonPaths[i] = base + ".json"
+		if len(pages) > 1 {
+			jsonPaths[i] = fmt.Sprintf("%v-%v.json", base, i)
+		}
+	}
+	for i = range pages {
+		related = nil
+		for j = range pages {
+			if j != i {
+				related = append(related, filepath.Base(jsonPaths[j]))
+			}
+		}
+		if err = w.writePage(jsonPaths[i], pages[i], related); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (w *TexturePackerWriter) writePage(jsonPath string, page *sprites.PackedSheet, related []string) (err error) {
+	var (
+		imagePath = strings.TrimSuffix(jsonPath, filepath.Ext(jsonPath)) + ".png"
+		out       texturePackerJSONArray
+		sprite    *sprites.Sprite
+		data      []byte
+	)
+	for _, key := range page.Keys() {
+		if sprite, err = page.Sprite(key); err != nil {
+			return
+		}
+		out.Frames = append(out.Frames, newTexturePackerFrame(key, sprite.Data()))
+	}
+	out.Meta = texturePackerMeta{
+		App:               "https://github.com/pikkpoiss/gamejam",
+		Version:           "1.0",
+		Image:             filepath.Base(imagePath),
+		Format:            "RGBA8888",
+		Size:              texturePackerSize{W: page.Width, H: page.Height},
+		Scale:             "1",
+		RelatedMultiPacks: related,
+	}
+	if data, err = json.MarshalIndent(out, "", "\t"); err != nil {
+		return
+	}
+	if err = core.WritePNG(imagePath, page.Image()); err != nil {
+		return
+	}
+	err = ioutil.WriteFile(jsonPath, data, 0644)
+	return
+}
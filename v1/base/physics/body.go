@@ -0,0 +1,311 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pikkpoiss/gamejam/v1/base/collision"
+	"math"
+)
+
+type BodyType int
+
+const (
+	Dynamic   BodyType = iota // Moved by forces and contacts.
+	Static                    // Never moves.
+	Kinematic                 // Moves by its velocity, pushing dynamic bodies aside.
+)
+
+// What a body moves, such as a render.Instance. If it also has a
+// Position() mgl32.Vec3 method the body starts there.
+type Target interface {
+	SetPosition(p mgl32.Vec3)
+	SetRotation(degrees float32)
+}
+
+type positioned interface {
+	Position() mgl32.Vec3
+}
+
+// A rigid body, which rotates around its position. Shapes should be centered
+// on the origin for bodies which rotate; bodies with AABB shapes never rotate.
+type Body struct {
+	Restitution    float32 // Bounciness, where the larger of two bodies' is used.
+	Friction       float32
+	GravityScale   float32
+	LinearDamping  float32 // Fraction of velocity lost per second.
+	AngularDamping float32
+	FixedRotation  bool
+	kind           BodyType
+	target         Target
+	position       mgl32.Vec2
+	z              float32
+	rotation       float32 // Radians.
+	velocity       mgl32.Vec2
+	angular        float32 // Radians per second.
+	force          mgl32.Vec2
+	torque         float32
+	mass           float32
+	invMass        float32
+	inertia        float32
+	invInertia     float32
+	aligned        bool // Has an AABB shape, so can't rotate.
+	massOverride   bool
+	colliders      []*collision.Collider
+	densities      []float32
+	world          *World
+	sleeping       bool
+	stillTime      float32 // Seconds spent below the sleep velocity.
+}
+
+func NewBody(kind BodyType, target Target) (b *Body) {
+	b = &Body{
+		Friction:     0.5,
+		GravityScale: 1,
+		kind:         kind,
+		target:       target,
+	}
+	if p, ok := target.(positioned); ok {
+		pos := p.Position()
+		b.position = mgl32.Vec2{pos.X(), pos.Y()}
+		b.z = pos.Z()
+	}
+	b.updateMass()
+	return
+}
+
+func (b *Body) Type() BodyType {
+	return b.kind
+}
+
+func (b *Body) Target() Target {
+	return b.target
+}
+
+// Adds a collider for shape, with mass from its area times density unless
+// SetMass was called.
+func (b *Body) AddShape(shape collision.Shape, density float32) (c *collision.Collider) {
+	c = collision.NewCollider(b, shape)
+	b.colliders = append(b.colliders, c)
+	b.densities = append(b.densities, density)
+	if b.world != nil {
+		b.world.collision.Add(c)
+	}
+	b.updateMass()
+	return
+}
+
+func (b *Body) RemoveCollider(c *collision.Collider) {
+	for i, other := range b.colliders {
+		if other == c {
+			b.colliders = append(b.colliders[:i], b.colliders[i+1:]...)
+			b.densities = append(b.densities[:i], b.densities[i+1:]...)
+			if b.world != nil {
+				b.world.collision.Remove(c)
+			}
+			break
+		}
+	}
+	b.updateMass()
+}
+
+func (b *Body) Colliders() []*collision.Collider {
+	return b.colliders
+}
+
+func (b *Body) Position() mgl32.Vec3 {
+	return mgl32.Vec3{b.position.X(), b.position.Y(), b.z}
+}
+
+// Teleports the body, waking it.
+func (b *Body) SetPosition(p mgl32.Vec3) {
+	b.position = mgl32.Vec2{p.X(), p.Y()}
+	b.z = p.Z()
+	b.Wake()
+	b.sync()
+}
+
+// Returns the rotation in degrees, counterclockwise, as render.Instance.
+func (b *Body) Rotation() float32 {
+	return mgl32.RadToDeg(b.rotation)
+}
+
+func (b *Body) SetRotation(degrees float32) {
+	b.rotation = mgl32.DegToRad(degrees)
+	b.Wake()
+	b.sync()
+}
+
+// In world units per second.
+func (b *Body) Velocity() mgl32.Vec2 {
+	return b.velocity
+}
+
+func (b *Body) SetVelocity(v mgl32.Vec2) {
+	if b.kind != Static {
+		b.velocity = v
+		b.Wake()
+	}
+}
+
+// In degrees per second, counterclockwise.
+func (b *Body) AngularVelocity() float32 {
+	return mgl32.RadToDeg(b.angular)
+}
+
+func (b *Body) SetAngularVelocity(degrees float32) {
+	if b.kind != Static {
+		b.angular = mgl32.DegToRad(degrees)
+		b.Wake()
+	}
+}
+
+// Pushes the body's position during the next step. Forces are cleared after
+// each step.
+func (b *Body) ApplyForce(force mgl32.Vec2) {
+	b.force = b.force.Add(force)
+	b.Wake()
+}
+
+func (b *Body) ApplyTorque(torque float32) {
+	b.torque += torque
+	b.Wake()
+}
+
+// Changes velocity immediately, as if struck at point in world space.
+func (b *Body) ApplyImpulse(impulse, point mgl32.Vec2) {
+	b.applyImpulse(impulse, point.Sub(b.position))
+	b.Wake()
+}
+
+func (b *Body) applyImpulse(impulse, r mgl32.Vec2) {
+	b.velocity = b.velocity.Add(impulse.Mul(b.invMass))
+	b.angular += b.inverseInertia() * cross(r, impulse)
+}
+
+func (b *Body) Mass() float32 {
+	return b.mass
+}
+
+// Overrides the mass from shapes, scaling inertia to match. Zero returns to
+// the mass from shapes.
+func (b *Body) SetMass(mass float32) {
+	b.massOverride = mass > 0
+	b.mass = mass
+	b.updateMass()
+}
+
+func (b *Body) Sleeping() bool {
+	return b.sleeping
+}
+
+func (b *Body) Wake() {
+	b.sleeping = false
+	b.stillTime = 0
+}
+
+// Works out mass and inertia around the body's position from its shapes.
+func (b *Body) updateMass() {
+	var (
+		mass, inertia float32
+		aligned       bool
+	)
+	for n, c := range b.colliders {
+		m, i := shapeMass(c.Shape, b.densities[n])
+		mass += m
+		inertia += i
+		if _, ok := c.Shape.(collision.AABB); ok {
+			aligned = true
+		}
+	}
+	// Boxes stay axis aligned whatever the body's rotation, so a body with
+	// one can't turn without its collision going wrong.
+	b.aligned = aligned
+	if b.massOverride {
+		if mass > 0 {
+			inertia *= b.mass / mass
+		}
+		mass = b.mass
+	}
+	if mass <= 0 {
+		mass, inertia = 1, 0
+	}
+	b.mass, b.inertia = mass, inertia
+	b.invMass, b.invInertia = 0, 0
+	if b.kind == Dynamic {
+		b.invMass = 1 / mass
+		if inertia > 0 {
+			b.invInertia = 1 / inertia
+		}
+	}
+}
+
+func (b *Body) fixedRotation() bool {
+	return b.FixedRotation || b.aligned
+}
+
+func (b *Body) inverseInertia() float32 {
+	if b.fixedRotation() {
+		return 0
+	}
+	return b.invInertia
+}
+
+// Returns mass and inertia around the origin for shape.
+func shapeMass(shape collision.Shape, density float32) (mass, inertia float32) {
+	switch s := shape.(type) {
+	case collision.Circle:
+		mass = density * math.Pi * s.Radius * s.Radius
+		inertia = mass * (s.Radius*s.Radius/2 + s.Center.Dot(s.Center))
+	case collision.AABB:
+		return polygonMass([]mgl32.Vec2{
+			s.Min,
+			mgl32.Vec2{s.Max.X(), s.Min.Y()},
+			s.Max,
+			mgl32.Vec2{s.Min.X(), s.Max.Y()},
+		}, density)
+	case *collision.Polygon:
+		return polygonMass(s.Points(), density)
+	}
+	return
+}
+
+// Sums the triangles from the origin to each counterclockwise edge.
+func polygonMass(points []mgl32.Vec2, density float32) (mass, inertia float32) {
+	for i, a := range points {
+		b := points[(i+1)%len(points)]
+		area := cross(a, b) / 2
+		mass += density * area
+		inertia += density * area * (a.Dot(a) + a.Dot(b) + b.Dot(b)) / 6
+	}
+	return
+}
+
+// Moves the target to the body.
+func (b *Body) sync() {
+	if b.target != nil {
+		b.target.SetPosition(b.Position())
+		b.target.SetRotation(b.Rotation())
+	}
+}
+
+func cross(a, b mgl32.Vec2) float32 {
+	return a.X()*b.Y() - a.Y()*b.X()
+}
+
+// Returns the velocity of a point at r from a center spinning at w.
+func crossScalar(w float32, r mgl32.Vec2) mgl32.Vec2 {
+	return mgl32.Vec2{-w * r.Y(), w * r.X()}
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pikkpoiss/gamejam/v1/base/collision"
+	"testing"
+	"time"
+)
+
+func TestAABBBodiesDontRotate(t *testing.T) {
+	var (
+		w   = NewWorld(WorldConfig{})
+		box = NewBody(Dynamic, nil)
+		cog = NewBody(Dynamic, nil)
+	)
+	box.AddShape(collision.NewAABB(2, 2), 1)
+	cog.AddShape(collision.Circle{Radius: 1}, 1)
+	cog.SetPosition(mgl32.Vec3{10, 0, 0})
+	w.Add(box)
+	w.Add(cog)
+	for _, b := range []*Body{box, cog} {
+		p := b.Position()
+		b.ApplyImpulse(mgl32.Vec2{0, 1}, mgl32.Vec2{p.X() + 1, p.Y()})
+		b.ApplyTorque(10)
+		b.SetAngularVelocity(90)
+	}
+	w.Step()
+	if box.Rotation() != 0 || box.AngularVelocity() != 0 {
+		t.Errorf("Box turned to %v at %v degrees per second", box.Rotation(), box.AngularVelocity())
+	}
+	if cog.Rotation() == 0 {
+		t.Errorf("Circle didn't turn")
+	}
+}
+
+// Two boxes asleep side by side, overlapping slightly.
+func sleepingPair(t *testing.T) (w *World, a, b *Body) {
+	w = NewWorld(WorldConfig{SleepTime: time.Second / 10})
+	a = NewBody(Dynamic, nil)
+	b = NewBody(Dynamic, nil)
+	a.AddShape(collision.NewAABB(2, 2), 1)
+	b.AddShape(collision.NewAABB(2, 2), 1)
+	b.SetPosition(mgl32.Vec3{1.99, 0, 0})
+	a.GravityScale, b.GravityScale = 0, 0
+	w.Add(a)
+	w.Add(b)
+	for i := 0; i < 60 && !(a.Sleeping() && b.Sleeping()); i++ {
+		w.Step()
+	}
+	if !a.Sleeping() || !b.Sleeping() {
+		t.Fatalf("Bodies never fell asleep")
+	}
+	return
+}
+
+func TestSleepersWokenByMovingBodies(t *testing.T) {
+	w, a, b := sleepingPair(t)
+	b.SetVelocity(mgl32.Vec2{0.01, 0})
+	w.Step()
+	if !a.Sleeping() {
+		t.Errorf("Woken by a neighbour slower than SleepVelocity")
+	}
+	w, a, b = sleepingPair(t)
+	b.SetVelocity(mgl32.Vec2{-5, 0})
+	w.Step()
+	if a.Sleeping() {
+		t.Errorf("Still asleep after being hit")
+	}
+}
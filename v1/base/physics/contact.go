@@ -0,0 +1,143 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pikkpoiss/gamejam/v1/base/collision"
+	"math"
+)
+
+// One point of a contact between two bodies, solved with sequential impulses.
+type contactPoint struct {
+	a, b          *Body
+	rA, rB        mgl32.Vec2 // From each body's position to the point.
+	normal        mgl32.Vec2 // From a to b.
+	tangent       mgl32.Vec2
+	normalMass    float32
+	tangentMass   float32
+	bias          float32 // Target separating velocity.
+	friction      float32
+	normalImpulse float32 // Accumulated over iterations.
+	frictionTotal float32
+}
+
+// Returns the body of a collider, or the world's static stand-in with the
+// collider's position for colliders not attached to one.
+func (w *World) colliderBody(c *collision.Collider) (b *Body, position mgl32.Vec2) {
+	var ok bool
+	if b, ok = c.Body.(*Body); ok {
+		return b, b.position
+	}
+	p := c.Body.Position()
+	return w.static, mgl32.Vec2{p.X(), p.Y()}
+}
+
+// Wakes a sleeping body touched by one moving faster than the sleep
+// threshold, so resting neighbours don't keep each other awake.
+func (w *World) wakeTouching(a, b *Body) {
+	if w.moving(a) && b.kind == Dynamic && b.sleeping {
+		b.Wake()
+	}
+	if w.moving(b) && a.kind == Dynamic && a.sleeping {
+		a.Wake()
+	}
+}
+
+func (w *World) moving(b *Body) bool {
+	if b.kind == Static || b.sleeping {
+		return false
+	}
+	return b.velocity.Len() > w.cfg.SleepVelocity || abs32(b.angular) > w.cfg.SleepVelocity
+}
+
+func (w *World) prepareContacts(dt float32) {
+	w.contacts = w.contacts[:0]
+	for _, contact := range w.collision.Contacts() {
+		if contact.Trigger() {
+			continue
+		}
+		a, posA := w.colliderBody(contact.A)
+		b, posB := w.colliderBody(contact.B)
+		if a.kind != Dynamic && b.kind != Dynamic {
+			continue
+		}
+		w.wakeTouching(a, b)
+		if (a.sleeping || a.kind != Dynamic) && (b.sleeping || b.kind != Dynamic) {
+			continue
+		}
+		var (
+			restitution = float32(math.Max(float64(a.Restitution), float64(b.Restitution)))
+			friction    = float32(math.Sqrt(float64(a.Friction * b.Friction)))
+			invIA       = a.inverseInertia()
+			invIB       = b.inverseInertia()
+			tangent     = mgl32.Vec2{-contact.Normal.Y(), contact.Normal.X()}
+			depthBias   = correction / dt * float32(math.Max(0, float64(contact.Depth-penetrationSlop)))
+		)
+		for _, point := range contact.Points {
+			p := contactPoint{
+				a:        a,
+				b:        b,
+				rA:       point.Sub(posA),
+				rB:       point.Sub(posB),
+				normal:   contact.Normal,
+				tangent:  tangent,
+				friction: friction,
+				bias:     depthBias,
+			}
+			rnA, rnB := cross(p.rA, p.normal), cross(p.rB, p.normal)
+			p.normalMass = 1 / (a.invMass + b.invMass + invIA*rnA*rnA + invIB*rnB*rnB)
+			rtA, rtB := cross(p.rA, tangent), cross(p.rB, tangent)
+			p.tangentMass = 1 / (a.invMass + b.invMass + invIA*rtA*rtA + invIB*rtB*rtB)
+			if vn := p.relativeVelocity().Dot(p.normal); vn < -restitutionThreshold {
+				p.bias = float32(math.Max(float64(p.bias), float64(-restitution*vn)))
+			}
+			w.contacts = append(w.contacts, p)
+		}
+	}
+}
+
+// Returns the velocity of b relative to a at the point.
+func (p *contactPoint) relativeVelocity() mgl32.Vec2 {
+	var (
+		vA = p.a.velocity.Add(crossScalar(p.a.angular, p.rA))
+		vB = p.b.velocity.Add(crossScalar(p.b.angular, p.rB))
+	)
+	return vB.Sub(vA)
+}
+
+func (p *contactPoint) apply(impulse mgl32.Vec2) {
+	p.a.applyImpulse(impulse.Mul(-1), p.rA)
+	p.b.applyImpulse(impulse, p.rB)
+}
+
+func (p *contactPoint) solve() {
+	var (
+		vn    = p.relativeVelocity().Dot(p.normal)
+		delta = p.normalMass * (p.bias - vn)
+		total = float32(math.Max(float64(p.normalImpulse+delta), 0))
+	)
+	delta, p.normalImpulse = total-p.normalImpulse, total
+	p.apply(p.normal.Mul(delta))
+
+	var (
+		vt       = p.relativeVelocity().Dot(p.tangent)
+		limit    = p.friction * p.normalImpulse
+		friction = p.frictionTotal - p.tangentMass*vt
+	)
+	friction = float32(math.Max(-float64(limit), math.Min(float64(limit), float64(friction))))
+	delta, p.frictionTotal = friction-p.frictionTotal, friction
+	p.apply(p.tangent.Mul(delta))
+}
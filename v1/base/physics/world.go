@@ -0,0 +1,233 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pikkpoiss/gamejam/v1/base/collision"
+	"math"
+	"time"
+)
+
+type WorldConfig struct {
+	Gravity    mgl32.Vec2
+	TimeStep   time.Duration // Of each fixed step. Defaults to 1/60 s.
+	MaxSteps   int           // Per Update, dropping time beyond. Defaults to 5.
+	Iterations int           // Of the contact solver per step. Defaults to 8.
+	// Time a body must be nearly still before it sleeps. Defaults to half a
+	// second, and negative disables sleeping.
+	SleepTime     time.Duration
+	SleepVelocity float32 // In world units per second. Defaults to 0.05.
+	// Update never drops time, and body state is rounded after each step so
+	// that tiny floating point differences between builds, such as fused
+	// multiply-adds, don't grow. Replays which apply the same inputs on the
+	// same steps then play out the same.
+	Deterministic bool
+	Collision     collision.WorldConfig
+}
+
+func (c WorldConfig) withDefaults() WorldConfig {
+	if c.TimeStep <= 0 {
+		c.TimeStep = time.Second / 60
+	}
+	if c.MaxSteps <= 0 {
+		c.MaxSteps = 5
+	}
+	if c.Iterations <= 0 {
+		c.Iterations = 8
+	}
+	if c.SleepTime == 0 {
+		c.SleepTime = time.Second / 2
+	}
+	if c.SleepVelocity <= 0 {
+		c.SleepVelocity = 0.05
+	}
+	return c
+}
+
+const (
+	// Penetration allowed before positions are corrected, in world units.
+	penetrationSlop = 0.01
+	// Fraction of penetration corrected per step.
+	correction = 0.2
+	// Approach speed below which contacts don't bounce.
+	restitutionThreshold = 1
+	// Grid state is rounded to in deterministic mode.
+	quantum = 1.0 / 65536
+)
+
+// Simulates bodies with impulses in fixed steps. Colliders in the collision
+// world which don't belong to a Body, such as level geometry, are static.
+type World struct {
+	cfg         WorldConfig
+	collision   *collision.World
+	bodies      []*Body
+	accumulator time.Duration
+	steps       uint64
+	contacts    []contactPoint
+	static      *Body // Stands in for colliders attached to something other than a Body.
+}
+
+func NewWorld(cfg WorldConfig) *World {
+	cfg = cfg.withDefaults()
+	return &World{
+		cfg:       cfg,
+		collision: collision.NewWorld(cfg.Collision),
+		static:    &Body{kind: Static},
+	}
+}
+
+func (w *World) Config() WorldConfig {
+	return w.cfg
+}
+
+// Returns the collision world, for queries and overlap events.
+func (w *World) Collision() *collision.World {
+	return w.collision
+}
+
+func (w *World) Add(b *Body) {
+	if b.world == w {
+		return
+	}
+	if b.world != nil {
+		b.world.Remove(b)
+	}
+	b.world = w
+	w.bodies = append(w.bodies, b)
+	for _, c := range b.colliders {
+		w.collision.Add(c)
+	}
+	b.sync()
+}
+
+func (w *World) Remove(b *Body) {
+	if b.world != w {
+		return
+	}
+	for i, other := range w.bodies {
+		if other == b {
+			copy(w.bodies[i:], w.bodies[i+1:])
+			w.bodies[len(w.bodies)-1] = nil
+			w.bodies = w.bodies[:len(w.bodies)-1]
+			break
+		}
+	}
+	for _, c := range b.colliders {
+		w.collision.Remove(c)
+	}
+	b.world = nil
+}
+
+func (w *World) Bodies() []*Body {
+	return w.bodies
+}
+
+// Returns the number of steps taken, for keying replayed inputs.
+func (w *World) Steps() uint64 {
+	return w.steps
+}
+
+// Runs as many fixed steps as fit in elapsed plus time left over from
+// earlier calls. Call once per frame with the frame time.
+func (w *World) Update(elapsed time.Duration) (steps int) {
+	w.accumulator += elapsed
+	for w.accumulator >= w.cfg.TimeStep {
+		if steps >= w.cfg.MaxSteps && !w.cfg.Deterministic {
+			w.accumulator = 0
+			break
+		}
+		w.Step()
+		w.accumulator -= w.cfg.TimeStep
+		steps++
+	}
+	return
+}
+
+// Advances by one TimeStep, then moves each body's target.
+func (w *World) Step() {
+	var dt = float32(w.cfg.TimeStep.Seconds())
+	for _, b := range w.bodies {
+		w.integrateVelocity(b, dt)
+	}
+	w.collision.Step()
+	w.prepareContacts(dt)
+	for i := 0; i < w.cfg.Iterations; i++ {
+		for j := range w.contacts {
+			w.contacts[j].solve()
+		}
+	}
+	for _, b := range w.bodies {
+		w.integratePosition(b, dt)
+	}
+	w.steps++
+	for _, b := range w.bodies {
+		b.sync()
+	}
+}
+
+func (w *World) integrateVelocity(b *Body, dt float32) {
+	if b.kind != Dynamic || b.sleeping {
+		b.force, b.torque = mgl32.Vec2{}, 0
+		return
+	}
+	var acceleration = w.cfg.Gravity.Mul(b.GravityScale).Add(b.force.Mul(b.invMass))
+	b.velocity = b.velocity.Add(acceleration.Mul(dt))
+	b.angular += b.torque * b.inverseInertia() * dt
+	b.velocity = b.velocity.Mul(damping(b.LinearDamping, dt))
+	b.angular *= damping(b.AngularDamping, dt)
+	if b.fixedRotation() {
+		b.angular = 0
+	}
+	b.force, b.torque = mgl32.Vec2{}, 0
+}
+
+func damping(rate, dt float32) float32 {
+	return float32(math.Max(0, 1-float64(rate*dt)))
+}
+
+func (w *World) integratePosition(b *Body, dt float32) {
+	if b.kind == Static || b.sleeping {
+		return
+	}
+	b.position = b.position.Add(b.velocity.Mul(dt))
+	b.rotation += b.angular * dt
+	if w.cfg.Deterministic {
+		b.position = mgl32.Vec2{quantize(b.position.X()), quantize(b.position.Y())}
+		b.velocity = mgl32.Vec2{quantize(b.velocity.X()), quantize(b.velocity.Y())}
+		b.rotation = quantize(b.rotation)
+		b.angular = quantize(b.angular)
+	}
+	if b.kind != Dynamic || w.cfg.SleepTime < 0 {
+		return
+	}
+	if b.velocity.Len() > w.cfg.SleepVelocity || abs32(b.angular) > w.cfg.SleepVelocity {
+		b.stillTime = 0
+	} else if b.stillTime += dt; b.stillTime >= float32(w.cfg.SleepTime.Seconds()) {
+		b.sleeping = true
+		b.velocity, b.angular = mgl32.Vec2{}, 0
+	}
+}
+
+func quantize(v float32) float32 {
+	return float32(math.Floor(float64(v)/quantum+0.5) * quantum)
+}
+
+func abs32(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,175 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pikkpoiss/gamejam/v1/base/collision"
+	"math"
+	"sync"
+	"testing"
+)
+
+// A world with gravity and a 20x1 floor, not attached to a Body, with its
+// top at y 0.
+func floorWorld(cfg WorldConfig) (w *World) {
+	cfg.Gravity = mgl32.Vec2{0, -10}
+	w = NewWorld(cfg)
+	w.Collision().Add(collision.NewCollider(
+		collision.NewTransform(mgl32.Vec3{0, -0.5, 0}),
+		collision.NewAABB(20, 1),
+	))
+	return
+}
+
+// Adds a ball of radius 0.5 at position.
+func addBall(w *World, position mgl32.Vec2) (b *Body) {
+	b = NewBody(Dynamic, nil)
+	b.AddShape(collision.Circle{Radius: 0.5}, 1)
+	b.SetPosition(mgl32.Vec3{position.X(), position.Y(), 0})
+	w.Add(b)
+	return
+}
+
+type bodyState struct {
+	position mgl32.Vec3
+	velocity mgl32.Vec2
+	rotation float32
+	angular  float32
+}
+
+// Drops a pile of balls and a box, pushing one ball partway through, and
+// returns every body's state after each step.
+func replay(t *testing.T, steps int) (states [][]bodyState) {
+	var (
+		w           = floorWorld(WorldConfig{Deterministic: true})
+		bodies      []*Body
+		box         = NewBody(Dynamic, nil)
+		square, err = collision.NewPolygon(
+			mgl32.Vec2{-0.5, -0.5},
+			mgl32.Vec2{0.5, -0.5},
+			mgl32.Vec2{0.5, 0.5},
+			mgl32.Vec2{-0.5, 0.5},
+		)
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < 5; i++ {
+		bodies = append(bodies, addBall(w, mgl32.Vec2{float32(i)*0.7 - 1.4, 1 + float32(i)*1.1}))
+	}
+	box.AddShape(square, 2)
+	box.SetPosition(mgl32.Vec3{0.2, 7, 0})
+	box.SetRotation(30)
+	w.Add(box)
+	bodies = append(bodies, box)
+	for i := 0; i < steps; i++ {
+		if i == 40 {
+			bodies[2].ApplyImpulse(mgl32.Vec2{3, 1}, mgl32.Vec2{-0.2, 3.4})
+		}
+		w.Step()
+		var state = make([]bodyState, len(bodies))
+		for j, b := range bodies {
+			state[j] = bodyState{b.Position(), b.Velocity(), b.Rotation(), b.AngularVelocity()}
+		}
+		states = append(states, state)
+	}
+	return
+}
+
+// The replays run at the same time, so the race detector also checks that
+// worlds share no state.
+func TestDeterministicWorldsMatch(t *testing.T) {
+	var (
+		wait  sync.WaitGroup
+		start = make(chan struct{})
+		runs  = make([][][]bodyState, 2)
+	)
+	for i := range runs {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			<-start
+			runs[i] = replay(t, 240)
+		}(i)
+	}
+	close(start)
+	wait.Wait()
+	if len(runs[0]) == 0 || len(runs[0]) != len(runs[1]) {
+		t.Fatalf("Replays ran for %v and %v steps", len(runs[0]), len(runs[1]))
+	}
+	for step := range runs[0] {
+		for j := range runs[0][step] {
+			if runs[0][step][j] != runs[1][step][j] {
+				t.Fatalf("Step %v body %v: %+v != %+v", step, j, runs[0][step][j], runs[1][step][j])
+			}
+		}
+	}
+	if last := runs[0][len(runs[0])-1]; last[0].position == (mgl32.Vec3{-1.4, 1, 0}) {
+		t.Errorf("Nothing moved")
+	}
+}
+
+func TestBallComesToRest(t *testing.T) {
+	var (
+		w    = floorWorld(WorldConfig{})
+		ball = addBall(w, mgl32.Vec2{0, 3})
+	)
+	for i := 0; i < 300 && !ball.Sleeping(); i++ {
+		w.Step()
+	}
+	if !ball.Sleeping() {
+		t.Errorf("Still moving at %v", ball.Velocity())
+	}
+	if y := ball.Position().Y(); math.Abs(float64(y-0.5)) > 0.02 {
+		t.Errorf("Resting at y %v, want 0.5", y)
+	}
+	if x := ball.Position().X(); x != 0 {
+		t.Errorf("Drifted to x %v", x)
+	}
+}
+
+// A static Body floor rather than a bare collider, with a perfectly bouncy
+// ball.
+func TestBallBounces(t *testing.T) {
+	var (
+		w     = NewWorld(WorldConfig{Gravity: mgl32.Vec2{0, -10}})
+		floor = NewBody(Static, nil)
+		ball  = addBall(w, mgl32.Vec2{0, 3})
+		top   float32
+		hit   bool
+	)
+	floor.AddShape(collision.NewAABB(20, 1), 1)
+	floor.SetPosition(mgl32.Vec3{0, -0.5, 0})
+	w.Add(floor)
+	ball.Restitution = 1
+	for i := 0; i < 120; i++ {
+		w.Step()
+		if ball.Velocity().Y() > 0 {
+			hit = true
+		}
+		if hit && ball.Position().Y() > top {
+			top = ball.Position().Y()
+		}
+	}
+	if !hit {
+		t.Fatalf("Never bounced")
+	}
+	// Dropped from 3, the ball should bounce most of the way back.
+	if top < 2.5 {
+		t.Errorf("Bounced up to %v, want nearly 3", top)
+	}
+}
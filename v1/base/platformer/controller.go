@@ -0,0 +1,439 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package platformer
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pikkpoiss/gamejam/v1/base/collision"
+	"math"
+	"time"
+)
+
+// Defaults suit tiles one unit across, and are multiplied by the grid's
+// scale.
+type ControllerConfig struct {
+	Size            mgl32.Vec2 // Of the collision box. Defaults to 0.8 by 0.9.
+	Gravity         float32    // Downward acceleration. Defaults to 40.
+	MaxFallSpeed    float32    // Defaults to 20.
+	RunSpeed        float32    // Defaults to 6.
+	Acceleration    float32    // Towards RunSpeed on the ground. Defaults to 60.
+	AirAcceleration float32    // Defaults to Acceleration.
+	JumpSpeed       float32    // Defaults to 14, jumping about two and a half tiles.
+	// Multiplies rising speed when jump is released early, for shorter hops.
+	// Defaults to 0.5, and 1 makes every jump full height.
+	JumpRelease float32
+	// Time after walking off a ledge during which a jump still works.
+	// Defaults to 0.1 seconds.
+	CoyoteTime time.Duration
+	// Time before landing that a jump press is remembered. Defaults to 0.1
+	// seconds.
+	JumpBuffer time.Duration
+	// Tallest ledge walked up without jumping, and furthest drop followed
+	// down slopes while grounded. Defaults to 0.5.
+	StepHeight float32
+}
+
+func (c ControllerConfig) withDefaults(scale float32) ControllerConfig {
+	var def = func(v *float32, d float32) {
+		if *v <= 0 {
+			*v = d * scale
+		}
+	}
+	if c.Size.X() <= 0 || c.Size.Y() <= 0 {
+		c.Size = mgl32.Vec2{0.8 * scale, 0.9 * scale}
+	}
+	def(&c.Gravity, 40)
+	def(&c.MaxFallSpeed, 20)
+	def(&c.RunSpeed, 6)
+	def(&c.Acceleration, 60)
+	if c.AirAcceleration <= 0 {
+		c.AirAcceleration = c.Acceleration
+	}
+	def(&c.JumpSpeed, 14)
+	def(&c.StepHeight, 0.5)
+	if c.JumpRelease <= 0 {
+		c.JumpRelease = 0.5
+	}
+	if c.CoyoteTime <= 0 {
+		c.CoyoteTime = time.Second / 10
+	}
+	if c.JumpBuffer <= 0 {
+		c.JumpBuffer = time.Second / 10
+	}
+	return c
+}
+
+// What a controller moves, such as a render.Instance. If it also has a
+// Position() mgl32.Vec3 method the controller starts there.
+type Target interface {
+	SetPosition(p mgl32.Vec3)
+}
+
+type positioned interface {
+	Position() mgl32.Vec3
+}
+
+// Moves a box through a tile grid, without physics, the way platformer
+// characters are expected to move. Its position is the center of the box.
+// Implements collision.Body, so triggers can be attached for pickups.
+type Controller struct {
+	cfg      ControllerConfig
+	grid     *TileGrid
+	target   Target
+	position mgl32.Vec2
+	z        float32
+	velocity mgl32.Vec2
+	move     float32
+	jumpHeld bool
+	grounded bool
+	ground   TileProperties
+	groundX  int
+	groundY  int
+	coyote   float32 // Seconds left to jump after leaving the ground.
+	buffer   float32 // Seconds left to act on a jump press.
+	jumping  bool    // Rising from a jump which may still be cut short.
+	dropping bool    // Falling through one-way platforms.
+	dropFrom float32 // Height of the one-way platforms being dropped through.
+	landed   bool
+	jumped   bool
+}
+
+func NewController(grid *TileGrid, cfg ControllerConfig, target Target) (c *Controller) {
+	c = &Controller{
+		cfg:    cfg.withDefaults(grid.scale),
+		grid:   grid,
+		target: target,
+	}
+	if p, ok := target.(positioned); ok {
+		pos := p.Position()
+		c.position = mgl32.Vec2{pos.X(), pos.Y()}
+		c.z = pos.Z()
+	}
+	return
+}
+
+func (c *Controller) Config() ControllerConfig {
+	return c.cfg
+}
+
+func (c *Controller) SetConfig(cfg ControllerConfig) {
+	c.cfg = cfg.withDefaults(c.grid.scale)
+}
+
+func (c *Controller) Grid() *TileGrid {
+	return c.grid
+}
+
+func (c *Controller) Position() mgl32.Vec3 {
+	return mgl32.Vec3{c.position.X(), c.position.Y(), c.z}
+}
+
+// Teleports the controller without checking for tiles.
+func (c *Controller) SetPosition(p mgl32.Vec3) {
+	c.position = mgl32.Vec2{p.X(), p.Y()}
+	c.z = p.Z()
+	c.grounded = false
+	c.sync()
+}
+
+func (c *Controller) Velocity() mgl32.Vec2 {
+	return c.velocity
+}
+
+// Sets velocity, such as for knockback or springs. Upward velocity leaves
+// the ground.
+func (c *Controller) SetVelocity(v mgl32.Vec2) {
+	c.velocity = v
+	if v.Y() > 0 {
+		c.grounded = false
+		c.coyote = 0
+	}
+}
+
+func (c *Controller) Bounds() collision.AABB {
+	var half = c.cfg.Size.Mul(0.5)
+	return collision.AABB{
+		Min: c.position.Sub(half),
+		Max: c.position.Add(half),
+	}
+}
+
+// Sets the input for following updates. move runs from -1, full speed left,
+// to 1, and jump is whether the jump button is held.
+func (c *Controller) SetInput(move float32, jump bool) {
+	if jump && !c.jumpHeld {
+		c.buffer = float32(c.cfg.JumpBuffer.Seconds())
+	}
+	c.move = float32(math.Max(-1, math.Min(1, float64(move))))
+	c.jumpHeld = jump
+}
+
+// Falls through the one-way platform being stood on, if any.
+func (c *Controller) Drop() {
+	if c.grounded && c.ground.Shape == TileOneWay {
+		c.dropping = true
+		c.dropFrom = c.Bounds().Min.Y()
+		c.grounded = false
+	}
+}
+
+func (c *Controller) Grounded() bool {
+	return c.grounded
+}
+
+// Returns the tile stood on, which is only meaningful while grounded.
+func (c *Controller) Ground() (tile TileProperties, x, y int) {
+	return c.ground, c.groundX, c.groundY
+}
+
+// Returns whether the last update landed on the ground.
+func (c *Controller) Landed() bool {
+	return c.landed
+}
+
+// Returns whether the last update started a jump.
+func (c *Controller) Jumped() bool {
+	return c.jumped
+}
+
+func (c *Controller) Update(elapsed time.Duration) {
+	var (
+		dt          = float32(elapsed.Seconds())
+		wasGrounded = c.grounded
+		accel       = c.cfg.AirAcceleration
+		delta       mgl32.Vec2
+		steps       int
+		stepping    bool
+	)
+	if dt <= 0 {
+		return
+	}
+	c.landed, c.jumped = false, false
+	if c.grounded {
+		accel = c.cfg.Acceleration
+		c.coyote = float32(c.cfg.CoyoteTime.Seconds())
+	} else {
+		c.coyote -= dt
+	}
+	c.velocity[0] = approach(c.velocity.X(), c.move*c.cfg.RunSpeed, accel*dt)
+	if c.buffer > 0 && (c.grounded || c.coyote > 0) {
+		c.velocity[1] = c.cfg.JumpSpeed
+		c.grounded = false
+		c.coyote, c.buffer = 0, 0
+		c.jumping, c.jumped = true, true
+	}
+	c.buffer -= dt
+	if c.jumping && (!c.jumpHeld || c.velocity.Y() <= 0) {
+		if c.velocity.Y() > 0 {
+			c.velocity[1] *= c.cfg.JumpRelease
+		}
+		c.jumping = false
+	}
+	c.velocity[1] = float32(math.Max(float64(c.velocity.Y()-c.cfg.Gravity*dt), -float64(c.cfg.MaxFallSpeed)))
+
+	// Move in steps of at most a quarter tile, so nothing is tunneled
+	// through and slopes stay within StepHeight.
+	delta = c.velocity.Mul(dt)
+	steps = int(math.Ceil(float64(max32(abs32(delta.X()), abs32(delta.Y())) / (c.grid.scale / 4))))
+	if steps < 1 {
+		steps = 1
+	}
+	stepping = wasGrounded && !c.jumped
+	c.grounded = false
+	for i := 0; i < steps; i++ {
+		c.moveX(delta.X()/float32(steps), stepping)
+		c.moveY(delta.Y()/float32(steps), stepping)
+	}
+	if stepping && !c.grounded && c.velocity.Y() <= 0 {
+		// Follow the ground down slopes instead of launching off them.
+		var saved = c.position
+		if !c.moveY(-c.cfg.StepHeight, true) {
+			c.position = saved
+		}
+	}
+	if c.grounded {
+		c.dropping = false
+	}
+	c.landed = c.grounded && !wasGrounded
+	c.sync()
+}
+
+func (c *Controller) epsilon() float32 {
+	return c.grid.scale * 1e-4
+}
+
+// Moves horizontally, stopping at solid tiles. Slopes and one-way platforms
+// never block sideways.
+func (c *Controller) moveX(dx float32, stepping bool) {
+	var (
+		eps     = c.epsilon()
+		s       = c.grid.scale
+		prev    = c.Bounds()
+		box     collision.AABB
+		blocked bool
+	)
+	if dx == 0 {
+		return
+	}
+	c.position[0] += dx
+	box = c.Bounds()
+	for ty := c.grid.cell(box.Min.Y() + eps); ty <= c.grid.cell(box.Max.Y()-eps); ty++ {
+		for tx := c.grid.cell(box.Min.X() + eps); tx <= c.grid.cell(box.Max.X()-eps); tx++ {
+			if c.grid.Tile(tx, ty).Shape != TileSolid {
+				continue
+			}
+			if stepping && float32(ty+1)*s-box.Min.Y() <= c.cfg.StepHeight {
+				continue
+			}
+			if dx > 0 && float32(tx)*s >= prev.Max.X()-eps {
+				c.position[0] = min32(c.position.X(), float32(tx)*s-c.cfg.Size.X()/2)
+				blocked = true
+			} else if dx < 0 && float32(tx+1)*s <= prev.Min.X()+eps {
+				c.position[0] = max32(c.position.X(), float32(tx+1)*s+c.cfg.Size.X()/2)
+				blocked = true
+			}
+		}
+	}
+	if blocked {
+		c.velocity[0] = 0
+	}
+}
+
+// Moves vertically, landing on floors and stopping at ceilings. Returns
+// whether the move landed.
+func (c *Controller) moveY(dy float32, stepping bool) (landed bool) {
+	var (
+		eps       = c.epsilon()
+		s         = c.grid.scale
+		prev      = c.Bounds()
+		box       collision.AABB
+		center    float32
+		allowance = eps
+	)
+	if dy == 0 {
+		return
+	}
+	c.position[1] += dy
+	box = c.Bounds()
+	center = c.position.X()
+	if stepping {
+		allowance = c.cfg.StepHeight
+	}
+	if dy < 0 {
+		var (
+			floor      = float32(math.Inf(-1))
+			slopeFloor = float32(math.Inf(-1))
+			tile       TileProperties
+			slopeTile  TileProperties
+			fx, fy     int
+			sx, sy     int
+		)
+		for ty := c.grid.cell(box.Min.Y()); ty <= c.grid.cell(prev.Min.Y()+allowance); ty++ {
+			for tx := c.grid.cell(box.Min.X() + eps); tx <= c.grid.cell(box.Max.X()-eps); tx++ {
+				var (
+					t   = c.grid.Tile(tx, ty)
+					top = float32(ty+1) * s
+				)
+				switch t.Shape {
+				case TileSolid:
+					if top <= prev.Min.Y()+allowance && top > box.Min.Y() && top > floor {
+						floor, tile, fx, fy = top, t, tx, ty
+					}
+				case TileOneWay:
+					if c.dropping && top >= c.dropFrom-eps {
+						continue
+					}
+					if top <= prev.Min.Y()+eps && top > box.Min.Y() && top > floor {
+						floor, tile, fx, fy = top, t, tx, ty
+					}
+				case TileSlopeUp, TileSlopeDown:
+					// Slopes hold up the center of the box.
+					if c.grid.cell(center) != tx {
+						continue
+					}
+					top = c.grid.slopeHeight(tx, ty, t.Shape, center)
+					if top <= prev.Min.Y()+allowance && top > box.Min.Y() && top > slopeFloor {
+						slopeFloor, slopeTile, sx, sy = top, t, tx, ty
+					}
+				}
+			}
+		}
+		if !math.IsInf(float64(slopeFloor), -1) {
+			// Standing on a slope lets the box's corners sink into the
+			// tiles it leads to.
+			floor, tile, fx, fy = slopeFloor, slopeTile, sx, sy
+		}
+		if math.IsInf(float64(floor), -1) {
+			return
+		}
+		c.position[1] = floor + c.cfg.Size.Y()/2
+		c.velocity[1] = max32(c.velocity.Y(), 0)
+		c.grounded, c.ground, c.groundX, c.groundY = true, tile, fx, fy
+		landed = true
+		return
+	}
+	var ceiling = float32(math.Inf(1))
+	for ty := c.grid.cell(prev.Max.Y() - eps); ty <= c.grid.cell(box.Max.Y()-eps); ty++ {
+		for tx := c.grid.cell(box.Min.X() + eps); tx <= c.grid.cell(box.Max.X()-eps); tx++ {
+			switch c.grid.Tile(tx, ty).Shape {
+			case TileSolid, TileSlopeUp, TileSlopeDown:
+				if bottom := float32(ty) * s; bottom >= prev.Max.Y()-eps && bottom < ceiling {
+					ceiling = bottom
+				}
+			}
+		}
+	}
+	if ceiling < box.Max.Y() {
+		c.position[1] = ceiling - c.cfg.Size.Y()/2
+		c.velocity[1] = min32(c.velocity.Y(), 0)
+		c.jumping = false
+	}
+	return
+}
+
+// Moves the target to the controller.
+func (c *Controller) sync() {
+	if c.target != nil {
+		c.target.SetPosition(c.Position())
+	}
+}
+
+func approach(v, target, step float32) float32 {
+	if v < target {
+		return min32(v+step, target)
+	}
+	return max32(v-step, target)
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func abs32(a float32) float32 {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
@@ -0,0 +1,211 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package platformer
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"math"
+	"testing"
+	"time"
+)
+
+const frame = time.Second / 60
+
+var testTiles = TileTable{
+	'#':  {Shape: TileSolid},
+	'-':  {Shape: TileOneWay},
+	'/':  {Shape: TileSlopeUp},
+	'\\': {Shape: TileSlopeDown},
+}
+
+// Returns a controller with the default 0.8 by 0.9 box centered at x, y in
+// grid, where the first line is the bottom row of one unit tiles.
+func testController(t *testing.T, grid string, x, y float32) *Controller {
+	var g, err = NewTileGrid(grid, 1, testTiles)
+	if err != nil {
+		t.Fatalf("NewTileGrid: %v", err)
+	}
+	c := NewController(g, ControllerConfig{}, nil)
+	c.SetPosition(mgl32.Vec3{x, y, 0})
+	return c
+}
+
+// Updates until the controller lands, returning how many updates it took.
+func land(t *testing.T, c *Controller) (frames int) {
+	for frames = 1; frames <= 300; frames++ {
+		c.Update(frame)
+		if c.Landed() {
+			return
+		}
+	}
+	t.Fatalf("Never landed, at %v", c.Position())
+	return
+}
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-3
+}
+
+func TestControllerLands(t *testing.T) {
+	var c = testController(t, "##########", 5, 4)
+	land(t, c)
+	if y := c.Position().Y(); !near(y, 1.45) {
+		t.Errorf("Landed at y %v, want 1.45", y)
+	}
+	for i := 0; i < 30; i++ {
+		c.Update(frame)
+	}
+	if !c.Grounded() || !near(c.Position().Y(), 1.45) || c.Velocity().Y() != 0 {
+		t.Errorf("Standing at %v moving %v", c.Position(), c.Velocity())
+	}
+}
+
+func TestControllerStopsAtWall(t *testing.T) {
+	var (
+		grid = "##########\n" +
+			"#........#\n" +
+			"#........#\n" +
+			"#........#"
+		tests = []struct {
+			name string
+			move float32
+			x    float32
+		}{
+			{"right", 1, 9 - 0.4},
+			{"left", -1, 1 + 0.4},
+		}
+	)
+	for _, test := range tests {
+		var c = testController(t, grid, 5, 1.45)
+		c.SetInput(test.move, false)
+		for i := 0; i < 120; i++ {
+			c.Update(frame)
+		}
+		if x := c.Position().X(); !near(x, test.x) {
+			t.Errorf("%v: stopped at x %v, want %v", test.name, x, test.x)
+		}
+		if !c.Grounded() || !near(c.Position().Y(), 1.45) {
+			t.Errorf("%v: at %v, grounded %v", test.name, c.Position(), c.Grounded())
+		}
+	}
+}
+
+// Jumps up through a one-way platform, lands on top of it, then drops back
+// through it to the floor.
+func TestControllerOneWayPlatform(t *testing.T) {
+	var c = testController(t,
+		"##########\n"+
+			"..........\n"+
+			"...----...", 5, 1.45)
+	c.Update(frame)
+	c.SetInput(0, true)
+	land(t, c)
+	if tile, _, y := c.Ground(); tile.Shape != TileOneWay || y != 2 || !near(c.Position().Y(), 3.45) {
+		t.Fatalf("Landed at %v on %v in row %v, want the platform", c.Position(), tile.Shape, y)
+	}
+	c.SetInput(0, false)
+	c.Update(frame)
+	c.Drop()
+	land(t, c)
+	if tile, _, y := c.Ground(); tile.Shape != TileSolid || y != 0 || !near(c.Position().Y(), 1.45) {
+		t.Errorf("Dropped to %v on %v in row %v, want the floor", c.Position(), tile.Shape, y)
+	}
+}
+
+// Walks up a slope onto the ledge it leads to without leaving the ground.
+func TestControllerClimbsSlope(t *testing.T) {
+	var c = testController(t,
+		"##########\n"+
+			"...../####", 2, 1.45)
+	c.Update(frame)
+	c.SetInput(1, false)
+	for i := 0; i < 120 && c.Position().X() < 7.5; i++ {
+		c.Update(frame)
+		var p = c.Position()
+		if !c.Grounded() {
+			t.Fatalf("Left the ground at %v", p)
+		}
+		if p.X() > 5 && p.X() < 6 && !near(p.Y(), p.X()-5+1+0.45) {
+			t.Errorf("At y %v halfway up the slope at x %v", p.Y(), p.X())
+		}
+	}
+	if p := c.Position(); p.X() < 7.5 || !near(p.Y(), 2.45) {
+		t.Errorf("Finished at %v, want on the ledge", p)
+	}
+}
+
+// Walks off a ledge, then presses jump after some frames in the air.
+func TestControllerCoyoteTime(t *testing.T) {
+	var tests = []struct {
+		frames int
+		jumps  bool
+	}{
+		{1, true},
+		{4, true}, // Within the default 0.1 seconds.
+		{12, false},
+	}
+	for _, test := range tests {
+		var c = testController(t,
+			"##########\n"+
+				"..........\n"+
+				"#####.....", 4, 3.45)
+		c.Update(frame)
+		c.SetInput(1, false)
+		for c.Grounded() {
+			c.Update(frame)
+		}
+		for i := 1; i < test.frames; i++ {
+			c.Update(frame)
+		}
+		c.SetInput(1, true)
+		c.Update(frame)
+		if c.Jumped() != test.jumps {
+			t.Errorf("Jump %v frames after leaving the ledge: jumped %v, want %v", test.frames, c.Jumped(), test.jumps)
+		}
+	}
+}
+
+// Presses jump some frames before landing, which jumps on landing if within
+// the buffer.
+func TestControllerJumpBuffer(t *testing.T) {
+	var (
+		grid    = "##########"
+		landing = land(t, testController(t, grid, 5, 6))
+		tests   = []struct {
+			early int
+			jumps bool
+		}{
+			{1, true},
+			{4, true}, // Within the default 0.1 seconds.
+			{12, false},
+		}
+	)
+	for _, test := range tests {
+		var (
+			c      = testController(t, grid, 5, 6)
+			jumped bool
+		)
+		for i := 1; i <= landing+2; i++ {
+			if i == landing-test.early+1 {
+				c.SetInput(0, true)
+			}
+			c.Update(frame)
+			jumped = jumped || c.Jumped()
+		}
+		if jumped != test.jumps {
+			t.Errorf("Jump %v frames before landing: jumped %v, want %v", test.early, jumped, test.jumps)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package platformer
+
+import (
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pikkpoiss/gamejam/v1/base/collision"
+	"math"
+	"strings"
+)
+
+type TileShape int
+
+const (
+	TileEmpty     TileShape = iota
+	TileSolid               // Blocks from every side.
+	TileOneWay              // Only its top is solid, and only from above.
+	TileSlopeUp             // Floor rising 45 degrees to the right.
+	TileSlopeDown           // Floor rising 45 degrees to the left.
+)
+
+type TileProperties struct {
+	Shape TileShape
+	Data  interface{} // For games, such as damage or a footstep sound.
+}
+
+// Properties for each rune of a grid. Runes not in the table are empty.
+type TileTable map[rune]TileProperties
+
+// Tiles laid out as loaders.TextLoader lays out the same grid and scale: the
+// first line at y zero, each tile scale units square.
+type TileGrid struct {
+	rows  [][]rune
+	scale float32
+	table TileTable
+}
+
+func NewTileGrid(grid string, scale float32, table TileTable) (g *TileGrid, err error) {
+	var lines = strings.Split(strings.TrimSpace(grid), "\n")
+	if len(lines) == 0 {
+		err = fmt.Errorf("No lines in input data")
+		return
+	}
+	if scale <= 0 {
+		err = fmt.Errorf("Tile grid scale must be positive, got %v", scale)
+		return
+	}
+	g = &TileGrid{
+		rows:  make([][]rune, len(lines)),
+		scale: scale,
+		table: table,
+	}
+	for y, line := range lines {
+		// Columns are byte offsets, as in TextLoader.
+		g.rows[y] = make([]rune, len(line))
+		for x, char := range line {
+			g.rows[y][x] = char
+		}
+	}
+	return
+}
+
+func (g *TileGrid) Scale() float32 {
+	return g.scale
+}
+
+func (g *TileGrid) Table() TileTable {
+	return g.table
+}
+
+// Returns the number of columns in the longest line and the number of lines.
+func (g *TileGrid) Size() (w, h int) {
+	for _, row := range g.rows {
+		if len(row) > w {
+			w = len(row)
+		}
+	}
+	return w, len(g.rows)
+}
+
+// Returns zero outside the grid.
+func (g *TileGrid) Rune(x, y int) rune {
+	if y < 0 || y >= len(g.rows) || x < 0 || x >= len(g.rows[y]) {
+		return 0
+	}
+	return g.rows[y][x]
+}
+
+// Changes a tile, such as for breakable blocks. Ignored outside the grid.
+func (g *TileGrid) SetRune(x, y int, r rune) {
+	if y >= 0 && y < len(g.rows) && x >= 0 && x < len(g.rows[y]) {
+		g.rows[y][x] = r
+	}
+}
+
+func (g *TileGrid) Tile(x, y int) TileProperties {
+	return g.table[g.Rune(x, y)]
+}
+
+// Returns the tile containing a point in world units.
+func (g *TileGrid) TileAt(p mgl32.Vec2) (x, y int) {
+	return g.cell(p.X()), g.cell(p.Y())
+}
+
+func (g *TileGrid) cell(v float32) int {
+	return int(math.Floor(float64(v / g.scale)))
+}
+
+func (g *TileGrid) Bounds(x, y int) collision.AABB {
+	return collision.AABB{
+		Min: mgl32.Vec2{float32(x) * g.scale, float32(y) * g.scale},
+		Max: mgl32.Vec2{float32(x+1) * g.scale, float32(y+1) * g.scale},
+	}
+}
+
+// Returns the height of a slope tile's floor at world x, clamped to the tile.
+func (g *TileGrid) slopeHeight(x, y int, shape TileShape, worldX float32) float32 {
+	var f = worldX/g.scale - float32(x)
+	if f < 0 {
+		f = 0
+	} else if f > 1 {
+		f = 1
+	}
+	if shape == TileSlopeDown {
+		f = 1 - f
+	}
+	return (float32(y) + f) * g.scale
+}
@@ -21,11 +21,13 @@ import (
 type Instance struct {
 	model    mgl32.Mat4
 	position mgl32.Vec3
+	offset   mgl32.Vec3
 	scale    mgl32.Vec3
 	rotation float32
 	Frame    int
 	Key      string // TODO: move to an interface{} data pointer.
 	color    mgl32.Vec4
+	tint     mgl32.Vec4
 	dirty    bool
 	next     *Instance
 	prev     *Instance
@@ -37,6 +39,7 @@ func newInstance() *Instance {
 		scale:    mgl32.Vec3{1.0, 1.0, 1.0},
 		position: mgl32.Vec3{0.0, 0.0, 0.0},
 		color:    mgl32.Vec4{0.0, 0.0, 0.0, 0.0},
+		tint:     mgl32.Vec4{1.0, 1.0, 1.0, 1.0},
 		rotation: 0,
 		dirty:    true,
 	}
@@ -49,6 +52,10 @@ func (i *Instance) SetScale(s mgl32.Vec3) {
 	}
 }
 
+func (i *Instance) Position() mgl32.Vec3 {
+	return i.position
+}
+
 func (i *Instance) SetPosition(p mgl32.Vec3) {
 	if i.position.X() != p.X() || i.position.Y() != p.Y() || i.position.Z() != p.Z() {
 		i.position = p
@@ -56,6 +63,20 @@ func (i *Instance) SetPosition(p mgl32.Vec3) {
 	}
 }
 
+// Sets a translation applied after rotation and before scale, so that the
+// geometry can be drawn away from the point the instance rotates around.
+func (i *Instance) SetOffset(o mgl32.Vec3) {
+	if i.offset.X() != o.X() || i.offset.Y() != o.Y() || i.offset.Z() != o.Z() {
+		i.offset = o
+		i.dirty = true
+	}
+}
+
+// Returns the rotation in degrees, counterclockwise.
+func (i *Instance) Rotation() float32 {
+	return i.rotation
+}
+
 func (i *Instance) SetRotation(r float32) {
 	if i.rotation != r {
 		i.rotation = r
@@ -72,6 +93,11 @@ func (i *Instance) GetModel() mgl32.Mat4 {
 			i.position.Z(),
 		)
 		model = model.Mul4(mgl32.HomogRotate3DZ(mgl32.DegToRad(i.rotation)))
+		model = model.Mul4(mgl32.Translate3D(
+			i.offset.X(),
+			i.offset.Y(),
+			i.offset.Z(),
+		))
 		model = model.Mul4(mgl32.Scale3D(i.scale.X(), i.scale.Y(), i.scale.Z()))
 		i.model = model
 		i.dirty = false
@@ -91,6 +117,16 @@ func (i *Instance) SetColor(r, g, b, a float32) {
 	i.dirty = true
 }
 
+func (i *Instance) Tint() mgl32.Vec4 {
+	return i.tint
+}
+
+// Sets a color the texture is multiplied by, before Color is added.
+func (i *Instance) SetTint(r, g, b, a float32) {
+	i.tint = mgl32.Vec4{r, g, b, a}
+	i.dirty = true
+}
+
 func (i *Instance) Next() *Instance {
 	return i.next
 }
@@ -47,6 +47,7 @@ func (l *InstanceList) Prepend(inst *Instance) {
 func (l *InstanceList) NewInstance() (inst *Instance) {
 	inst = newInstance()
 	inst.SetPosition(mgl32.Vec3{0, 0, 0})
+	inst.SetOffset(mgl32.Vec3{0, 0, 0})
 	inst.SetScale(mgl32.Vec3{1.0, 1.0, 1.0})
 	inst.SetRotation(0)
 	inst.Frame = 0
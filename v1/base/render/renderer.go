@@ -30,12 +30,13 @@ in vec2 v_TexturePos;
 in vec2 v_TextureMin;
 in vec2 v_TextureDim;
 in vec4 v_BaseColor;
+in vec4 v_TintColor;
 uniform sampler2D u_Texture;
 out vec4 v_FragData;
 
 void main() {
   vec2 v_TexturePosition = v_TextureMin + mod(v_TexturePos, v_TextureDim);
-  v_FragData = clamp(texture(u_Texture, v_TexturePosition) + v_BaseColor, 0.0, 1.0);
+  v_FragData = clamp(texture(u_Texture, v_TexturePosition) * v_TintColor + v_BaseColor, 0.0, 1.0);
 }`
 
 const VERTEX = `#version 150
@@ -55,6 +56,7 @@ in vec2 v_Texture;
 in float f_VertexFrame;
 in float f_InstanceFrame;
 in vec4 v_Color;
+in vec4 v_Tint;
 in mat4 m_Model;
 uniform mat4 m_View;
 uniform mat4 m_Projection;
@@ -62,13 +64,23 @@ out vec2 v_TexturePos;
 out vec2 v_TextureMin;
 out vec2 v_TextureDim;
 out vec4 v_BaseColor;
+out vec4 v_TintColor;
 
 void main() {
   Tile t_Tile = Tiles[int(f_VertexFrame + f_InstanceFrame)];
+  vec2 v_Tex = v_Texture;
+  if (t_Tile.texture.x < 0.0) {
+    // Packed rotated 90 degrees clockwise.
+    v_Tex = vec2(v_Texture.y, 1.0 - v_Texture.x);
+  } else if (t_Tile.texture.y < 0.0) {
+    // Packed rotated 90 degrees counter-clockwise.
+    v_Tex = vec2(1.0 - v_Texture.y, v_Texture.x);
+  }
   v_TextureMin = t_Tile.texture.zw;
-  v_TextureDim = t_Tile.texture.xy;
-  v_TexturePos = v_Texture * v_TextureDim;
+  v_TextureDim = abs(t_Tile.texture.xy);
+  v_TexturePos = v_Tex * v_TextureDim;
   v_BaseColor = v_Color;
+  v_TintColor = v_Tint;
   gl_Position = m_Projection * m_View * m_Model * vec4(v_Position, 1.0);
 }`
 
@@ -76,6 +88,7 @@ type renderInstance struct {
 	model mgl32.Mat4
 	frame float32
 	color mgl32.Vec4
+	tint  mgl32.Vec4
 }
 
 type Renderer struct {
@@ -90,6 +103,10 @@ type Renderer struct {
 }
 
 func NewRenderer(bufferSize int) (r *Renderer, err error) {
+	return newRenderer(bufferSize, VERTEX, FRAGMENT)
+}
+
+func newRenderer(bufferSize int, vertex, fragment string) (r *Renderer, err error) {
 	var (
 		instance       renderInstance
 		instanceStride = unsafe.Sizeof(instance)
@@ -100,7 +117,7 @@ func NewRenderer(bufferSize int) (r *Renderer, err error) {
 		buffer:     make([]renderInstance, bufferSize),
 		stride:     instanceStride,
 	}
-	if err = r.shader.Load(VERTEX, FRAGMENT); err != nil {
+	if err = r.shader.Load(vertex, fragment); err != nil {
 		return
 	}
 	r.shader.Bind()
@@ -110,6 +127,7 @@ func NewRenderer(bufferSize int) (r *Renderer, err error) {
 	r.shader.Attrib("f_InstanceFrame", instanceStride).Float(unsafe.Offsetof(instance.frame), 1)
 	r.shader.Attrib("m_Model", instanceStride).Mat4(unsafe.Offsetof(instance.model), 1)
 	r.shader.Attrib("v_Color", instanceStride).Vec4(unsafe.Offsetof(instance.color), 1)
+	r.shader.Attrib("v_Tint", instanceStride).Vec4(unsafe.Offsetof(instance.tint), 1)
 
 	r.textureData = r.shader.UniformBlock("TextureData", 1)
 
@@ -179,18 +197,36 @@ func (r *Renderer) Render(
 		instance *Instance
 		i        *renderInstance
 		index    int
+		paged    PagedSheet
+		isPaged  bool
+		page     int = -1
+		next     int
 	)
 	r.uView.Mat4(camera.View)
 	r.uProj.Mat4(camera.Projection)
 	r.registerGeometry(geometry)
 	r.registerTextureData(sheet)
+	if paged, isPaged = sheet.(PagedSheet); isPaged && paged.PageCount() < 2 {
+		isPaged = false
+	}
 	index = 0
 	instance = instances.Head()
 	for instance != nil {
+		if isPaged {
+			if next = paged.FramePage(instance.Frame); next != page {
+				if err = r.draw(geometry, index); err != nil {
+					return
+				}
+				index = 0
+				paged.BindPage(next)
+				page = next
+			}
+		}
 		i = &r.buffer[index]
 		i.frame = float32(instance.Frame)
 		i.model = instance.GetModel()
 		i.color = instance.Color()
+		i.tint = instance.Tint()
 		index++
 		instance = instance.Next()
 		if index >= r.bufferSize {
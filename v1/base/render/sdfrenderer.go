@@ -0,0 +1,107 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pikkpoiss/gamejam/v1/base/core"
+)
+
+// Textures hold signed distances in alpha, with 0.5 on the glyph outline and
+// larger values inside. The fill color is the instance tint.
+const SDF_FRAGMENT = `#version 150
+
+precision mediump float;
+
+in vec2 v_TexturePos;
+in vec2 v_TextureMin;
+in vec2 v_TextureDim;
+in vec4 v_BaseColor;
+in vec4 v_TintColor;
+uniform sampler2D u_Texture;
+uniform float u_Outline;
+uniform vec4 u_OutlineColor;
+uniform float u_Glow;
+uniform vec4 u_GlowColor;
+out vec4 v_FragData;
+
+void main() {
+  vec2 v_TexturePosition = v_TextureMin + mod(v_TexturePos, v_TextureDim);
+  float d = texture(u_Texture, v_TexturePosition).a;
+  float aa = max(fwidth(d), 0.0001);
+  float fill = smoothstep(0.5 - aa, 0.5 + aa, d);
+  float edge = 0.5 - u_Outline;
+  float shape = smoothstep(edge - aa, edge + aa, d);
+  float ring = max(shape - fill, 0.0) * u_OutlineColor.a;
+  vec4 color;
+  color.a = v_TintColor.a * fill + ring;
+  color.rgb = (v_TintColor.rgb * v_TintColor.a * fill + u_OutlineColor.rgb * ring) / max(color.a, 0.0001);
+  float glow = u_Glow > 0.0 ? smoothstep(edge - u_Glow, edge, d) * u_GlowColor.a : 0.0;
+  vec4 under = vec4(u_GlowColor.rgb, glow);
+  vec4 result = vec4(
+    mix(under.rgb, color.rgb, color.a),
+    color.a + under.a * (1.0 - color.a)
+  );
+  v_FragData = clamp(result + v_BaseColor, 0.0, 1.0);
+}`
+
+// Draws sheets of signed distance field glyphs, which stay sharp at any scale.
+type SDFRenderer struct {
+	*Renderer
+	uOutline      *core.Uniform
+	uOutlineColor *core.Uniform
+	uGlow         *core.Uniform
+	uGlowColor    *core.Uniform
+	outline       float32
+	outlineColor  mgl32.Vec4
+	glow          float32
+	glowColor     mgl32.Vec4
+}
+
+func NewSDFRenderer(bufferSize int) (r *SDFRenderer, err error) {
+	var renderer *Renderer
+	if renderer, err = newRenderer(bufferSize, VERTEX, SDF_FRAGMENT); err != nil {
+		return
+	}
+	r = &SDFRenderer{
+		Renderer:      renderer,
+		uOutline:      renderer.shader.Uniform("u_Outline"),
+		uOutlineColor: renderer.shader.Uniform("u_OutlineColor"),
+		uGlow:         renderer.shader.Uniform("u_Glow"),
+		uGlowColor:    renderer.shader.Uniform("u_GlowColor"),
+	}
+	return
+}
+
+// Widths are fractions of the field's spread, so 1 reaches as far from the
+// outline as the field encodes. Takes effect on the next Bind.
+func (r *SDFRenderer) SetOutline(width float32, color mgl32.Vec4) {
+	r.outline = width / 2
+	r.outlineColor = color
+}
+
+// Adds a soft halo beyond the outline, which fades out over width.
+func (r *SDFRenderer) SetGlow(width float32, color mgl32.Vec4) {
+	r.glow = width / 2
+	r.glowColor = color
+}
+
+func (r *SDFRenderer) Bind() {
+	r.Renderer.Bind()
+	r.uOutline.Float(r.outline)
+	r.uOutlineColor.Vec4(r.outlineColor)
+	r.uGlow.Float(r.glow)
+	r.uGlowColor.Vec4(r.glowColor)
+}
@@ -21,6 +21,17 @@ type UniformBufferSheet interface {
 	BufferID() uint32
 }
 
+// Implemented by sheets whose sprites are spread over several textures. The
+// renderer splits batches wherever the page changes and binds each page
+// before drawing. Per-vertex frames in a geometry must share the page of
+// the instance frame.
+type PagedSheet interface {
+	UniformBufferSheet
+	PageCount() int
+	FramePage(frame int) int
+	BindPage(page int)
+}
+
 type UniformSprite [4]float32
 
 func NewUniformSprite(texW, texH, texX, texY float32) UniformSprite {
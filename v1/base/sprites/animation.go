@@ -0,0 +1,125 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sprites
+
+import (
+	"time"
+)
+
+type AnimationDirection int
+
+const (
+	AnimationForward AnimationDirection = iota
+	AnimationReverse
+	AnimationPingPong
+	AnimationPingPongReverse
+)
+
+type AnimationFrame struct {
+	Key      string
+	Duration time.Duration
+}
+
+// An Animation is a named sequence of sprite keys in a Sheet, such as the
+// clips generated from Aseprite frame tags.
+type Animation struct {
+	Name      string
+	Direction AnimationDirection
+	Frames    []AnimationFrame
+}
+
+func NewAnimation(name string, direction AnimationDirection) *Animation {
+	return &Animation{
+		Name:      name,
+		Direction: direction,
+		Frames:    []AnimationFrame{},
+	}
+}
+
+func (a *Animation) AddFrame(key string, duration time.Duration) {
+	a.Frames = append(a.Frames, AnimationFrame{
+		Key:      key,
+		Duration: duration,
+	})
+}
+
+// Returns the frames of a single loop of the animation in playback order.
+// Ping-pong animations don't repeat the frames at either end.
+func (a *Animation) Sequence() (out []AnimationFrame) {
+	var (
+		count = len(a.Frames)
+		i     int
+	)
+	out = make([]AnimationFrame, 0, count*2)
+	switch a.Direction {
+	case AnimationReverse:
+		for i = count - 1; i >= 0; i-- {
+			out = append(out, a.Frames[i])
+		}
+	case AnimationPingPong:
+		out = append(out, a.Frames...)
+		for i = count - 2; i > 0; i-- {
+			out = append(out, a.Frames[i])
+		}
+	case AnimationPingPongReverse:
+		for i = count - 1; i >= 0; i-- {
+			out = append(out, a.Frames[i])
+		}
+		for i = 1; i < count-1; i++ {
+			out = append(out, a.Frames[i])
+		}
+	default:
+		out = append(out, a.Frames...)
+	}
+	return
+}
+
+// Returns the length of a single loop of the animation.
+func (a *Animation) Duration() (total time.Duration) {
+	for _, frame := range a.Sequence() {
+		total += frame.Duration
+	}
+	return
+}
+
+// Returns the frame which should be displayed after elapsed time, looping
+// the animation as many times as needed.
+func (a *Animation) FrameAt(elapsed time.Duration) (frame AnimationFrame) {
+	var (
+		sequence = a.Sequence()
+		total    time.Duration
+	)
+	if len(sequence) == 0 {
+		return
+	}
+	for _, frame = range sequence {
+		total += frame.Duration
+	}
+	if total <= 0 {
+		frame = sequence[0]
+		return
+	}
+	elapsed = elapsed % total
+	if elapsed < 0 {
+		elapsed += total
+	}
+	for _, frame = range sequence {
+		if elapsed < frame.Duration {
+			return
+		}
+		elapsed -= frame.Duration
+	}
+	return
+}
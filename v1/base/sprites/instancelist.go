@@ -47,7 +47,12 @@ func (l *SpriteInstanceList) SetFrame(instance *render.Instance, frame string) (
 	}
 	instance.Frame = s.Index()
 	instance.SetScale(s.WorldDimensions(l.pixelsPerUnit).Vec3(1.0))
+	instance.SetOffset(s.WorldOffset(l.pixelsPerUnit).Vec3(0.0))
 	instance.MarkChanged()
 	instance.Key = frame
 	return
 }
+
+func (l *SpriteInstanceList) Sheet() *Sheet {
+	return l.sheet
+}
@@ -0,0 +1,144 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sprites
+
+import (
+	"image"
+)
+
+// Tracks every maximal free rectangle, which may overlap, and places each
+// rectangle where it leaves the shortest leftover side (best short side fit).
+// See Jukka Jylänki, "A Thousand Ways to Pack the Bin".
+type maxRectsPacker struct {
+	free []image.Rectangle
+	w    int
+	h    int
+}
+
+func newMaxRectsPacker(w, h int) *maxRectsPacker {
+	return &maxRectsPacker{
+		free: []image.Rectangle{image.Rect(0, 0, w, h)},
+		w:    w,
+		h:    h,
+	}
+}
+
+func (p *maxRectsPacker) grow(w, h int) {
+	var (
+		oldW = p.w
+		oldH = p.h
+	)
+	// The new area is empty, so free rectangles touching the old edges can
+	// simply be extended into it.
+	for i := range p.free {
+		if p.free[i].Max.X == oldW {
+			p.free[i].Max.X = w
+		}
+		if p.free[i].Max.Y == oldH {
+			p.free[i].Max.Y = h
+		}
+	}
+	if w > oldW {
+		p.free = append(p.free, image.Rect(oldW, 0, w, h))
+	}
+	if h > oldH {
+		p.free = append(p.free, image.Rect(0, oldH, w, h))
+	}
+	p.w = w
+	p.h = h
+	p.prune()
+}
+
+func shortLongSides(a, b int) (short, long int) {
+	if a < b {
+		return a, b
+	}
+	return b, a
+}
+
+func (p *maxRectsPacker) insert(w, h int, rotate bool) (rect image.Rectangle, rotated bool, ok bool) {
+	var (
+		bestShort int
+		bestLong  int
+		short     int
+		long      int
+	)
+	for _, free := range p.free {
+		if free.Dx() >= w && free.Dy() >= h {
+			short, long = shortLongSides(free.Dx()-w, free.Dy()-h)
+			if !ok || short < bestShort || (short == bestShort && long < bestLong) {
+				rect = image.Rect(free.Min.X, free.Min.Y, free.Min.X+w, free.Min.Y+h)
+				bestShort, bestLong, rotated, ok = short, long, false, true
+			}
+		}
+		if rotate && w != h && free.Dx() >= h && free.Dy() >= w {
+			short, long = shortLongSides(free.Dx()-h, free.Dy()-w)
+			if !ok || short < bestShort || (short == bestShort && long < bestLong) {
+				rect = image.Rect(free.Min.X, free.Min.Y, free.Min.X+h, free.Min.Y+w)
+				bestShort, bestLong, rotated, ok = short, long, true, true
+			}
+		}
+	}
+	if ok {
+		p.place(rect)
+	}
+	return
+}
+
+func (p *maxRectsPacker) place(used image.Rectangle) {
+	var (
+		count = len(p.free)
+		free  image.Rectangle
+	)
+	for i := 0; i < count; {
+		free = p.free[i]
+		if !free.Overlaps(used) {
+			i++
+			continue
+		}
+		if used.Min.X > free.Min.X {
+			p.free = append(p.free, image.Rect(free.Min.X, free.Min.Y, used.Min.X, free.Max.Y))
+		}
+		if used.Max.X < free.Max.X {
+			p.free = append(p.free, image.Rect(used.Max.X, free.Min.Y, free.Max.X, free.Max.Y))
+		}
+		if used.Min.Y > free.Min.Y {
+			p.free = append(p.free, image.Rect(free.Min.X, free.Min.Y, free.Max.X, used.Min.Y))
+		}
+		if used.Max.Y < free.Max.Y {
+			p.free = append(p.free, image.Rect(free.Min.X, used.Max.Y, free.Max.X, free.Max.Y))
+		}
+		p.free = append(p.free[:i], p.free[i+1:]...)
+		count--
+	}
+	p.prune()
+}
+
+// Removes free rectangles contained in other free rectangles.
+func (p *maxRectsPacker) prune() {
+	for i := 0; i < len(p.free); i++ {
+		for j := i + 1; j < len(p.free); j++ {
+			if p.free[i].In(p.free[j]) {
+				p.free = append(p.free[:i], p.free[i+1:]...)
+				i--
+				break
+			}
+			if p.free[j].In(p.free[i]) {
+				p.free = append(p.free[:j], p.free[j+1:]...)
+				j--
+			}
+		}
+	}
+}
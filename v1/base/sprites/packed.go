@@ -18,25 +18,75 @@ import (
 	"fmt"
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/golang/glog"
+	"github.com/pikkpoiss/gamejam/v1/base/core"
 	"image"
 	"image/draw"
 )
 
+var ErrPackedSheetFull = fmt.Errorf("Cannot fit image into texture")
+
+// Where a sprite was packed. rect includes padding and extrusion.
+type packedRegion struct {
+	rect    image.Rectangle
+	area    int
+	rotated bool
+	refs    int
+}
+
 type PackedSheet struct {
 	*Sheet
-	Width   int
-	Height  int
-	img     draw.Image
-	shelves []*packingShelf
+	Width    int
+	Height   int
+	img      draw.Image
+	packer   packer
+	cfg      PackingConfig
+	usedArea int
+	rotated  int
+	regions  map[string]*packedRegion
+	free     []image.Rectangle // Regions of released sprites.
+	dirty    image.Rectangle   // Changed since the last UploadTexture.
 }
 
 func NewPackedSheet(w, h int) (i *PackedSheet) {
+	return NewPackedSheetWithConfig(PackingConfig{
+		Width:    w,
+		Height:   h,
+		Strategy: PackingShelf,
+	})
+}
+
+func NewPackedSheetWithConfig(cfg PackingConfig) (i *PackedSheet) {
 	return &PackedSheet{
-		Width:   w,
-		Height:  h,
-		img:     image.NewRGBA(image.Rect(0, 0, w, h)),
-		shelves: []*packingShelf{newShelf()},
+		Width:   cfg.Width,
+		Height:  cfg.Height,
+		img:     image.NewRGBA(image.Rect(0, 0, cfg.Width, cfg.Height)),
+		packer:  newPacker(cfg.Strategy, cfg.Width, cfg.Height),
+		cfg:     cfg,
 		Sheet:   NewSheet(),
+		regions: map[string]*packedRegion{},
+	}
+}
+
+// Sets the number of empty pixels left between packed images and the number
+// of pixels each image's edges are repeated outwards. Both prevent texture
+// bleeding when sampling with linear filtering. Only affects images packed
+// after the call.
+func (s *PackedSheet) SetPadding(padding, extrude int) {
+	s.cfg.Padding = padding
+	s.cfg.Extrude = extrude
+}
+
+func (s *PackedSheet) Config() PackingConfig {
+	return s.cfg
+}
+
+func (s *PackedSheet) Stats() PackingStats {
+	return PackingStats{
+		Strategy:  s.cfg.Strategy,
+		Sprites:   s.Len(),
+		Rotated:   s.rotated,
+		UsedArea:  s.usedArea,
+		TotalArea: s.Width * s.Height,
 	}
 }
 
@@ -44,8 +94,189 @@ func (s *PackedSheet) Image() image.Image {
 	return s.img
 }
 
+// Packs img under key. Packing a key which is already in the sheet just adds a
+// reference to it, which must be matched by a call to Release.
 func (s *PackedSheet) Pack(key string, img image.Image) (err error) {
-	return s.packSprite(key, img, img.Bounds())
+	var (
+		bounds = img.Bounds()
+		data   = NewSpriteData(
+			mgl32.Vec2{float32(bounds.Dx()), float32(bounds.Dy())},
+			mgl32.Vec2{},
+		)
+		trimmed image.Rectangle
+	)
+	if s.cfg.Trim {
+		trimmed = trimBounds(img)
+		data.TrimOffset = mgl32.Vec2{
+			float32(trimmed.Min.X - bounds.Min.X),
+			float32(trimmed.Min.Y - bounds.Min.Y),
+		}
+		bounds = trimmed
+	}
+	return s.packSprite(key, img, bounds, data)
+}
+
+// Returns the smallest rectangle containing every visible pixel of img. Fully
+// transparent images are trimmed to a single pixel.
+func trimBounds(img image.Image) (out image.Rectangle) {
+	var (
+		bounds = img.Bounds()
+		x, y   int
+		found  bool
+	)
+	for y = bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x = bounds.Min.X; x < bounds.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a == 0 {
+				continue
+			}
+			if !found {
+				out = image.Rect(x, y, x+1, y+1)
+				found = true
+			} else {
+				out = out.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if !found {
+		out = image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Min.X+1, bounds.Min.Y+1)
+	}
+	return
+}
+
+// Adds a reference to key, returning false if it isn't in the sheet.
+func (s *PackedSheet) Retain(key string) bool {
+	var region, exists = s.regions[key]
+	if exists {
+		region.refs++
+	}
+	return exists
+}
+
+// Removes a reference to key. When none are left the sprite is removed and its
+// space is reused by later images.
+func (s *PackedSheet) Release(key string) (err error) {
+	var region, exists = s.regions[key]
+	if !exists {
+		err = fmt.Errorf("Invalid tile key %v", key)
+		return
+	}
+	if region.refs--; region.refs > 0 {
+		return
+	}
+	return s.Remove(key)
+}
+
+// Removes key regardless of how many references it has.
+func (s *PackedSheet) Remove(key string) (err error) {
+	var region, exists = s.regions[key]
+	if !exists {
+		err = fmt.Errorf("Invalid tile key %v", key)
+		return
+	}
+	if err = s.RemoveSprite(key); err != nil {
+		return
+	}
+	delete(s.regions, key)
+	s.usedArea -= region.area
+	if region.rotated {
+		s.rotated--
+	}
+	s.free = append(s.free, region.rect)
+	s.mergeFree()
+	return
+}
+
+// Returns the number of references to key.
+func (s *PackedSheet) Refs(key string) int {
+	if region, exists := s.regions[key]; exists {
+		return region.refs
+	}
+	return 0
+}
+
+// Joins free regions which share a whole edge.
+func (s *PackedSheet) mergeFree() {
+	var (
+		a, b   image.Rectangle
+		merged = true
+	)
+	for merged {
+		merged = false
+		for i := 0; i < len(s.free) && !merged; i++ {
+			for j := i + 1; j < len(s.free); j++ {
+				a, b = s.free[i], s.free[j]
+				if (a.Min.Y == b.Min.Y && a.Max.Y == b.Max.Y && (a.Max.X == b.Min.X || b.Max.X == a.Min.X)) ||
+					(a.Min.X == b.Min.X && a.Max.X == b.Max.X && (a.Max.Y == b.Min.Y || b.Max.Y == a.Min.Y)) {
+					s.free[i] = a.Union(b)
+					s.free = append(s.free[:j], s.free[j+1:]...)
+					merged = true
+					break
+				}
+			}
+		}
+	}
+}
+
+// Finds the free region leaving the shortest leftover side for a w by h
+// rectangle and splits off the unused space.
+func (s *PackedSheet) allocateFree(w, h int, rotate bool) (rect image.Rectangle, rotated bool, ok bool) {
+	var (
+		best      = -1
+		bestShort int
+		short     int
+		free      image.Rectangle
+	)
+	for i, f := range s.free {
+		if f.Dx() >= w && f.Dy() >= h {
+			if short, _ = shortLongSides(f.Dx()-w, f.Dy()-h); best == -1 || short < bestShort {
+				best, bestShort, rotated = i, short, false
+			}
+		}
+		if rotate && w != h && f.Dx() >= h && f.Dy() >= w {
+			if short, _ = shortLongSides(f.Dx()-h, f.Dy()-w); best == -1 || short < bestShort {
+				best, bestShort, rotated = i, short, true
+			}
+		}
+	}
+	if best == -1 {
+		return
+	}
+	if rotated {
+		w, h = h, w
+	}
+	free = s.free[best]
+	rect = image.Rect(free.Min.X, free.Min.Y, free.Min.X+w, free.Min.Y+h)
+	s.free = append(s.free[:best], s.free[best+1:]...)
+	if rect.Max.X < free.Max.X {
+		s.free = append(s.free, image.Rect(rect.Max.X, free.Min.Y, free.Max.X, rect.Max.Y))
+	}
+	if rect.Max.Y < free.Max.Y {
+		s.free = append(s.free, image.Rect(free.Min.X, rect.Max.Y, free.Max.X, free.Max.Y))
+	}
+	ok = true
+	return
+}
+
+// Uploads the sheet image to its texture. The texture is created on the first
+// call or after the sheet grows, and otherwise only the region changed since
+// the last call is updated.
+func (s *PackedSheet) UploadTexture(smoothing core.TextureSmoothing) (err error) {
+	var texture = s.Texture()
+	if texture == nil || int(texture.OriginalSize.X()) != s.Width || int(texture.OriginalSize.Y()) != s.Height {
+		if texture, err = core.GetTexture(s.img, smoothing); err != nil {
+			return
+		}
+		s.SetTexture(texture)
+	} else if !s.dirty.Empty() {
+		if glog.V(2) {
+			glog.Infof("Updating texture region %v", s.dirty)
+		}
+		if err = texture.Update(s.img, s.dirty); err != nil {
+			return
+		}
+	}
+	s.dirty = image.Rectangle{}
+	return
 }
 
 func (s *PackedSheet) Copy(key string, src *PackedSheet) (err error) {
@@ -56,55 +287,151 @@ func (s *PackedSheet) Copy(key string, src *PackedSheet) (err error) {
 	if sprite, err = src.Sprite(key); err != nil {
 		return
 	}
+	if sprite.Rotated() {
+		var img = unrotateImage(src.img, sprite.ImageBounds())
+		return s.packSprite(key, img, img.Bounds(), sprite.Data())
+	}
 	bounds = sprite.ImageBounds()
-	return s.packSprite(key, src.img, bounds)
+	return s.packSprite(key, src.img, bounds, sprite.Data())
 }
 
-func (s *PackedSheet) packSprite(key string, src image.Image, srcBounds image.Rectangle) (err error) {
+// Doubles the shorter side of the sheet, up to the configured maximum size.
+func (s *PackedSheet) grow() bool {
 	var (
-		j         int
-		shelf     *packingShelf
-		score     int
-		bestScore int             = -1
-		bestShelf int             = -1
-		texBounds image.Rectangle = s.img.Bounds()
-		w         int             = srcBounds.Max.X - srcBounds.Min.X
-		h         int             = srcBounds.Max.Y - srcBounds.Min.Y
-		maxW      int             = texBounds.Max.X
+		w   = s.Width
+		h   = s.Height
+		img *image.RGBA
 	)
-	if s.Exists(key) {
+	if w <= h && w*2 <= s.cfg.MaxWidth {
+		w *= 2
+	} else if h*2 <= s.cfg.MaxHeight {
+		h *= 2
+	} else if w*2 <= s.cfg.MaxWidth {
+		w *= 2
+	} else {
+		return false
+	}
+	if glog.V(1) {
+		glog.Infof("Growing packed sheet to %vx%v", w, h)
+	}
+	img = image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, s.img.Bounds(), s.img, image.ZP, draw.Src)
+	s.img = img
+	s.packer.grow(w, h)
+	s.Width = w
+	s.Height = h
+	return true
+}
+
+// Packs srcBounds of src. The source size, trim offset and pivot are taken
+// from data, and the rest is filled in according to where the image lands.
+func (s *PackedSheet) packSprite(key string, src image.Image, srcBounds image.Rectangle, data SpriteData) (err error) {
+	var (
+		spriteW = srcBounds.Dx()
+		spriteH = srcBounds.Dy()
+		border  = 2*s.cfg.Extrude + s.cfg.Padding
+		rect    image.Rectangle
+		rotated bool
+		ok      bool
+	)
+	if s.Retain(key) {
 		// Don't need to pack since it's already in here
 		return
 	}
-	for j, shelf = range s.shelves {
-		if shelf.CanAdd(w, h, maxW) {
-			score = shelf.BestAreaFit(w, h, maxW)
-			if score > bestScore {
-				bestScore = score
-				bestShelf = j
-			}
+	for {
+		if rect, rotated, ok = s.allocateFree(
+			spriteW+border,
+			spriteH+border,
+			s.cfg.AllowRotation,
+		); ok {
+			break
 		}
-	}
-	if bestShelf == -1 {
-		shelf = s.shelves[len(s.shelves)-1]
-		if shelf.y+shelf.height+h > texBounds.Max.Y {
-			// New packingShelf would exceed current image size
-			err = fmt.Errorf("Cannot fit text into texture")
+		if rect, rotated, ok = s.packer.insert(
+			spriteW+border,
+			spriteH+border,
+			s.cfg.AllowRotation,
+		); ok {
+			break
+		}
+		if !s.grow() {
+			err = ErrPackedSheetFull
 			return
 		}
-		s.shelves = append(s.shelves, s.shelves[len(s.shelves)-1].Close())
-		bestShelf = len(s.shelves) - 1
 	}
-	shelf = s.shelves[bestShelf]
+	// Clear whatever a released sprite left in the padding.
+	draw.Draw(s.img, rect, image.Transparent, image.ZP, draw.Src)
 	var (
-		x, y     = shelf.Add(w, h)
-		destPt   = image.Pt(x, y)
-		destRect = image.Rectangle{destPt, destPt.Add(image.Pt(w, h))}
+		destPt   = rect.Min.Add(image.Pt(s.cfg.Extrude, s.cfg.Extrude))
+		destRect = image.Rectangle{destPt, destPt.Add(image.Pt(spriteW, spriteH))}
 	)
-	s.AddSprite(key, mgl32.Vec2{float32(w), float32(h)}, mgl32.Vec2{float32(x), float32(y)})
+	if rotated {
+		destRect.Max = destPt.Add(image.Pt(spriteH, spriteW))
+		rotateImage(s.img, destRect, src, srcBounds)
+		s.rotated++
+	} else {
+		draw.Draw(s.img, destRect, src, srcBounds.Min, draw.Src)
+	}
+	data.Bounds = mgl32.Vec2{float32(spriteW), float32(spriteH)}
+	data.Offset = mgl32.Vec2{float32(destPt.X), float32(destPt.Y)}
+	data.Rotated = rotated
+	data.RotatedCCW = false
+	data.Page = 0
+	s.AddSpriteData(key, data)
+	s.regions[key] = &packedRegion{
+		rect:    rect,
+		area:    spriteW * spriteH,
+		rotated: rotated,
+		refs:    1,
+	}
+	s.usedArea += spriteW * spriteH
+	s.dirty = s.dirty.Union(rect.Intersect(s.img.Bounds()))
 	if glog.V(2) {
-		glog.Infof("packRegion(%v): dest %v src %v", key, destRect, srcBounds.Min)
+		glog.Infof("packRegion(%v): dest %v src %v rotated %v", key, destRect, srcBounds.Min, rotated)
+	}
+	s.extrudeEdges(destRect)
+	return
+}
+
+// Draws src rotated 90 degrees clockwise into dest, which must be srcBounds
+// with width and height swapped.
+func rotateImage(dst draw.Image, dest image.Rectangle, src image.Image, srcBounds image.Rectangle) {
+	var (
+		x, y int
+		h    = srcBounds.Dy()
+	)
+	for y = 0; y < srcBounds.Dy(); y++ {
+		for x = 0; x < srcBounds.Dx(); x++ {
+			dst.Set(dest.Min.X+h-1-y, dest.Min.Y+x, src.At(srcBounds.Min.X+x, srcBounds.Min.Y+y))
+		}
+	}
+}
+
+// Returns a copy of a region packed by rotateImage in its original
+// orientation.
+func unrotateImage(src image.Image, packed image.Rectangle) (out *image.RGBA) {
+	var (
+		x, y int
+		w    = packed.Dy()
+		h    = packed.Dx()
+	)
+	out = image.NewRGBA(image.Rect(0, 0, w, h))
+	for y = 0; y < h; y++ {
+		for x = 0; x < w; x++ {
+			out.Set(x, y, src.At(packed.Min.X+h-1-y, packed.Min.Y+x))
+		}
 	}
-	draw.Draw(s.img, destRect, src, srcBounds.Min, draw.Src)
 	return
 }
+
+// Repeats the outermost pixels of rect into the extrusion border around it.
+func (s *PackedSheet) extrudeEdges(rect image.Rectangle) {
+	var i int
+	for i = 1; i <= s.cfg.Extrude; i++ {
+		draw.Draw(s.img, image.Rect(rect.Min.X-i, rect.Min.Y, rect.Min.X-i+1, rect.Max.Y), s.img, rect.Min, draw.Src)
+		draw.Draw(s.img, image.Rect(rect.Max.X+i-1, rect.Min.Y, rect.Max.X+i, rect.Max.Y), s.img, image.Pt(rect.Max.X-1, rect.Min.Y), draw.Src)
+	}
+	for i = 1; i <= s.cfg.Extrude; i++ {
+		draw.Draw(s.img, image.Rect(rect.Min.X-s.cfg.Extrude, rect.Min.Y-i, rect.Max.X+s.cfg.Extrude, rect.Min.Y-i+1), s.img, image.Pt(rect.Min.X-s.cfg.Extrude, rect.Min.Y), draw.Src)
+		draw.Draw(s.img, image.Rect(rect.Min.X-s.cfg.Extrude, rect.Max.Y+i-1, rect.Max.X+s.cfg.Extrude, rect.Max.Y+i), s.img, image.Pt(rect.Min.X-s.cfg.Extrude, rect.Max.Y-1), draw.Src)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sprites
+
+import (
+	"fmt"
+	"image"
+)
+
+type PackingStrategy int
+
+const (
+	PackingShelf PackingStrategy = iota
+	PackingSkyline
+	PackingMaxRects
+)
+
+var PackingStrategies = []PackingStrategy{
+	PackingShelf,
+	PackingSkyline,
+	PackingMaxRects,
+}
+
+func (s PackingStrategy) String() string {
+	switch s {
+	case PackingShelf:
+		return "shelf"
+	case PackingSkyline:
+		return "skyline"
+	case PackingMaxRects:
+		return "maxrects"
+	}
+	return fmt.Sprintf("PackingStrategy(%d)", int(s))
+}
+
+func ParsePackingStrategy(name string) (s PackingStrategy, err error) {
+	for _, s = range PackingStrategies {
+		if s.String() == name {
+			return
+		}
+	}
+	err = fmt.Errorf("Unknown packing strategy %v", name)
+	return
+}
+
+type PackingConfig struct {
+	Width         int // Initial size of the sheet.
+	Height        int
+	MaxWidth      int // The sheet grows in powers of two up to this size.
+	MaxHeight     int
+	Strategy      PackingStrategy
+	AllowRotation bool // Images may be packed rotated 90 degrees clockwise.
+	Padding       int  // Empty pixels between images.
+	Extrude       int  // Pixels of repeated edge around each image.
+	Trim          bool // Transparent borders are cropped from images.
+}
+
+type PackingStats struct {
+	Strategy  PackingStrategy
+	Sprites   int
+	Rotated   int
+	UsedArea  int // Pixels covered by images, excluding padding and extrusion.
+	TotalArea int
+}
+
+func (s PackingStats) Efficiency() float64 {
+	if s.TotalArea == 0 {
+		return 0
+	}
+	return float64(s.UsedArea) / float64(s.TotalArea)
+}
+
+func (s PackingStats) String() string {
+	return fmt.Sprintf(
+		"%v: %v sprites (%v rotated), %.1f%% of %v pixels used",
+		s.Strategy,
+		s.Sprites,
+		s.Rotated,
+		100*s.Efficiency(),
+		s.TotalArea,
+	)
+}
+
+// Allocates rectangles within a sheet.
+type packer interface {
+	// Finds space for a w by h rectangle. If rotate is set the packer may
+	// return an h by w rectangle instead, reporting rotated.
+	insert(w, h int, rotate bool) (rect image.Rectangle, rotated bool, ok bool)
+	// Extends the packing area, which never shrinks.
+	grow(w, h int)
+}
+
+func newPacker(strategy PackingStrategy, w, h int) packer {
+	switch strategy {
+	case PackingSkyline:
+		return newSkylinePacker(w, h)
+	case PackingMaxRects:
+		return newMaxRectsPacker(w, h)
+	}
+	return newShelfPacker(w, h)
+}
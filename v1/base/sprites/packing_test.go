@@ -0,0 +1,162 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sprites
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+// Inserts random rectangles until the packer is full, checking that each
+// lands inside the area with the right size and without overlapping another.
+func fillPacker(t *testing.T, p packer, random *rand.Rand, w, h int, rotate bool) (placed []image.Rectangle) {
+	var bounds = image.Rect(0, 0, w, h)
+	for {
+		var (
+			rw, rh            = 1 + random.Intn(40), 1 + random.Intn(40)
+			rect, rotated, ok = p.insert(rw, rh, rotate)
+		)
+		if !ok {
+			return
+		}
+		if rotated && !rotate {
+			t.Fatalf("Rotated %vx%v without being allowed", rw, rh)
+		}
+		if rotated {
+			rw, rh = rh, rw
+		}
+		if rect.Dx() != rw || rect.Dy() != rh {
+			t.Fatalf("Inserted %vx%v as %v", rw, rh, rect)
+		}
+		if !rect.In(bounds) {
+			t.Fatalf("%v is outside %v", rect, bounds)
+		}
+		for _, other := range placed {
+			if rect.Overlaps(other) {
+				t.Fatalf("%v overlaps %v", rect, other)
+			}
+		}
+		placed = append(placed, rect)
+	}
+}
+
+func TestPackers(t *testing.T) {
+	for _, strategy := range PackingStrategies {
+		for _, rotate := range []bool{false, true} {
+			var (
+				random = rand.New(rand.NewSource(1))
+				p      = newPacker(strategy, 128, 128)
+				before = fillPacker(t, p, random, 128, 128, rotate)
+			)
+			if len(before) == 0 {
+				t.Fatalf("%v: nothing fit", strategy)
+			}
+			p.grow(256, 256)
+			if after := fillPacker(t, p, random, 256, 256, rotate); len(after) == 0 {
+				t.Errorf("%v: nothing fit after growing", strategy)
+			}
+		}
+	}
+}
+
+func TestPackedSheetRotation(t *testing.T) {
+	var (
+		sheet = NewPackedSheetWithConfig(PackingConfig{
+			Width:         2,
+			Height:        4,
+			Strategy:      PackingMaxRects,
+			AllowRotation: true,
+		})
+		img = image.NewRGBA(image.Rect(0, 0, 3, 2))
+	)
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 80), uint8(y * 80), 0, 255})
+		}
+	}
+	if err := sheet.Pack("wide", img); err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	sprite, err := sheet.Sprite("wide")
+	if err != nil {
+		t.Fatalf("Sprite: %v", err)
+	}
+	if !sprite.Rotated() {
+		t.Fatalf("3x2 image packed into a 2x4 sheet without rotating")
+	}
+	var out = unrotateImage(sheet.Image(), sprite.ImageBounds())
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			if got, want := out.RGBAAt(x, y), img.RGBAAt(x, y); got != want {
+				t.Errorf("Pixel %v,%v = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestPackedSheetGrows(t *testing.T) {
+	var (
+		sheet = NewPackedSheetWithConfig(PackingConfig{
+			Width:     16,
+			Height:    16,
+			MaxWidth:  32,
+			MaxHeight: 32,
+		})
+		img = image.NewRGBA(image.Rect(0, 0, 20, 20))
+	)
+	if err := sheet.Pack("big", img); err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if sheet.Width != 32 || sheet.Height != 32 {
+		t.Errorf("Sheet is %vx%v, want 32x32", sheet.Width, sheet.Height)
+	}
+	if err := sheet.Pack("bigger", image.NewRGBA(image.Rect(0, 0, 40, 1))); err != ErrPackedSheetFull {
+		t.Errorf("Packing past the maximum size returned %v", err)
+	}
+}
+
+func TestPackedSheetExtrude(t *testing.T) {
+	var (
+		sheet = NewPackedSheetWithConfig(PackingConfig{
+			Width:   8,
+			Height:  8,
+			Extrude: 1,
+		})
+		red = color.RGBA{255, 0, 0, 255}
+		img = image.NewRGBA(image.Rect(0, 0, 1, 1))
+	)
+	img.SetRGBA(0, 0, red)
+	if err := sheet.Pack("dot", img); err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	sprite, err := sheet.Sprite("dot")
+	if err != nil {
+		t.Fatalf("Sprite: %v", err)
+	}
+	var (
+		at         = sprite.ImageBounds().Min
+		sheetImage = sheet.Image().(*image.RGBA)
+	)
+	for _, d := range []image.Point{{-1, -1}, {0, -1}, {1, 0}, {1, 1}, {0, 0}} {
+		if got := sheetImage.RGBAAt(at.X+d.X, at.Y+d.Y); got != red {
+			t.Errorf("Pixel %v from the sprite = %v, want %v", d, got, red)
+		}
+	}
+	if got := sheetImage.RGBAAt(at.X+2, at.Y); got != (color.RGBA{}) {
+		t.Errorf("Pixel past the extrusion = %v, want transparent", got)
+	}
+}
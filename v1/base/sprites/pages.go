@@ -0,0 +1,57 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sprites
+
+import (
+	"fmt"
+	"image"
+	"sort"
+)
+
+type PackImage struct {
+	Key   string
+	Image image.Image
+}
+
+// Packs images into as many sheets as needed, starting a new sheet whenever
+// the current one is full. Images are packed tallest first, which suits all
+// of the packing strategies.
+func PackPages(images []PackImage, cfg PackingConfig) (pages []*PackedSheet, err error) {
+	var page *PackedSheet
+	images = append([]PackImage(nil), images...)
+	sort.SliceStable(images, func(i, j int) bool {
+		var hi, hj = images[i].Image.Bounds().Dy(), images[j].Image.Bounds().Dy()
+		if hi != hj {
+			return hi > hj
+		}
+		return images[i].Key < images[j].Key
+	})
+	for _, entry := range images {
+		if page != nil {
+			if err = page.Pack(entry.Key, entry.Image); err == nil {
+				continue
+			} else if err != ErrPackedSheetFull {
+				return
+			}
+		}
+		page = NewPackedSheetWithConfig(cfg)
+		pages = append(pages, page)
+		if err = page.Pack(entry.Key, entry.Image); err != nil {
+			err = fmt.Errorf("Cannot fit %v into a %vx%v page", entry.Key, page.Width, page.Height)
+			return
+		}
+	}
+	return
+}
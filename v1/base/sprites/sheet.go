@@ -19,12 +19,16 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/pikkpoiss/gamejam/v1/base/core"
 	"github.com/pikkpoiss/gamejam/v1/base/render"
+	"sort"
 	"unsafe"
 )
 
 type Sheet struct {
 	keys            map[string]*Sprite
-	texture         *core.Texture
+	animations      map[string]*Animation
+	pages           []*core.Texture
+	framePages      []int
+	freeIndices     []int
 	ubo             *core.UniformBuffer
 	Count           int
 	version         int
@@ -34,35 +38,76 @@ type Sheet struct {
 func NewSheet() *Sheet {
 	return &Sheet{
 		keys:            map[string]*Sprite{},
+		animations:      map[string]*Animation{},
 		version:         0,
 		uploadedVersion: -1,
-		ubo:             core.NewUniformBuffer(),
 	}
 }
 
 func (s *Sheet) SetTexture(texture *core.Texture) {
-	s.deleteTexture()
-	s.texture = texture
+	s.SetPageTexture(0, texture)
 }
 
-func (s *Sheet) Bind() {
-	if s.texture != nil {
-		s.texture.Bind()
+// Sets the texture for one page of a sheet whose sprites are spread across
+// several textures, such as a TexturePacker multipack export.
+func (s *Sheet) SetPageTexture(page int, texture *core.Texture) {
+	for len(s.pages) <= page {
+		s.pages = append(s.pages, nil)
+	}
+	var old = s.pages[page]
+	if old != nil && old != texture {
+		old.Delete()
+	}
+	s.pages[page] = texture
+	if old == nil || texture == nil || old.Size != texture.Size {
+		// Sprite texture coordinates depend on the page size.
+		s.version++
+	}
+}
+
+func (s *Sheet) PageCount() int {
+	return len(s.pages)
+}
+
+func (s *Sheet) PageTexture(page int) *core.Texture {
+	if page < 0 || page >= len(s.pages) {
+		return nil
+	}
+	return s.pages[page]
+}
+
+// Returns the page holding the sprite with the given index.
+func (s *Sheet) FramePage(frame int) int {
+	if frame < 0 || frame >= len(s.framePages) {
+		return 0
 	}
+	return s.framePages[frame]
+}
+
+func (s *Sheet) Bind() {
+	s.BindPage(0)
 	s.upload()
 }
 
+func (s *Sheet) BindPage(page int) {
+	if texture := s.PageTexture(page); texture != nil {
+		texture.Bind()
+	}
+}
+
 func (s *Sheet) Unbind() {
-	if s.texture != nil {
-		s.texture.Unbind()
+	if texture := s.PageTexture(0); texture != nil {
+		texture.Unbind()
 	}
 }
 
 func (s *Sheet) deleteTexture() {
-	if s.texture != nil {
-		s.texture.Delete()
-		s.texture = nil
+	for _, texture := range s.pages {
+		if texture != nil {
+			texture.Delete()
+		}
 	}
+	s.pages = nil
 }
 
 func (s *Sheet) Delete() {
@@ -70,28 +115,75 @@ func (s *Sheet) Delete() {
 	if s.ubo != nil {
 		s.ubo.Delete()
 		s.ubo = nil
+		s.uploadedVersion = -1
 	}
 }
 
 func (s *Sheet) AddSprite(key string, bounds, offset mgl32.Vec2) (out *Sprite) {
+	return s.AddSpriteData(key, NewSpriteData(bounds, offset))
+}
+
+func (s *Sheet) AddSpriteData(key string, data SpriteData) (out *Sprite) {
 	var index int
-	index = s.Count
+	if n := len(s.freeIndices); n > 0 {
+		index = s.freeIndices[n-1]
+		s.freeIndices = s.freeIndices[:n-1]
+		s.framePages[index] = data.Page
+	} else {
+		index = s.Count
+		s.framePages = append(s.framePages, data.Page)
+		s.Count++
+	}
 	out = &Sprite{
-		index:  index,
-		bounds: bounds,
-		offset: offset,
+		index:      index,
+		bounds:     data.Bounds,
+		offset:     data.Offset,
+		rotated:    data.Rotated,
+		rotatedCCW: data.RotatedCCW,
+		sourceSize: data.SourceSize,
+		trimOffset: data.TrimOffset,
+		pivot:      data.Pivot,
+		page:       data.Page,
 	}
 	s.keys[key] = out
-	s.Count++
 	s.version++
 	return
 }
 
+// Removes a sprite. Its index is given to the next sprite added, so any
+// instance still showing it will show that sprite instead.
+func (s *Sheet) RemoveSprite(key string) (err error) {
+	var sprite *Sprite
+	if sprite, err = s.Sprite(key); err != nil {
+		return
+	}
+	delete(s.keys, key)
+	s.freeIndices = append(s.freeIndices, sprite.index)
+	s.version++
+	return
+}
+
+// Returns the number of sprites in the sheet. Count also includes the
+// indices of removed sprites which have not been reused yet.
+func (s *Sheet) Len() int {
+	return len(s.keys)
+}
+
 func (s *Sheet) Exists(key string) (exists bool) {
 	_, exists = s.keys[key]
 	return
 }
 
+// Returns the keys of all sprites in the sheet, sorted.
+func (s *Sheet) Keys() (keys []string) {
+	keys = make([]string, 0, len(s.keys))
+	for key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return
+}
+
 func (s *Sheet) Sprite(key string) (out *Sprite, err error) {
 	var exists bool
 	if out, exists = s.keys[key]; !exists {
@@ -101,22 +193,46 @@ func (s *Sheet) Sprite(key string) (out *Sprite, err error) {
 	return
 }
 
+func (s *Sheet) AddAnimation(animation *Animation) (err error) {
+	for _, frame := range animation.Frames {
+		if !s.Exists(frame.Key) {
+			err = fmt.Errorf("Animation %v references invalid tile key %v", animation.Name, frame.Key)
+			return
+		}
+	}
+	s.animations[animation.Name] = animation
+	return
+}
+
+func (s *Sheet) Animation(name string) (out *Animation, err error) {
+	var exists bool
+	if out, exists = s.animations[name]; !exists {
+		err = fmt.Errorf("Invalid animation name %v", name)
+		return
+	}
+	return
+}
+
 func (s *Sheet) upload() (err error) {
 	if s.version == s.uploadedVersion {
 		return
 	}
 	var (
-		sprite *Sprite
-		entry  render.UniformSprite
-		data   = make([]render.UniformSprite, s.Count)
-		size   = s.Count * int(unsafe.Sizeof(entry))
+		sprite  *Sprite
+		texture *core.Texture
+		entry   render.UniformSprite
+		data    = make([]render.UniformSprite, s.Count)
+		size    = s.Count * int(unsafe.Sizeof(entry))
 	)
-	if s.texture == nil {
-		err = fmt.Errorf("No texture associated with sheet")
-		return
-	}
 	for _, sprite = range s.keys {
-		data[sprite.index] = sprite.textureBounds(s.texture.Size)
+		if texture = s.PageTexture(sprite.page); texture == nil {
+			err = fmt.Errorf("No texture associated with sheet page %v", sprite.page)
+			return
+		}
+		data[sprite.index] = sprite.textureBounds(texture.Size)
+	}
+	if s.ubo == nil {
+		s.ubo = core.NewUniformBuffer()
 	}
 	s.ubo.Upload(data, size)
 	s.uploadedVersion = s.version
@@ -124,13 +240,19 @@ func (s *Sheet) upload() (err error) {
 }
 
 func (s *Sheet) Texture() *core.Texture {
-	return s.texture
+	return s.PageTexture(0)
 }
 
 func (s *Sheet) BufferID() uint32 {
+	if s.ubo == nil {
+		return 0
+	}
 	return s.ubo.BufferID()
 }
 
 func (s *Sheet) Size() int {
+	if s.ubo == nil {
+		return 0
+	}
 	return s.ubo.Size()
 }
@@ -14,6 +14,10 @@
 
 package sprites
 
+import (
+	"image"
+)
+
 type packingShelf struct {
 	x      int
 	y      int
@@ -72,7 +76,73 @@ func (s *packingShelf) Close() (out *packingShelf) {
 func (s *packingShelf) BestAreaFit(w, h, maxW int) int {
 	var (
 		packingShelfArea = s.RemainingX(maxW) * s.height
-		wordArea  = w * h
+		wordArea         = w * h
 	)
 	return packingShelfArea - wordArea
 }
+
+// Packs rectangles into rows, each as tall as the tallest rectangle in it.
+type shelfPacker struct {
+	shelves []*packingShelf
+	w       int
+	h       int
+}
+
+func newShelfPacker(w, h int) *shelfPacker {
+	return &shelfPacker{
+		shelves: []*packingShelf{newShelf()},
+		w:       w,
+		h:       h,
+	}
+}
+
+func (p *shelfPacker) grow(w, h int) {
+	p.w = w
+	p.h = h
+}
+
+func (p *shelfPacker) insert(w, h int, rotate bool) (rect image.Rectangle, rotated bool, ok bool) {
+	if rect, ok = p.insertOriented(w, h); ok {
+		return
+	}
+	if rotate && w != h {
+		rect, ok = p.insertOriented(h, w)
+		rotated = ok
+	}
+	return
+}
+
+func (p *shelfPacker) insertOriented(w, h int) (rect image.Rectangle, ok bool) {
+	var (
+		j         int
+		shelf     *packingShelf
+		score     int
+		bestScore int = -1
+		bestShelf int = -1
+	)
+	if w > p.w || h > p.h {
+		return
+	}
+	for j, shelf = range p.shelves {
+		if shelf.CanAdd(w, h, p.w) && shelf.y+h <= p.h {
+			score = shelf.BestAreaFit(w, h, p.w)
+			if score > bestScore {
+				bestScore = score
+				bestShelf = j
+			}
+		}
+	}
+	if bestShelf == -1 {
+		shelf = p.shelves[len(p.shelves)-1]
+		if shelf.y+shelf.height+h > p.h {
+			// New packingShelf would exceed current image size
+			return
+		}
+		p.shelves = append(p.shelves, shelf.Close())
+		bestShelf = len(p.shelves) - 1
+	}
+	var x, y = p.shelves[bestShelf].Add(w, h)
+	rect = image.Rect(x, y, x+w, y+h)
+	ok = true
+	return
+}
@@ -0,0 +1,156 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sprites
+
+import (
+	"image"
+)
+
+type skylineNode struct {
+	x int
+	y int
+	w int
+}
+
+// Packs rectangles bottom-left against a skyline of the lowest free y for
+// each horizontal span. Space under overhangs is lost, but placement is fast
+// and handles mixed heights far better than shelves.
+type skylinePacker struct {
+	nodes []skylineNode
+	w     int
+	h     int
+}
+
+func newSkylinePacker(w, h int) *skylinePacker {
+	return &skylinePacker{
+		nodes: []skylineNode{skylineNode{x: 0, y: 0, w: w}},
+		w:     w,
+		h:     h,
+	}
+}
+
+func (p *skylinePacker) grow(w, h int) {
+	if w > p.w {
+		p.nodes = append(p.nodes, skylineNode{x: p.w, y: 0, w: w - p.w})
+		p.merge()
+	}
+	p.w = w
+	p.h = h
+}
+
+// Returns the y at which a w by h rectangle could rest if its left edge is at
+// the start of node i.
+func (p *skylinePacker) fit(i, w, h int) (y int, ok bool) {
+	var (
+		x         = p.nodes[i].x
+		widthLeft = w
+	)
+	if x+w > p.w {
+		return
+	}
+	for widthLeft > 0 {
+		if i >= len(p.nodes) {
+			return
+		}
+		if p.nodes[i].y > y {
+			y = p.nodes[i].y
+		}
+		if y+h > p.h {
+			return
+		}
+		widthLeft -= p.nodes[i].w
+		i++
+	}
+	ok = true
+	return
+}
+
+func (p *skylinePacker) find(w, h int) (best int, bestY int, bestW int) {
+	var (
+		y  int
+		ok bool
+	)
+	best = -1
+	for i := range p.nodes {
+		if y, ok = p.fit(i, w, h); !ok {
+			continue
+		}
+		if best == -1 || y+h < bestY+h || (y+h == bestY+h && p.nodes[i].w < bestW) {
+			best = i
+			bestY = y
+			bestW = p.nodes[i].w
+		}
+	}
+	return
+}
+
+func (p *skylinePacker) insert(w, h int, rotate bool) (rect image.Rectangle, rotated bool, ok bool) {
+	var (
+		best, bestY, bestW = p.find(w, h)
+	)
+	if rotate && w != h {
+		var rbest, rbestY, rbestW = p.find(h, w)
+		if rbest != -1 && (best == -1 || rbestY+w < bestY+h || (rbestY+w == bestY+h && rbestW < bestW)) {
+			best, bestY = rbest, rbestY
+			w, h = h, w
+			rotated = true
+		}
+	}
+	if best == -1 {
+		return
+	}
+	rect = image.Rect(p.nodes[best].x, bestY, p.nodes[best].x+w, bestY+h)
+	p.add(best, rect)
+	ok = true
+	return
+}
+
+// Raises the skyline over rect, which starts at node i.
+func (p *skylinePacker) add(i int, rect image.Rectangle) {
+	var (
+		node = skylineNode{x: rect.Min.X, y: rect.Max.Y, w: rect.Dx()}
+		j    int
+	)
+	p.nodes = append(p.nodes, skylineNode{})
+	copy(p.nodes[i+1:], p.nodes[i:])
+	p.nodes[i] = node
+	for j = i + 1; j < len(p.nodes); {
+		var (
+			prev   = p.nodes[j-1]
+			shrink = prev.x + prev.w - p.nodes[j].x
+		)
+		if shrink <= 0 {
+			break
+		}
+		p.nodes[j].x += shrink
+		p.nodes[j].w -= shrink
+		if p.nodes[j].w > 0 {
+			break
+		}
+		p.nodes = append(p.nodes[:j], p.nodes[j+1:]...)
+	}
+	p.merge()
+}
+
+func (p *skylinePacker) merge() {
+	for i := 0; i < len(p.nodes)-1; {
+		if p.nodes[i].y == p.nodes[i+1].y {
+			p.nodes[i].w += p.nodes[i+1].w
+			p.nodes = append(p.nodes[:i+1], p.nodes[i+2:]...)
+		} else {
+			i++
+		}
+	}
+}
@@ -15,34 +15,133 @@
 package sprites
 
 import (
+	"fmt"
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/pikkpoiss/gamejam/v1/base/render"
 	"image"
 )
 
+// Named region of a sprite, such as an Aseprite slice. Bounds is in pixels
+// relative to the top left of the sprite; Center and Pivot are relative to
+// the top left of Bounds.
+type SpriteSlice struct {
+	Name      string
+	Bounds    image.Rectangle
+	Center    image.Rectangle
+	HasCenter bool
+	Pivot     image.Point
+	HasPivot  bool
+	Color     string
+	Data      string
+}
+
+// Describes where a sprite lives in a texture and how it maps back to the
+// untrimmed source image. Sizes and offsets are in pixels.
+type SpriteData struct {
+	Bounds     mgl32.Vec2 // Size of the trimmed sprite, before rotation.
+	Offset     mgl32.Vec2 // Top left of the packed region in the texture.
+	Rotated    bool       // Packed rotated 90 degrees clockwise.
+	RotatedCCW bool       // Rotated sprites were turned counter-clockwise.
+	SourceSize mgl32.Vec2 // Size of the untrimmed source image.
+	TrimOffset mgl32.Vec2 // Top left of the trimmed region in the source.
+	Pivot      mgl32.Vec2 // Normalized origin within the source image.
+	Page       int        // Index of the sheet texture holding the sprite.
+}
+
+// Returns sprite data for an untrimmed, unrotated sprite pivoting around
+// its center.
+func NewSpriteData(bounds, offset mgl32.Vec2) SpriteData {
+	return SpriteData{
+		Bounds:     bounds,
+		Offset:     offset,
+		SourceSize: bounds,
+		Pivot:      mgl32.Vec2{0.5, 0.5},
+	}
+}
+
 type Sprite struct {
-	index  int
-	bounds mgl32.Vec2
-	offset mgl32.Vec2
+	index      int
+	bounds     mgl32.Vec2
+	offset     mgl32.Vec2
+	rotated    bool
+	rotatedCCW bool
+	sourceSize mgl32.Vec2
+	trimOffset mgl32.Vec2
+	pivot      mgl32.Vec2
+	page       int
+	slices     map[string]SpriteSlice
+}
+
+func (s *Sprite) Data() SpriteData {
+	return SpriteData{
+		Bounds:     s.bounds,
+		Offset:     s.offset,
+		Rotated:    s.rotated,
+		RotatedCCW: s.rotatedCCW,
+		SourceSize: s.sourceSize,
+		TrimOffset: s.trimOffset,
+		Pivot:      s.pivot,
+		Page:       s.page,
+	}
 }
 
 func (s *Sprite) Index() int {
 	return s.index
 }
 
+func (s *Sprite) Page() int {
+	return s.page
+}
+
+func (s *Sprite) Rotated() bool {
+	return s.rotated
+}
+
+func (s *Sprite) SourceSize() mgl32.Vec2 {
+	return s.sourceSize
+}
+
+func (s *Sprite) Pivot() mgl32.Vec2 {
+	return s.pivot
+}
+
+// Size of the region the sprite occupies in the texture, which is swapped
+// relative to the sprite bounds if the sprite was packed rotated.
+func (s *Sprite) packedBounds() mgl32.Vec2 {
+	if s.rotated {
+		return mgl32.Vec2{s.bounds.Y(), s.bounds.X()}
+	}
+	return s.bounds
+}
+
 func (s *Sprite) ImageBounds() image.Rectangle {
+	var packed = s.packedBounds()
 	return image.Rectangle{
 		image.Point{int(s.offset.X()), int(s.offset.Y())},
-		image.Point{int(s.offset.X() + s.bounds.X()), int(s.offset.Y() + s.bounds.Y())},
+		image.Point{int(s.offset.X() + packed.X()), int(s.offset.Y() + packed.Y())},
 	}
 }
 
+// Rotated sprites are flagged to the shader with a negative width, or a
+// negative height if they were rotated counter-clockwise.
 func (s *Sprite) textureBounds(textureBounds mgl32.Vec2) render.UniformSprite {
+	var (
+		packed = s.packedBounds()
+		texW   = packed.X() / textureBounds.X()
+		texH   = packed.Y() / textureBounds.Y()
+	)
+	if s.rotated {
+		if s.rotatedCCW {
+			texH = -texH
+		} else {
+			texW = -texW
+		}
+	}
 	return render.NewUniformSprite(
-		s.bounds.X()/textureBounds.X(),
-		s.bounds.Y()/textureBounds.Y(),
+		texW,
+		texH,
 		s.offset.X()/textureBounds.X(),
-		1.0-(s.offset.Y()+s.bounds.Y()-1.0)/textureBounds.Y(),
+		1.0-(s.offset.Y()+packed.Y()-1.0)/textureBounds.Y(),
 	)
 }
 
@@ -52,3 +151,43 @@ func (s *Sprite) WorldDimensions(pxPerUnit float32) mgl32.Vec2 {
 		s.bounds.Y() / pxPerUnit,
 	}
 }
+
+// Returns the distance from the pivot to the center of the trimmed sprite in
+// world units. Instances drawn with a centered quad should be offset by this
+// much so that trimmed sprites line up with their untrimmed source.
+func (s *Sprite) WorldOffset(pxPerUnit float32) mgl32.Vec2 {
+	var (
+		centerX = s.trimOffset.X() + s.bounds.X()/2.0
+		centerY = s.trimOffset.Y() + s.bounds.Y()/2.0
+		pivotX  = s.pivot.X() * s.sourceSize.X()
+		pivotY  = s.pivot.Y() * s.sourceSize.Y()
+	)
+	return mgl32.Vec2{
+		(centerX - pivotX) / pxPerUnit,
+		(pivotY - centerY) / pxPerUnit,
+	}
+}
+
+func (s *Sprite) SetSlice(slice SpriteSlice) {
+	if s.slices == nil {
+		s.slices = map[string]SpriteSlice{}
+	}
+	s.slices[slice.Name] = slice
+}
+
+func (s *Sprite) Slice(name string) (out SpriteSlice, err error) {
+	var exists bool
+	if out, exists = s.slices[name]; !exists {
+		err = fmt.Errorf("Invalid slice name %v", name)
+		return
+	}
+	return
+}
+
+func (s *Sprite) Slices() (out []SpriteSlice) {
+	out = make([]SpriteSlice, 0, len(s.slices))
+	for _, slice := range s.slices {
+		out = append(out, slice)
+	}
+	return
+}
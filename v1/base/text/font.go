@@ -0,0 +1,47 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+	"image"
+)
+
+// Metrics needed to lay out text. All values are in pixels.
+type Font interface {
+	// Distance between the baselines of consecutive lines.
+	LineHeight() float32
+	// Distance from the top of a line to its baseline.
+	Ascent() float32
+	// Distance from the baseline to the bottom of a line.
+	Descent() float32
+	// How far the pen moves after drawing r.
+	Advance(r rune) float32
+	// Adjustment to the advance between a and b.
+	Kern(a, b rune) float32
+}
+
+// A font whose glyphs are sprites in a sheet, such as a GlyphAtlas or a
+// bitmap font.
+type GlyphSource interface {
+	Font
+	// Returns the sprite for r and the position of its top left corner
+	// relative to the pen on the baseline. ok is false for glyphs with
+	// nothing to draw.
+	Glyph(r rune) (sprite *sprites.Sprite, offset image.Point, ok bool, err error)
+	Sheet() *sprites.Sheet
+	// Uploads any glyphs added to the sheet since the last call.
+	Upload() error
+}
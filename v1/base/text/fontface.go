@@ -19,80 +19,281 @@ import (
 	"github.com/golang/freetype"
 	"github.com/golang/freetype/truetype"
 	"github.com/pikkpoiss/gamejam/v1/base/core"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
 	"golang.org/x/image/math/fixed"
 	"image"
 	"image/color"
 	"image/draw"
+	"io"
 	"io/ioutil"
+	"sync"
 )
 
+type Hinting int
+
+// The zero value leaves glyphs unhinted.
+const (
+	HintingNone Hinting = iota
+	HintingVertical
+	HintingFull
+)
+
+func (h Hinting) font() font.Hinting {
+	switch h {
+	case HintingVertical:
+		return font.HintingVertical
+	case HintingFull:
+		return font.HintingFull
+	}
+	return font.HintingNone
+}
+
+// Controls how a FontFace rasterizes. The zero value matches NewFontFace.
+type FontOptions struct {
+	DPI       float32 // Sizes are pixels at 96 DPI and scale with this. Zero is 96.
+	Hinting   Hinting
+	Subpixels int     // Horizontal glyph positions per pixel. Zero is 4, one snaps glyphs to whole pixels.
+	LineGap   float32 // Extra pixels between lines.
+}
+
+func (o FontOptions) withDefaults() FontOptions {
+	if o.DPI <= 0 {
+		o.DPI = 96
+	}
+	if o.Subpixels <= 0 {
+		o.Subpixels = 4
+	}
+	return o
+}
+
 type FontFace struct {
-	font    *truetype.Font
-	charw   float32
-	charh   float32
-	offy    float32
-	fg      color.Color
-	bg      color.Color
-	context *freetype.Context
+	font      *truetype.Font
+	face      font.Face
+	pixels    float32
+	points    float32
+	dpi       float32
+	opts      FontOptions
+	style     FontStyle
+	fallbacks []*FontFace
 }
 
 func NewFontFace(path string, pixels float32, fg, bg color.Color) (fontface *FontFace, err error) {
-	var (
-		font      *truetype.Font
-		fontbytes []byte
-		bounds    fixed.Rectangle26_6
-		context   *freetype.Context
-		points    float32
-		dpi       float32 = 96
-	)
-	if fontbytes, err = ioutil.ReadFile(path); err != nil {
+	return NewStyledFontFace(path, pixels, FontStyle{
+		Foreground: fg,
+		Background: bg,
+	})
+}
+
+func NewStyledFontFace(path string, pixels float32, style FontStyle) (fontface *FontFace, err error) {
+	var ttf *truetype.Font
+	if ttf, err = LoadTrueType(path); err != nil {
+		return
+	}
+	fontface = NewFontFaceFromTrueType(ttf, pixels, style, FontOptions{})
+	return
+}
+
+func NewFontFaceFromBytes(data []byte, pixels float32, style FontStyle, opts FontOptions) (fontface *FontFace, err error) {
+	var ttf *truetype.Font
+	if ttf, err = ParseTrueType(data); err != nil {
+		return
+	}
+	fontface = NewFontFaceFromTrueType(ttf, pixels, style, opts)
+	return
+}
+
+func NewFontFaceFromReader(r io.Reader, pixels float32, style FontStyle, opts FontOptions) (fontface *FontFace, err error) {
+	var ttf *truetype.Font
+	if ttf, err = ReadTrueType(r); err != nil {
 		return
 	}
-	if font, err = freetype.ParseFont(fontbytes); err != nil {
+	fontface = NewFontFaceFromTrueType(ttf, pixels, style, opts)
+	return
+}
+
+// Creates a face from an already parsed font, which can be shared by faces
+// of any size.
+func NewFontFaceFromTrueType(ttf *truetype.Font, pixels float32, style FontStyle, opts FontOptions) *FontFace {
+	opts = opts.withDefaults()
+	var points = pixels * 72 / 96
+	return &FontFace{
+		font:   ttf,
+		pixels: pixels,
+		points: points,
+		dpi:    opts.DPI,
+		opts:   opts,
+		face: truetype.NewFace(ttf, &truetype.Options{
+			Size:       float64(points),
+			DPI:        float64(opts.DPI),
+			Hinting:    opts.Hinting.font(),
+			SubPixelsX: opts.Subpixels,
+		}),
+		style: style,
+	}
+}
+
+func LoadTrueType(path string) (ttf *truetype.Font, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(path); err != nil {
 		return
 	}
-	points = pixels * 72 / dpi
-	bounds = font.Bounds(fixed.I(int(pixels)))
-	context = freetype.NewContext()
-	context.SetFont(font)
-	context.SetFontSize(float64(points))
-	context.SetDPI(float64(dpi))
-	fontface = &FontFace{
-		font:    font,
-		charw:   float32(bounds.Max.X-bounds.Min.X) / 64,
-		charh:   float32(bounds.Max.Y-bounds.Min.Y) / 64,
-		offy:    float32(bounds.Min.Y) / 64,
-		fg:      fg,
-		bg:      bg,
-		context: context,
+	return ParseTrueType(data)
+}
+
+func ReadTrueType(r io.Reader) (ttf *truetype.Font, err error) {
+	var data []byte
+	if data, err = ioutil.ReadAll(r); err != nil {
+		return
+	}
+	return ParseTrueType(data)
+}
+
+func ParseTrueType(data []byte) (ttf *truetype.Font, err error) {
+	return freetype.ParseFont(data)
+}
+
+var (
+	defaultTrueType     *truetype.Font
+	defaultTrueTypeErr  error
+	defaultTrueTypeOnce sync.Once
+)
+
+// Returns a face using the Go Mono font compiled into the binary, so that
+// debug text needs no asset files.
+func NewDefaultFontFace(pixels float32, style FontStyle) (fontface *FontFace, err error) {
+	defaultTrueTypeOnce.Do(func() {
+		defaultTrueType, defaultTrueTypeErr = ParseTrueType(gomono.TTF)
+	})
+	if err = defaultTrueTypeErr; err != nil {
+		return
+	}
+	fontface = NewFontFaceFromTrueType(defaultTrueType, pixels, style, FontOptions{})
+	return
+}
+
+// Returns a face sharing this one's font, options and style at another size.
+// Fallbacks are resized too.
+func (ff *FontFace) WithSize(pixels float32) (fontface *FontFace) {
+	fontface = NewFontFaceFromTrueType(ff.font, pixels, ff.style, ff.opts)
+	for _, fallback := range ff.fallbacks {
+		fontface.AddFallback(fallback.WithSize(pixels))
 	}
 	return
 }
 
+func (ff *FontFace) TrueType() *truetype.Font {
+	return ff.font
+}
+
+func (ff *FontFace) Options() FontOptions {
+	return ff.opts
+}
+
+// The size the face was created with, in pixels at 96 DPI.
+func (ff *FontFace) Size() float32 {
+	return ff.pixels
+}
+
+func (ff *FontFace) Style() FontStyle {
+	return ff.style
+}
+
+// Affects images rasterized after the call. Glyphs already cached in an
+// atlas keep their old style.
+func (ff *FontFace) SetStyle(style FontStyle) {
+	ff.style = style
+}
+
+// Adds a face to draw runes which this face and earlier fallbacks lack, such
+// as accented or CJK characters. Glyphs from fallbacks use this face's style.
+func (ff *FontFace) AddFallback(fallback *FontFace) {
+	ff.fallbacks = append(ff.fallbacks, fallback)
+}
+
+func (ff *FontFace) Fallbacks() []*FontFace {
+	return ff.fallbacks
+}
+
+// Whether the face itself, ignoring fallbacks, has a glyph for r.
+func (ff *FontFace) HasGlyph(r rune) bool {
+	return ff.font.Index(r) != 0
+}
+
+// Returns the first face in the fallback chain with a glyph for r, or ff if
+// none has one.
+func (ff *FontFace) faceFor(r rune) *FontFace {
+	if ff.HasGlyph(r) {
+		return ff
+	}
+	for _, fallback := range ff.fallbacks {
+		if fallback.HasGlyph(r) {
+			return fallback
+		}
+	}
+	return ff
+}
+
 func (ff *FontFace) GetImage(text string) (img draw.Image, err error) {
 	var (
-		src image.Image
-		bg  image.Image
-		dst draw.Image
-		pt  fixed.Point26_6
-		w   int
-		h   int
+		runes   = []rune(text)
+		faces   = make([]*FontFace, len(runes))
+		ascent  = ff.face.Metrics().Ascent
+		descent = ff.face.Metrics().Descent
+		pens    = make([]fixed.Int26_6, len(runes))
+		x       fixed.Int26_6
+		minX    fixed.Int26_6
+		maxX    fixed.Int26_6
+		advance fixed.Int26_6
+		bounds  fixed.Rectangle26_6
+		mask    *image.Alpha
+		dr      image.Rectangle
+		glyph   image.Image
+		maskp   image.Point
+		ok      bool
 	)
-	src = image.NewUniform(ff.fg)
-	bg = image.NewUniform(ff.bg)
-	w = int(float32(len(text)) * ff.charw)
-	h = int(ff.charh)
-	dst = image.NewRGBA(image.Rect(0, 0, w, h))
-	draw.Draw(dst, dst.Bounds(), bg, image.ZP, draw.Src)
-	ff.context.SetSrc(src)
-	ff.context.SetDst(dst)
-	ff.context.SetClip(dst.Bounds())
-	pt = freetype.Pt(0, int(ff.charh+ff.offy))
-	if pt, err = ff.context.DrawString(text, pt); err != nil {
-		return
+	// Fallback faces may need more room above or below the baseline.
+	for i, r := range runes {
+		faces[i] = ff.faceFor(r)
+		metrics := faces[i].face.Metrics()
+		if metrics.Ascent > ascent {
+			ascent = metrics.Ascent
+		}
+		if metrics.Descent > descent {
+			descent = metrics.Descent
+		}
+	}
+	// Ink can start left of the pen or run past the last advance, as with
+	// italics, so the mask covers the union of the glyph bounds too.
+	for i, r := range runes {
+		if i > 0 && faces[i] == faces[i-1] {
+			x += faces[i].face.Kern(runes[i-1], r)
+		}
+		pens[i] = x
+		if bounds, advance, ok = faces[i].face.GlyphBounds(r); ok && bounds.Min.X < bounds.Max.X {
+			if x+bounds.Min.X < minX {
+				minX = x + bounds.Min.X
+			}
+			if x+bounds.Max.X > maxX {
+				maxX = x + bounds.Max.X
+			}
+		}
+		x += advance
 	}
-	img = image.NewRGBA(image.Rect(0, 0, int(pt.X/64), int(pt.Y/64)))
-	draw.Draw(img, img.Bounds(), dst, image.Pt(0, -int(ff.offy)), draw.Src)
+	if x > maxX {
+		maxX = x
+	}
+	mask = image.NewAlpha(image.Rect(0, 0, maxX.Ceil()-minX.Floor(), ascent.Ceil()+descent.Ceil()))
+	if mask.Rect.Dx() < 1 {
+		mask = image.NewAlpha(image.Rect(0, 0, 1, mask.Rect.Dy()))
+	}
+	for i, r := range runes {
+		dot := fixed.Point26_6{X: pens[i] - fixed.I(minX.Floor()), Y: fixed.I(ascent.Ceil())}
+		if dr, glyph, maskp, _, ok = faces[i].face.Glyph(dot, r); ok {
+			draw.DrawMask(mask, dr, image.Opaque, image.ZP, glyph, maskp, draw.Over)
+		}
+	}
+	img, _ = ff.style.render(mask, ascent.Ceil(), ff.Ascent(), ff.Descent(), true)
 	return
 }
 
@@ -106,3 +307,63 @@ func (ff *FontFace) GetText(text string) (t *core.Texture, err error) {
 	t, err = core.GetTexture(img, gl.NEAREST)
 	return
 }
+
+// Returns the distance in pixels between the baselines of consecutive lines.
+// Metrics come from this face even where fallbacks draw the glyphs.
+func (ff *FontFace) LineHeight() float32 {
+	return fixedToFloat(ff.face.Metrics().Height) + ff.opts.LineGap
+}
+
+// Returns the distance in pixels from the top of a line to its baseline.
+func (ff *FontFace) Ascent() float32 {
+	return fixedToFloat(ff.face.Metrics().Ascent)
+}
+
+// Returns how far in pixels the pen moves after drawing r.
+func (ff *FontFace) Advance(r rune) float32 {
+	var advance, _ = ff.faceFor(r).face.GlyphAdvance(r)
+	return fixedToFloat(advance)
+}
+
+// Returns the adjustment in pixels to the advance between a and b. Runes drawn
+// by different faces in the fallback chain aren't kerned.
+func (ff *FontFace) Kern(a, b rune) float32 {
+	var face = ff.faceFor(a)
+	if face != ff.faceFor(b) {
+		return 0
+	}
+	return fixedToFloat(face.face.Kern(a, b))
+}
+
+// Returns the distance in pixels from the baseline to the bottom of a line.
+func (ff *FontFace) Descent() float32 {
+	return fixedToFloat(ff.face.Metrics().Descent)
+}
+
+// Rasterizes a single glyph with the font's style, except for the background
+// which is left transparent so that neighboring glyph quads can overlap.
+// offset is the position of the image's top left corner relative to the pen
+// on the baseline. ok is false for glyphs with nothing to draw, such as
+// spaces.
+func (ff *FontFace) GlyphImage(r rune) (img *image.RGBA, offset image.Point, ok bool) {
+	var (
+		dr     image.Rectangle
+		mask   image.Image
+		maskp  image.Point
+		alpha  *image.Alpha
+		origin image.Point
+	)
+	if dr, mask, maskp, _, ok = ff.faceFor(r).face.Glyph(fixed.Point26_6{}, r); !ok || dr.Empty() {
+		ok = false
+		return
+	}
+	alpha = image.NewAlpha(image.Rect(0, 0, dr.Dx(), dr.Dy()))
+	draw.Draw(alpha, alpha.Bounds(), mask, maskp, draw.Src)
+	img, origin = ff.style.render(alpha, -dr.Min.Y, ff.Ascent(), ff.Descent(), false)
+	offset = dr.Min.Sub(origin)
+	return
+}
+
+func fixedToFloat(i fixed.Int26_6) float32 {
+	return float32(i) / 64
+}
@@ -0,0 +1,101 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"sync"
+	"testing"
+)
+
+// Shared by every caller, however many ask for it at once.
+func TestDefaultFontFaceParsesOnce(t *testing.T) {
+	var (
+		wait  sync.WaitGroup
+		faces = make([]*FontFace, 8)
+		errs  = make([]error, len(faces))
+	)
+	for i := range faces {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			faces[i], errs[i] = NewDefaultFontFace(16, FontStyle{})
+		}(i)
+	}
+	wait.Wait()
+	for i, ff := range faces {
+		if errs[i] != nil {
+			t.Fatalf("NewDefaultFontFace: %v", errs[i])
+		}
+		if ff.TrueType() != faces[0].TrueType() {
+			t.Errorf("Face %v parsed its own font", i)
+		}
+	}
+}
+
+// Unhinted advances keep their fractional part, which full hinting rounds
+// away.
+func TestZeroFontOptionsAreUnhinted(t *testing.T) {
+	var ttf, err = NewDefaultFontFace(13, FontStyle{})
+	if err != nil {
+		t.Fatalf("NewDefaultFontFace: %v", err)
+	}
+	var (
+		zero    = NewFontFaceFromTrueType(ttf.TrueType(), 13, FontStyle{}, FontOptions{})
+		none    = NewFontFaceFromTrueType(ttf.TrueType(), 13, FontStyle{}, FontOptions{Hinting: HintingNone})
+		full    = NewFontFaceFromTrueType(ttf.TrueType(), 13, FontStyle{}, FontOptions{Hinting: HintingFull})
+		a, _    = zero.face.GlyphAdvance('a')
+		b, _    = none.face.GlyphAdvance('a')
+		hint, _ = full.face.GlyphAdvance('a')
+	)
+	if a != b {
+		t.Errorf("Zero options advance %v, unhinted %v", a, b)
+	}
+	if a&63 == 0 || hint&63 != 0 {
+		t.Errorf("Got unhinted advance %v and hinted %v", a, hint)
+	}
+}
+
+// Hinted, Go Mono's underscore is wider than its advance, so the last column
+// of its ink is past the pen.
+func TestGetImageKeepsOverhangs(t *testing.T) {
+	var def, err = NewDefaultFontFace(32, FontStyle{})
+	if err != nil {
+		t.Fatalf("NewDefaultFontFace: %v", err)
+	}
+	var ff = NewFontFaceFromTrueType(def.TrueType(), 32, FontStyle{}, FontOptions{Hinting: HintingFull})
+	bounds, advance, _ := ff.face.GlyphBounds('_')
+	if bounds.Max.X <= advance {
+		t.Fatalf("Underscore ink ends at %v, within its advance %v", bounds.Max.X, advance)
+	}
+	img, err := ff.GetImage("_")
+	if err != nil {
+		t.Fatalf("GetImage: %v", err)
+	}
+	var (
+		rect  = img.Bounds()
+		inked bool
+	)
+	if rect.Dx() < bounds.Max.X.Ceil() {
+		t.Fatalf("Image is %v wide, want at least %v", rect.Dx(), bounds.Max.X.Ceil())
+	}
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		if _, _, _, a := img.At(rect.Max.X-1, y).RGBA(); a > 0 {
+			inked = true
+		}
+	}
+	if !inked {
+		t.Errorf("Nothing drawn in the last column of %v", rect)
+	}
+}
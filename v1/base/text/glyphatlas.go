@@ -0,0 +1,128 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"github.com/pikkpoiss/gamejam/v1/base/core"
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+	"image"
+	"image/draw"
+)
+
+type glyphEntry struct {
+	sprite *sprites.Sprite // Nil for glyphs with nothing to draw.
+	offset image.Point
+}
+
+// Caches each glyph of a font in a packed sheet the first time it is used.
+// Implements GlyphSource.
+type GlyphAtlas struct {
+	font      *FontFace
+	sheet     *sprites.PackedSheet
+	glyphs    map[rune]glyphEntry
+	rasterize func(r rune) (img *image.RGBA, offset image.Point, ok bool)
+}
+
+func NewGlyphAtlas(font *FontFace, w, h int) *GlyphAtlas {
+	return newGlyphAtlas(font, w, h, font.GlyphImage)
+}
+
+// Stores signed distance fields instead of coverage, for drawing with a
+// render.SDFRenderer.
+func NewSDFGlyphAtlas(font *FontFace, w, h int, cfg SDFConfig) *GlyphAtlas {
+	return newGlyphAtlas(font, w, h, newSDFGenerator(font, cfg).glyph)
+}
+
+func newGlyphAtlas(font *FontFace, w, h int, rasterize func(r rune) (*image.RGBA, image.Point, bool)) *GlyphAtlas {
+	var sheet = sprites.NewPackedSheetWithConfig(sprites.PackingConfig{
+		Width:     w,
+		Height:    h,
+		MaxWidth:  w,
+		MaxHeight: h,
+		Strategy:  sprites.PackingSkyline,
+		Padding:   1,
+	})
+	return &GlyphAtlas{
+		font:      font,
+		sheet:     sheet,
+		glyphs:    map[rune]glyphEntry{},
+		rasterize: rasterize,
+	}
+}
+
+// Returns the sprite for r and the position of its top left corner relative
+// to the pen on the baseline. ok is false for glyphs with nothing to draw.
+func (a *GlyphAtlas) Glyph(r rune) (sprite *sprites.Sprite, offset image.Point, ok bool, err error) {
+	var (
+		entry  glyphEntry
+		exists bool
+		img    draw.Image
+		key    = string(r)
+	)
+	if entry, exists = a.glyphs[r]; !exists {
+		if img, entry.offset, ok = a.rasterize(r); ok {
+			if err = a.sheet.Pack(key, img); err != nil {
+				return
+			}
+			if entry.sprite, err = a.sheet.Sprite(key); err != nil {
+				return
+			}
+		}
+		a.glyphs[r] = entry
+	}
+	sprite, offset, ok = entry.sprite, entry.offset, entry.sprite != nil
+	return
+}
+
+// Uploads glyphs added since the last call.
+func (a *GlyphAtlas) Upload() error {
+	return a.sheet.UploadTexture(core.SmoothingLinear)
+}
+
+func (a *GlyphAtlas) Font() *FontFace {
+	return a.font
+}
+
+func (a *GlyphAtlas) LineHeight() float32 {
+	return a.font.LineHeight()
+}
+
+func (a *GlyphAtlas) Ascent() float32 {
+	return a.font.Ascent()
+}
+
+func (a *GlyphAtlas) Descent() float32 {
+	return a.font.Descent()
+}
+
+func (a *GlyphAtlas) Advance(r rune) float32 {
+	return a.font.Advance(r)
+}
+
+func (a *GlyphAtlas) Kern(x, y rune) float32 {
+	return a.font.Kern(x, y)
+}
+
+func (a *GlyphAtlas) Sheet() *sprites.Sheet {
+	return a.sheet.Sheet
+}
+
+func (a *GlyphAtlas) PackedSheet() *sprites.PackedSheet {
+	return a.sheet
+}
+
+func (a *GlyphAtlas) Delete() {
+	a.sheet.Delete()
+}
@@ -0,0 +1,295 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pikkpoiss/gamejam/v1/base/render"
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+	"image"
+)
+
+// Draws text as one instance per glyph, sharing the sheet of a single glyph
+// source. Inline icons are drawn by a second list using the icon sheet.
+type GlyphInstanceList struct {
+	*render.InstanceList
+	cfg    Config
+	source GlyphSource
+	owned  bool // Whether Delete should delete the source's sheet.
+	icons  *sprites.SpriteInstanceList
+}
+
+// Rasterizes glyphs of font into an atlas of the configured texture size.
+func NewGlyphInstanceList(cfg Config, font *FontFace) (l *GlyphInstanceList) {
+	l = NewGlyphSourceInstanceList(
+		cfg,
+		NewGlyphAtlas(font, cfg.TextureWidth, cfg.TextureHeight),
+	)
+	l.owned = true
+	return
+}
+
+// Rasterizes glyphs of font as signed distance fields, to be rendered with a
+// render.SDFRenderer.
+func NewSDFGlyphInstanceList(cfg Config, font *FontFace, sdf SDFConfig) (l *GlyphInstanceList) {
+	l = NewGlyphSourceInstanceList(
+		cfg,
+		NewSDFGlyphAtlas(font, cfg.TextureWidth, cfg.TextureHeight, sdf),
+	)
+	l.owned = true
+	return
+}
+
+// Draws glyphs from source, such as a bitmap font. The texture size in cfg is
+// unused, and the caller remains responsible for deleting source.
+func NewGlyphSourceInstanceList(cfg Config, source GlyphSource) *GlyphInstanceList {
+	return &GlyphInstanceList{
+		InstanceList: render.NewInstanceList(),
+		cfg:          cfg,
+		source:       source,
+	}
+}
+
+// Sets the sheet [icon=key] markup draws from. Must be called before labels
+// using icons are created.
+func (l *GlyphInstanceList) SetIconSheet(sheet *sprites.Sheet) {
+	l.icons = sprites.NewSpriteInstanceList(sheet, l.cfg.PixelsPerUnit)
+}
+
+// Returns the instances for inline icons, which should be rendered with the
+// icon sheet. Nil if no icon sheet was set.
+func (l *GlyphInstanceList) Icons() *sprites.SpriteInstanceList {
+	return l.icons
+}
+
+func (l *GlyphInstanceList) iconSheet() *sprites.Sheet {
+	if l.icons == nil {
+		return nil
+	}
+	return l.icons.Sheet()
+}
+
+func (l *GlyphInstanceList) NewLabel(text string, cfg LayoutConfig) (label *Label, err error) {
+	label = &Label{
+		list: l,
+		cfg:  cfg,
+	}
+	err = label.SetText(text)
+	return
+}
+
+// Creates a label from markup as accepted by ParseMarkup.
+func (l *GlyphInstanceList) NewMarkupLabel(markup string, cfg LayoutConfig) (label *Label, err error) {
+	label = &Label{
+		list: l,
+		cfg:  cfg,
+	}
+	err = label.SetMarkup(markup)
+	return
+}
+
+func (l *GlyphInstanceList) Source() GlyphSource {
+	return l.source
+}
+
+func (l *GlyphInstanceList) Bind() {
+	l.source.Sheet().Bind()
+}
+
+func (l *GlyphInstanceList) Unbind() {
+	l.source.Sheet().Unbind()
+}
+
+func (l *GlyphInstanceList) Delete() {
+	if l.owned {
+		l.source.Sheet().Delete()
+	}
+	l.source = nil
+}
+
+func (l *GlyphInstanceList) Sheet() *sprites.Sheet {
+	return l.source.Sheet()
+}
+
+type labelGlyph struct {
+	instance *render.Instance
+	offset   mgl32.Vec3 // From the label position, in world units.
+}
+
+// A block of text whose position is the top left corner of its layout.
+type Label struct {
+	list     *GlyphInstanceList
+	text     string
+	runs     []MarkupRun
+	cfg      LayoutConfig
+	layout   Layout
+	position mgl32.Vec3
+	glyphs   []labelGlyph
+	icons    []labelGlyph
+}
+
+func (lbl *Label) Text() string {
+	return lbl.text
+}
+
+func (lbl *Label) SetText(text string) (err error) {
+	lbl.text = text
+	lbl.runs = []MarkupRun{MarkupRun{Text: text, Tint: markupWhite}}
+	return lbl.update()
+}
+
+// Replaces the label's contents with parsed markup. On error the label is left
+// unchanged and the error is a *MarkupError.
+func (lbl *Label) SetMarkup(markup string) (err error) {
+	var runs []MarkupRun
+	if runs, err = ParseMarkup(markup); err != nil {
+		return
+	}
+	if _, err = LayoutMarkup(lbl.list.source, runs, lbl.list.iconSheet(), lbl.cfg); err != nil {
+		return
+	}
+	lbl.text = markup
+	lbl.runs = runs
+	return lbl.update()
+}
+
+func (lbl *Label) SetLayoutConfig(cfg LayoutConfig) (err error) {
+	lbl.cfg = cfg
+	return lbl.update()
+}
+
+func (lbl *Label) Layout() Layout {
+	return lbl.layout
+}
+
+// Returns the size of the laid out text in world units.
+func (lbl *Label) Size() mgl32.Vec2 {
+	return mgl32.Vec2{lbl.layout.Width, lbl.layout.Height}.Mul(1.0 / lbl.list.cfg.PixelsPerUnit)
+}
+
+// Returns the metrics of the laid out text in world units.
+func (lbl *Label) Metrics() TextMetrics {
+	return lbl.layout.Metrics(lbl.list.source, lbl.cfg).World(lbl.list.cfg.PixelsPerUnit)
+}
+
+func (lbl *Label) Position() mgl32.Vec3 {
+	return lbl.position
+}
+
+func (lbl *Label) SetPosition(p mgl32.Vec3) {
+	lbl.position = p
+	lbl.place()
+}
+
+func (lbl *Label) Remove() {
+	for _, glyph := range lbl.glyphs {
+		glyph.instance.Remove()
+	}
+	for _, icon := range lbl.icons {
+		icon.instance.Remove()
+	}
+	lbl.glyphs = nil
+	lbl.icons = nil
+}
+
+// Returns the i-th entry of list, adding an instance from instances if needed.
+func reuseGlyph(list []labelGlyph, i int, instances render.Instances) []labelGlyph {
+	if i < len(list) {
+		return list
+	}
+	return append(list, labelGlyph{instance: instances.NewInstance()})
+}
+
+func truncateGlyphs(list []labelGlyph, count int) []labelGlyph {
+	for _, glyph := range list[count:] {
+		glyph.instance.Remove()
+	}
+	return list[:count]
+}
+
+func (lbl *Label) update() (err error) {
+	var (
+		source = lbl.list.source
+		ppu    = lbl.list.cfg.PixelsPerUnit
+		sprite *sprites.Sprite
+		offset image.Point
+		ok     bool
+		glyphs int
+		icons  int
+		center mgl32.Vec2
+		glyph  *labelGlyph
+		pivot  mgl32.Vec2
+		size   mgl32.Vec2
+	)
+	if lbl.layout, err = LayoutMarkup(source, lbl.runs, lbl.list.iconSheet(), lbl.cfg); err != nil {
+		return
+	}
+	for _, pos := range lbl.layout.Glyphs {
+		if pos.Icon != "" {
+			lbl.icons = reuseGlyph(lbl.icons, icons, lbl.list.icons)
+			glyph = &lbl.icons[icons]
+			if err = lbl.list.icons.SetFrame(glyph.instance, pos.Icon); err != nil {
+				return
+			}
+			if sprite, err = lbl.list.icons.Sheet().Sprite(pos.Icon); err != nil {
+				return
+			}
+			// The bottom of the icon rests on the baseline.
+			size = sprite.SourceSize()
+			pivot = mgl32.Vec2{
+				pos.X + sprite.Pivot().X()*size.X(),
+				pos.Y - size.Y() + sprite.Pivot().Y()*size.Y(),
+			}
+			glyph.offset = mgl32.Vec3{pivot.X() / ppu, -pivot.Y() / ppu, 0}
+			icons++
+		} else {
+			if sprite, offset, ok, err = source.Glyph(pos.Rune); err != nil {
+				return
+			}
+			if !ok {
+				continue
+			}
+			lbl.glyphs = reuseGlyph(lbl.glyphs, glyphs, lbl.list)
+			glyph = &lbl.glyphs[glyphs]
+			glyph.instance.Frame = sprite.Index()
+			glyph.instance.Key = string(pos.Rune)
+			glyph.instance.SetScale(sprite.WorldDimensions(ppu).Vec3(1.0))
+			center = mgl32.Vec2{
+				pos.X + float32(offset.X),
+				pos.Y + float32(offset.Y),
+			}.Add(sprite.Data().Bounds.Mul(0.5))
+			glyph.offset = mgl32.Vec3{center.X() / ppu, -center.Y() / ppu, 0}
+			glyphs++
+		}
+		glyph.instance.SetTint(pos.Tint[0], pos.Tint[1], pos.Tint[2], pos.Tint[3])
+	}
+	lbl.glyphs = truncateGlyphs(lbl.glyphs, glyphs)
+	lbl.icons = truncateGlyphs(lbl.icons, icons)
+	if err = source.Upload(); err != nil {
+		return
+	}
+	lbl.place()
+	return
+}
+
+// Moves each instance to its position in the layout.
+func (lbl *Label) place() {
+	for _, glyph := range lbl.glyphs {
+		glyph.instance.SetPosition(lbl.position.Add(glyph.offset))
+	}
+	for _, icon := range lbl.icons {
+		icon.instance.SetPosition(lbl.position.Add(icon.offset))
+	}
+}
@@ -32,6 +32,7 @@ type TextInstanceList struct {
 	*render.InstanceList
 	cfg   Config
 	sheet *sprites.PackedSheet
+	texts map[*render.Instance]string // Text each instance holds a reference to.
 }
 
 func NewTextInstanceList(cfg Config) *TextInstanceList {
@@ -42,6 +43,7 @@ func NewTextInstanceList(cfg Config) *TextInstanceList {
 			cfg.TextureWidth,
 			cfg.TextureHeight,
 		),
+		texts: map[*render.Instance]string{},
 	}
 }
 
@@ -53,18 +55,31 @@ func (l *TextInstanceList) SetText(instance *render.Instance, text string, font
 	if instance == nil {
 		return // No error.
 	}
-	if img, err = font.GetImage(text); err != nil {
-		return
+	if old, exists := l.texts[instance]; exists {
+		if old == text {
+			return
+		}
+		// Release first so the old text's space can be reused.
+		delete(l.texts, instance)
+		if err = l.sheet.Release(old); err != nil {
+			return
+		}
 	}
-	if err = l.sheet.Pack(text, img); err != nil {
-		// Attempt to compact the texture.
-		if err = l.repackImage(); err != nil {
+	if !l.sheet.Retain(text) {
+		if img, err = font.GetImage(text); err != nil {
 			return
 		}
 		if err = l.sheet.Pack(text, img); err != nil {
-			return
+			// Attempt to compact the texture.
+			if err = l.repackImage(); err != nil {
+				return
+			}
+			if err = l.sheet.Pack(text, img); err != nil {
+				return
+			}
 		}
 	}
+	l.texts[instance] = text
 	if sprite, err = l.sheet.Sprite(text); err != nil {
 		return
 	}
@@ -72,23 +87,20 @@ func (l *TextInstanceList) SetText(instance *render.Instance, text string, font
 	instance.SetScale(sprite.WorldDimensions(l.cfg.PixelsPerUnit).Vec3(1.0))
 	instance.MarkChanged()
 	instance.Key = text
-	if err = l.generateTexture(); err != nil {
+	if err = l.sheet.UploadTexture(core.SmoothingLinear); err != nil {
 		return
 	}
 	return
 }
 
-func (l *TextInstanceList) generateTexture() (err error) {
-	var (
-		texture *core.Texture
-	)
-	if texture, err = core.GetTexture(
-		l.sheet.Image(),
-		core.SmoothingLinear,
-	); err != nil {
-		return
+// Removes instance from the list, freeing its text once no other instance
+// shows it.
+func (l *TextInstanceList) Remove(instance *render.Instance) (err error) {
+	if text, exists := l.texts[instance]; exists {
+		delete(l.texts, instance)
+		err = l.sheet.Release(text)
 	}
-	l.sheet.SetTexture(texture)
+	instance.Remove()
 	return
 }
 
@@ -97,6 +109,9 @@ func (l *TextInstanceList) repackImage() (err error) {
 		newImage *sprites.PackedSheet
 		instance *render.Instance
 		sprite   *sprites.Sprite
+		text     string
+		exists   bool
+		texts    = map[*render.Instance]string{}
 	)
 	if glog.V(1) {
 		glog.Info("Repacking image")
@@ -105,20 +120,27 @@ func (l *TextInstanceList) repackImage() (err error) {
 		l.sheet.Width,
 		l.sheet.Height,
 	)
+	// Only instances still in the list are copied, which also frees text
+	// held by instances removed without calling Remove.
 	instance = l.Head()
 	for instance != nil {
-		if err = newImage.Copy(instance.Key, l.sheet); err != nil {
-			return
+		if text, exists = l.texts[instance]; exists {
+			if err = newImage.Copy(text, l.sheet); err != nil {
+				return
+			}
+			if sprite, err = newImage.Sheet.Sprite(text); err != nil {
+				return
+			}
+			texts[instance] = text
+			instance.Frame = sprite.Index()
+			instance.MarkChanged()
 		}
-		if sprite, err = newImage.Sheet.Sprite(instance.Key); err != nil {
-			return
-		}
-		instance.Frame = sprite.Index()
-		instance.MarkChanged()
 		instance = instance.Next()
 	}
+	l.texts = texts
+	l.sheet.Delete()
 	l.sheet = newImage
-	if err = l.generateTexture(); err != nil {
+	if err = l.sheet.UploadTexture(core.SmoothingLinear); err != nil {
 		return
 	}
 	if glog.V(1) {
@@ -0,0 +1,244 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+)
+
+type Alignment int
+
+const (
+	AlignLeft Alignment = iota
+	AlignCenter
+	AlignRight
+)
+
+type LayoutConfig struct {
+	MaxWidth    float32 // Lines wrap between words to fit this many pixels. Zero disables wrapping.
+	Align       Alignment
+	LineSpacing float32 // Multiple of the font's line height. Zero is single spacing.
+}
+
+type GlyphPosition struct {
+	Rune rune
+	Icon string // Sprite key, set instead of Rune for inline icons.
+	Tint mgl32.Vec4
+	Line int
+	X    float32 // Pen position on the baseline, in pixels from the top left.
+	Y    float32
+}
+
+type Layout struct {
+	Glyphs      []GlyphPosition
+	Lines       int
+	Width       float32 // Pixels.
+	Height      float32
+	LineMetrics []LineMetrics
+}
+
+type layoutItem struct {
+	r     rune
+	icon  string
+	width float32 // Icons only; glyphs use the font's advance.
+	tint  mgl32.Vec4
+}
+
+func advance(font Font, item layoutItem) float32 {
+	if item.icon != "" {
+		return item.width
+	}
+	return font.Advance(item.r)
+}
+
+func kern(font Font, a, b layoutItem) float32 {
+	if a.icon != "" || b.icon != "" {
+		return 0
+	}
+	return font.Kern(a.r, b.r)
+}
+
+func measure(font Font, line []layoutItem) (width float32) {
+	for i, item := range line {
+		if i > 0 {
+			width += kern(font, line[i-1], item)
+		}
+		width += advance(font, item)
+	}
+	return
+}
+
+// Returns the width in pixels of a single line, including kerning.
+func Measure(font Font, line string) float32 {
+	return measure(font, textItems(line, markupWhite))
+}
+
+func textItems(text string, tint mgl32.Vec4) (items []layoutItem) {
+	for _, r := range text {
+		items = append(items, layoutItem{r: r, tint: tint})
+	}
+	return
+}
+
+// Splits line so that each part fits within maxWidth, breaking at the last
+// space where possible and otherwise inside the word.
+func wrap(font Font, line []layoutItem, maxWidth float32) (lines [][]layoutItem) {
+	var (
+		start     int
+		lastSpace = -1
+		advances  = make([]float32, len(line))
+		pen       = make([]float32, len(line)) // Width of line[:i+1] unwrapped.
+	)
+	for i := 0; i < len(line); i++ {
+		advances[i] = advance(font, line[i])
+		pen[i] = advances[i]
+		if i > 0 {
+			pen[i] += pen[i-1] + kern(font, line[i-1], line[i])
+		}
+		if line[i].icon == "" && line[i].r == ' ' {
+			lastSpace = i
+		}
+		// The width of line[start:i+1], without the kerning into start.
+		if i == start || pen[i]-pen[start]+advances[start] <= maxWidth {
+			continue
+		}
+		if lastSpace > start {
+			lines = append(lines, line[start:lastSpace])
+			start = lastSpace + 1
+		} else {
+			lines = append(lines, line[start:i])
+			start = i
+		}
+		lastSpace = -1
+	}
+	return append(lines, line[start:])
+}
+
+func trimTrailingSpaces(line []layoutItem) []layoutItem {
+	for len(line) > 0 && line[len(line)-1].icon == "" && line[len(line)-1].r == ' ' {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// Positions each glyph of text, which may contain newlines.
+func LayoutText(font Font, text string, cfg LayoutConfig) Layout {
+	return layout(font, textItems(text, markupWhite), cfg)
+}
+
+// Positions each glyph and icon of parsed markup. Icons are sized from their
+// sprites in icons and sit on the baseline.
+func LayoutMarkup(font Font, runs []MarkupRun, icons *sprites.Sheet, cfg LayoutConfig) (out Layout, err error) {
+	var (
+		items  []layoutItem
+		sprite *sprites.Sprite
+	)
+	for _, run := range runs {
+		if run.Icon == "" {
+			items = append(items, textItems(run.Text, run.Tint)...)
+			continue
+		}
+		if icons == nil {
+			err = &MarkupError{Pos: run.Pos, Msg: "No icon sheet"}
+			return
+		}
+		if sprite, err = icons.Sprite(run.Icon); err != nil {
+			err = &MarkupError{Pos: run.Pos, Msg: err.Error()}
+			return
+		}
+		items = append(items, layoutItem{
+			icon:  run.Icon,
+			width: sprite.SourceSize().X(),
+			tint:  run.Tint,
+		})
+	}
+	out = layout(font, items, cfg)
+	return
+}
+
+func layout(font Font, items []layoutItem, cfg LayoutConfig) (out Layout) {
+	var (
+		lines      [][]layoutItem
+		widths     []float32
+		spacing    = cfg.LineSpacing
+		lineHeight float32
+		alignWidth float32
+		start      int
+		x          float32
+		y          float32
+	)
+	if spacing == 0 {
+		spacing = 1
+	}
+	lineHeight = font.LineHeight() * spacing
+	for i := 0; i <= len(items); i++ {
+		if i < len(items) && (items[i].icon != "" || items[i].r != '\n') {
+			continue
+		}
+		if cfg.MaxWidth > 0 {
+			lines = append(lines, wrap(font, items[start:i], cfg.MaxWidth)...)
+		} else {
+			lines = append(lines, items[start:i])
+		}
+		start = i + 1
+	}
+	widths = make([]float32, len(lines))
+	for i, line := range lines {
+		widths[i] = measure(font, trimTrailingSpaces(line))
+		if widths[i] > out.Width {
+			out.Width = widths[i]
+		}
+	}
+	if alignWidth = out.Width; cfg.MaxWidth > 0 {
+		alignWidth = cfg.MaxWidth
+	}
+	for i, line := range lines {
+		switch cfg.Align {
+		case AlignCenter:
+			x = (alignWidth - widths[i]) / 2
+		case AlignRight:
+			x = alignWidth - widths[i]
+		default:
+			x = 0
+		}
+		y = float32(i)*lineHeight + font.Ascent()
+		out.LineMetrics = append(out.LineMetrics, LineMetrics{
+			X:        x,
+			Width:    widths[i],
+			Top:      float32(i) * lineHeight,
+			Baseline: y,
+			Start:    len(out.Glyphs),
+			End:      len(out.Glyphs) + len(line),
+		})
+		for j, item := range line {
+			if j > 0 {
+				x += kern(font, line[j-1], item)
+			}
+			out.Glyphs = append(out.Glyphs, GlyphPosition{
+				Rune: item.r,
+				Icon: item.icon,
+				Tint: item.tint,
+				Line: i,
+				X:    x,
+				Y:    y,
+			})
+			x += advance(font, item)
+		}
+	}
+	out.Lines = len(lines)
+	out.Height = float32(len(lines)-1)*lineHeight + font.LineHeight()
+	return
+}
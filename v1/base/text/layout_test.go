@@ -0,0 +1,144 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Every glyph is 10 pixels wide, except that V tucks 2 pixels under A.
+type testFont struct{}
+
+func (testFont) LineHeight() float32  { return 20 }
+func (testFont) Ascent() float32      { return 15 }
+func (testFont) Descent() float32     { return 5 }
+func (testFont) Advance(rune) float32 { return 10 }
+
+func (testFont) Kern(a, b rune) float32 {
+	if a == 'A' && b == 'V' {
+		return -2
+	}
+	return 0
+}
+
+func TestWrap(t *testing.T) {
+	var tests = []struct {
+		text     string
+		maxWidth float32
+		want     []string
+	}{
+		{"hello world", 1000, []string{"hello world"}},
+		{"hello world", 60, []string{"hello", "world"}},
+		{"one two three", 70, []string{"one two", "three"}},
+		{"abcdefgh", 30, []string{"abc", "def", "gh"}},
+		{"AVAV", 36, []string{"AVAV"}},
+		{"AVAV", 35, []string{"AVA", "V"}},
+		{"a", 5, []string{"a"}},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, line := range wrap(testFont{}, textItems(test.text, markupWhite), test.maxWidth) {
+			var s []rune
+			for _, item := range line {
+				s = append(s, item.r)
+			}
+			got = append(got, string(s))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("wrap(%q, %v) = %q, want %q", test.text, test.maxWidth, got, test.want)
+		}
+	}
+}
+
+func TestLayoutText(t *testing.T) {
+	var tests = []struct {
+		name   string
+		text   string
+		cfg    LayoutConfig
+		width  float32
+		height float32
+		xs     []float32 // Of each glyph.
+		lines  []int
+	}{
+		{
+			name:   "newlines",
+			text:   "ab\nc",
+			width:  20,
+			height: 40,
+			xs:     []float32{0, 10, 0},
+			lines:  []int{0, 0, 1},
+		},
+		{
+			name:   "kerning",
+			text:   "AV",
+			width:  18,
+			height: 20,
+			xs:     []float32{0, 8},
+			lines:  []int{0, 0},
+		},
+		{
+			name:   "right",
+			text:   "ab\nc",
+			cfg:    LayoutConfig{Align: AlignRight},
+			width:  20,
+			height: 40,
+			xs:     []float32{0, 10, 10},
+			lines:  []int{0, 0, 1},
+		},
+		{
+			name:   "centered in the wrap width",
+			text:   "ab ",
+			cfg:    LayoutConfig{Align: AlignCenter, MaxWidth: 100},
+			width:  20,
+			height: 20,
+			xs:     []float32{40, 50, 60},
+			lines:  []int{0, 0, 0},
+		},
+		{
+			name:   "wrapped and spaced",
+			text:   "ab cd",
+			cfg:    LayoutConfig{MaxWidth: 25, LineSpacing: 2},
+			width:  20,
+			height: 60,
+			xs:     []float32{0, 10, 0, 10},
+			lines:  []int{0, 0, 1, 1},
+		},
+	}
+	for _, test := range tests {
+		var (
+			out     = LayoutText(testFont{}, test.text, test.cfg)
+			spacing = test.cfg.LineSpacing
+		)
+		if spacing == 0 {
+			spacing = 1
+		}
+		if out.Width != test.width || out.Height != test.height {
+			t.Errorf("%v: size = %vx%v, want %vx%v", test.name, out.Width, out.Height, test.width, test.height)
+		}
+		if len(out.Glyphs) != len(test.xs) {
+			t.Errorf("%v: %v glyphs, want %v", test.name, len(out.Glyphs), len(test.xs))
+			continue
+		}
+		for i, g := range out.Glyphs {
+			if g.X != test.xs[i] || g.Line != test.lines[i] {
+				t.Errorf("%v: glyph %v at %v on line %v, want %v on line %v", test.name, i, g.X, g.Line, test.xs[i], test.lines[i])
+			}
+			if want := float32(g.Line)*20*spacing + 15; g.Y != want {
+				t.Errorf("%v: glyph %v baseline %v, want %v", test.name, i, g.Y, want)
+			}
+		}
+	}
+}
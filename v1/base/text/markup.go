@@ -0,0 +1,157 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"strconv"
+	"strings"
+)
+
+// Reports where in the markup source parsing failed.
+type MarkupError struct {
+	Pos int // Byte offset into the source.
+	Msg string
+}
+
+func (e *MarkupError) Error() string {
+	return fmt.Sprintf("Markup error at %v: %v", e.Pos, e.Msg)
+}
+
+// A span of text drawn with one tint, or a single inline icon.
+type MarkupRun struct {
+	Pos  int // Byte offset into the source.
+	Text string
+	Icon string // Sprite key, set instead of Text for icons.
+	Tint mgl32.Vec4
+}
+
+var markupWhite = mgl32.Vec4{1, 1, 1, 1}
+
+// Parses text containing tags:
+//
+//	[color=#ff0]gold[/color]  Tints the enclosed text. Colors may be nested
+//	                          and use #rgb, #rgba, #rrggbb or #rrggbbaa.
+//	[icon=coin]               Draws the sprite coin inline.
+//	[[                        A literal [.
+func ParseMarkup(src string) (runs []MarkupRun, err error) {
+	var (
+		tints   = []mgl32.Vec4{markupWhite}
+		opens   []int
+		text    strings.Builder
+		textPos int
+		pos     int
+		end     int
+		tag     string
+		tint    mgl32.Vec4
+	)
+	var flush = func() {
+		if text.Len() > 0 {
+			runs = append(runs, MarkupRun{Pos: textPos, Text: text.String(), Tint: tints[len(tints)-1]})
+			text.Reset()
+		}
+	}
+	for pos < len(src) {
+		if src[pos] != '[' {
+			if text.Len() == 0 {
+				textPos = pos
+			}
+			text.WriteByte(src[pos])
+			pos++
+			continue
+		}
+		if strings.HasPrefix(src[pos:], "[[") {
+			if text.Len() == 0 {
+				textPos = pos
+			}
+			text.WriteByte('[')
+			pos += 2
+			continue
+		}
+		if end = strings.IndexByte(src[pos:], ']'); end == -1 {
+			err = &MarkupError{Pos: pos, Msg: "Unterminated tag"}
+			return
+		}
+		tag = src[pos+1 : pos+end]
+		flush()
+		switch {
+		case strings.HasPrefix(tag, "color="):
+			if tint, err = parseMarkupColor(tag[len("color="):]); err != nil {
+				err = &MarkupError{Pos: pos, Msg: err.Error()}
+				return
+			}
+			tints = append(tints, tint)
+			opens = append(opens, pos)
+		case tag == "/color":
+			if len(opens) == 0 {
+				err = &MarkupError{Pos: pos, Msg: "[/color] without matching [color]"}
+				return
+			}
+			tints = tints[:len(tints)-1]
+			opens = opens[:len(opens)-1]
+		case strings.HasPrefix(tag, "icon="):
+			if tag == "icon=" {
+				err = &MarkupError{Pos: pos, Msg: "Missing icon name"}
+				return
+			}
+			runs = append(runs, MarkupRun{Pos: pos, Icon: tag[len("icon="):], Tint: tints[len(tints)-1]})
+		default:
+			err = &MarkupError{Pos: pos, Msg: fmt.Sprintf("Unknown tag [%v]", tag)}
+			return
+		}
+		pos += end + 1
+	}
+	flush()
+	if len(opens) > 0 {
+		err = &MarkupError{Pos: opens[len(opens)-1], Msg: "[color] is never closed"}
+		return
+	}
+	return
+}
+
+func parseMarkupColor(s string) (out mgl32.Vec4, err error) {
+	var (
+		digits = strings.TrimPrefix(s, "#")
+		value  uint64
+		width  uint
+	)
+	if digits == s {
+		err = fmt.Errorf("Color %v must start with #", s)
+		return
+	}
+	switch len(digits) {
+	case 3, 4:
+		width = 4
+	case 6, 8:
+		width = 8
+	default:
+		err = fmt.Errorf("Invalid color %v", s)
+		return
+	}
+	if value, err = strconv.ParseUint(digits, 16, 32); err != nil {
+		err = fmt.Errorf("Invalid color %v", s)
+		return
+	}
+	out = markupWhite
+	var (
+		count = len(digits) / int(width/4)
+		max   = float32(uint64(1)<<width - 1)
+	)
+	for i := 0; i < count; i++ {
+		out[i] = float32(value>>(width*uint(count-1-i))&(1<<width-1)) / max
+	}
+	return
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"reflect"
+	"testing"
+)
+
+func TestParseMarkup(t *testing.T) {
+	var (
+		red  = mgl32.Vec4{1, 0, 0, 1}
+		gold = mgl32.Vec4{1, 1, 0, 1}
+	)
+	var tests = []struct {
+		src  string
+		want []MarkupRun
+	}{
+		{"", nil},
+		{"plain", []MarkupRun{{Pos: 0, Text: "plain", Tint: markupWhite}}},
+		{"a [[b]", []MarkupRun{{Pos: 0, Text: "a [b]", Tint: markupWhite}}},
+		{"x[color=#f00]red[/color]y", []MarkupRun{
+			{Pos: 0, Text: "x", Tint: markupWhite},
+			{Pos: 13, Text: "red", Tint: red},
+			{Pos: 24, Text: "y", Tint: markupWhite},
+		}},
+		{"[color=#ff0][color=#ff0000ff]r[/color]g[/color]", []MarkupRun{
+			{Pos: 29, Text: "r", Tint: red},
+			{Pos: 38, Text: "g", Tint: gold},
+		}},
+		{"[color=#f008][icon=coin][/color]", []MarkupRun{
+			{Pos: 13, Icon: "coin", Tint: mgl32.Vec4{1, 0, 0, float32(8) / 15}},
+		}},
+	}
+	for _, test := range tests {
+		runs, err := ParseMarkup(test.src)
+		if err != nil {
+			t.Errorf("ParseMarkup(%q): %v", test.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(runs, test.want) {
+			t.Errorf("ParseMarkup(%q) = %+v, want %+v", test.src, runs, test.want)
+		}
+	}
+}
+
+func TestParseMarkupErrors(t *testing.T) {
+	var tests = []struct {
+		src string
+		pos int
+	}{
+		{"ab[color=#f00", 2},
+		{"[color=red]x[/color]", 0},
+		{"[color=#ff]x[/color]", 0},
+		{"[color=#ggg]x[/color]", 0},
+		{"x[/color]", 1},
+		{"[color=#fff]a[color=#000]b[/color]", 0},
+		{"[color=#fff]a[/color][color=#000]b", 21},
+		{"[icon=]", 0},
+		{"ok [bold]", 3},
+	}
+	for _, test := range tests {
+		_, err := ParseMarkup(test.src)
+		if e, ok := err.(*MarkupError); !ok {
+			t.Errorf("ParseMarkup(%q) returned %v, want a MarkupError", test.src, err)
+		} else if e.Pos != test.pos {
+			t.Errorf("ParseMarkup(%q) failed at %v, want %v: %v", test.src, e.Pos, test.pos, e)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"github.com/pikkpoiss/gamejam/v1/base/sprites"
+)
+
+// Placement of one laid out line, in pixels from the top left of the text.
+type LineMetrics struct {
+	X        float32 // Offset from alignment.
+	Width    float32 // Excludes trailing spaces.
+	Top      float32
+	Baseline float32
+	Start    int // Range of the line's entries in Layout.Glyphs.
+	End      int
+}
+
+type TextMetrics struct {
+	Width      float32
+	Height     float32
+	Ascent     float32
+	Descent    float32
+	LineHeight float32 // Baseline to baseline, including line spacing.
+	Lines      []LineMetrics
+}
+
+func (l Layout) Metrics(font Font, cfg LayoutConfig) (m TextMetrics) {
+	var spacing = cfg.LineSpacing
+	if spacing == 0 {
+		spacing = 1
+	}
+	m = TextMetrics{
+		Width:      l.Width,
+		Height:     l.Height,
+		Ascent:     font.Ascent(),
+		Descent:    font.Descent(),
+		LineHeight: font.LineHeight() * spacing,
+		Lines:      make([]LineMetrics, len(l.LineMetrics)),
+	}
+	copy(m.Lines, l.LineMetrics)
+	return
+}
+
+// Returns the metrics converted from pixels to world units, where a world
+// unit spans pixelsPerUnit pixels.
+func (m TextMetrics) World(pixelsPerUnit float32) (out TextMetrics) {
+	var scale = 1 / pixelsPerUnit
+	out = TextMetrics{
+		Width:      m.Width * scale,
+		Height:     m.Height * scale,
+		Ascent:     m.Ascent * scale,
+		Descent:    m.Descent * scale,
+		LineHeight: m.LineHeight * scale,
+		Lines:      make([]LineMetrics, len(m.Lines)),
+	}
+	for i, line := range m.Lines {
+		out.Lines[i] = LineMetrics{
+			X:        line.X * scale,
+			Width:    line.Width * scale,
+			Top:      line.Top * scale,
+			Baseline: line.Baseline * scale,
+			Start:    line.Start,
+			End:      line.End,
+		}
+	}
+	return
+}
+
+// Measures text, which may contain newlines, without creating any glyphs.
+func MeasureText(font Font, text string, cfg LayoutConfig) TextMetrics {
+	return LayoutText(font, text, cfg).Metrics(font, cfg)
+}
+
+func MeasureMarkup(font Font, runs []MarkupRun, icons *sprites.Sheet, cfg LayoutConfig) (m TextMetrics, err error) {
+	var out Layout
+	if out, err = LayoutMarkup(font, runs, icons, cfg); err != nil {
+		return
+	}
+	m = out.Metrics(font, cfg)
+	return
+}
@@ -0,0 +1,213 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"math"
+)
+
+type SDFConfig struct {
+	Spread  int // Pixels of distance encoded either side of each outline.
+	Upscale int // Glyphs are rasterized this many times larger, then reduced.
+}
+
+func (c SDFConfig) withDefaults() SDFConfig {
+	if c.Spread <= 0 {
+		c.Spread = 4
+	}
+	if c.Upscale <= 0 {
+		c.Upscale = 4
+	}
+	return c
+}
+
+// Generates signed distance field glyphs from a font's outlines.
+type sdfGenerator struct {
+	cfg   SDFConfig
+	chain []*FontFace
+	faces []font.Face // Rasterize chain at Upscale times the font size.
+}
+
+func newSDFGenerator(ff *FontFace, cfg SDFConfig) (g *sdfGenerator) {
+	cfg = cfg.withDefaults()
+	g = &sdfGenerator{
+		cfg:   cfg,
+		chain: append([]*FontFace{ff}, ff.fallbacks...),
+	}
+	for _, f := range g.chain {
+		g.faces = append(g.faces, truetype.NewFace(f.font, &truetype.Options{
+			Size: float64(ff.points * float32(cfg.Upscale)),
+			DPI:  float64(ff.dpi),
+		}))
+	}
+	return
+}
+
+// Returns the first face in the chain with a glyph for r, as with
+// FontFace fallbacks.
+func (g *sdfGenerator) face(r rune) font.Face {
+	for i, f := range g.chain {
+		if f.HasGlyph(r) {
+			return g.faces[i]
+		}
+	}
+	return g.faces[0]
+}
+
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
+
+func ceilDiv(a, b int) int {
+	return -floorDiv(-a, b)
+}
+
+// Returns r as a field in the alpha channel of a white image: 0.5 on the
+// outline, rising to 1 at Spread pixels inside and falling to 0 at Spread
+// pixels outside. offset is relative to the pen as with FontFace.GlyphImage.
+func (g *sdfGenerator) glyph(r rune) (img *image.RGBA, offset image.Point, ok bool) {
+	var (
+		up      = g.cfg.Upscale
+		pad     = g.cfg.Spread * up
+		dr      image.Rectangle
+		mask    image.Image
+		maskp   image.Point
+		area    image.Rectangle
+		w, h    int
+		inside  []bool
+		x, y    int
+		dx, dy  int
+		sum     float64
+		dist    float64
+		spread  = float64(g.cfg.Spread)
+		scale   = float64(up)
+		samples = float64(up * up)
+	)
+	if dr, mask, maskp, _, ok = g.face(r).Glyph(fixed.Point26_6{}, r); !ok || dr.Empty() {
+		ok = false
+		return
+	}
+	// Align the high resolution area to whole output pixels from the pen.
+	area = image.Rect(
+		floorDiv(dr.Min.X-pad, up)*up,
+		floorDiv(dr.Min.Y-pad, up)*up,
+		ceilDiv(dr.Max.X+pad, up)*up,
+		ceilDiv(dr.Max.Y+pad, up)*up,
+	)
+	w, h = area.Dx(), area.Dy()
+	inside = make([]bool, w*h)
+	for y = dr.Min.Y; y < dr.Max.Y; y++ {
+		for x = dr.Min.X; x < dr.Max.X; x++ {
+			_, _, _, a := mask.At(maskp.X+x-dr.Min.X, maskp.Y+y-dr.Min.Y).RGBA()
+			inside[(y-area.Min.Y)*w+x-area.Min.X] = a >= 0x8000
+		}
+	}
+	var (
+		toInside  = distanceTransform(inside, w, h, true)
+		toOutside = distanceTransform(inside, w, h, false)
+	)
+	img = image.NewRGBA(image.Rect(0, 0, w/up, h/up))
+	for y = 0; y < h/up; y++ {
+		for x = 0; x < w/up; x++ {
+			sum = 0
+			for dy = 0; dy < up; dy++ {
+				for dx = 0; dx < up; dx++ {
+					i := (y*up+dy)*w + x*up + dx
+					sum += math.Sqrt(toInside[i]) - math.Sqrt(toOutside[i])
+				}
+			}
+			// Positive outside the glyph, in output pixels.
+			dist = sum / samples / scale
+			a := math.Max(0, math.Min(1, 0.5-dist/(2*spread)))
+			img.SetRGBA(x, y, color.RGBA{255, 255, 255, uint8(a*255 + 0.5)})
+		}
+	}
+	offset = image.Pt(area.Min.X/up, area.Min.Y/up)
+	return
+}
+
+// Returns the squared distance from each pixel to the nearest pixel where
+// inside equals target, which is zero for pixels that already match.
+func distanceTransform(inside []bool, w, h int, target bool) (out []float64) {
+	var (
+		inf     = float64(w*w + h*h)
+		column  = make([]float64, h)
+		row     = make([]float64, w)
+		scratch = make([]float64, h)
+		x, y    int
+	)
+	out = make([]float64, w*h)
+	for i, in := range inside {
+		if in != target {
+			out[i] = inf
+		}
+	}
+	for y = 0; y < h; y++ {
+		distanceTransform1D(out[y*w:(y+1)*w], row)
+	}
+	for x = 0; x < w; x++ {
+		for y = 0; y < h; y++ {
+			column[y] = out[y*w+x]
+		}
+		distanceTransform1D(column, scratch)
+		for y = 0; y < h; y++ {
+			out[y*w+x] = column[y]
+		}
+	}
+	return
+}
+
+// Felzenszwalb and Huttenlocher's lower envelope of parabolas, in place.
+// scratch must be as long as f.
+func distanceTransform1D(f []float64, scratch []float64) {
+	var (
+		n = len(f)
+		v = make([]int, n)
+		z = make([]float64, n+1)
+		k int
+		s float64
+	)
+	copy(scratch, f)
+	var intersect = func(q, p int) float64 {
+		return ((scratch[q] + float64(q*q)) - (scratch[p] + float64(p*p))) / float64(2*q-2*p)
+	}
+	z[0], z[1] = math.Inf(-1), math.Inf(1)
+	for q := 1; q < n; q++ {
+		s = intersect(q, v[k])
+		for s <= z[k] {
+			k--
+			s = intersect(q, v[k])
+		}
+		k++
+		v[k] = q
+		z[k] = s
+		z[k+1] = math.Inf(1)
+	}
+	k = 0
+	for q := 0; q < n; q++ {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		f[q] = float64((q-v[k])*(q-v[k])) + scratch[v[k]]
+	}
+}
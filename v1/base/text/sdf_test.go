@@ -0,0 +1,100 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"testing"
+)
+
+// A 7x7 mask with a 3x3 square from (2, 2) to (4, 4).
+func squareMask() (inside []bool, w, h int) {
+	w, h = 7, 7
+	inside = make([]bool, w*h)
+	for y := 2; y <= 4; y++ {
+		for x := 2; x <= 4; x++ {
+			inside[y*w+x] = true
+		}
+	}
+	return
+}
+
+// Squared distance to the nearest matching pixel by checking every pixel.
+func bruteDistance(inside []bool, w, h, x, y int, target bool) (best float64) {
+	best = float64(w*w + h*h)
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			if inside[j*w+i] != target {
+				continue
+			}
+			if d := float64((i-x)*(i-x) + (j-y)*(j-y)); d < best {
+				best = d
+			}
+		}
+	}
+	return
+}
+
+func TestDistanceTransformSquare(t *testing.T) {
+	var (
+		inside, w, h = squareMask()
+		toInside     = distanceTransform(inside, w, h, true)
+		toOutside    = distanceTransform(inside, w, h, false)
+		tests        = []struct {
+			x, y      int
+			toInside  float64
+			toOutside float64
+		}{
+			{3, 3, 0, 4}, // Center, two pixels from the nearest edge outside.
+			{2, 2, 0, 1}, // Corner of the square.
+			{2, 3, 0, 1},
+			{0, 0, 8, 0}, // Diagonal to the corner at (2, 2).
+			{0, 3, 4, 0},
+			{1, 1, 2, 0},
+			{6, 6, 8, 0},
+			{5, 3, 1, 0},
+		}
+	)
+	for _, test := range tests {
+		i := test.y*w + test.x
+		if toInside[i] != test.toInside || toOutside[i] != test.toOutside {
+			t.Errorf("(%v, %v): got %v and %v, want %v and %v", test.x, test.y,
+				toInside[i], toOutside[i], test.toInside, test.toOutside)
+		}
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if want := bruteDistance(inside, w, h, x, y, true); toInside[i] != want {
+				t.Errorf("(%v, %v): got %v to inside, want %v", x, y, toInside[i], want)
+			}
+			if want := bruteDistance(inside, w, h, x, y, false); toOutside[i] != want {
+				t.Errorf("(%v, %v): got %v to outside, want %v", x, y, toOutside[i], want)
+			}
+		}
+	}
+}
+
+// With nothing to reach, every pixel is further than the diagonal.
+func TestDistanceTransformEmpty(t *testing.T) {
+	var (
+		inside = make([]bool, 4*3)
+		out    = distanceTransform(inside, 4, 3, true)
+	)
+	for i, d := range out {
+		if d < 4*4+3*3 {
+			t.Fatalf("Pixel %v: got %v", i, d)
+		}
+	}
+}
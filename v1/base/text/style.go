@@ -0,0 +1,225 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Effects baked into rasterized text. Nil colors disable the matching effect.
+type FontStyle struct {
+	Foreground     color.Color // Fill, or the top of the gradient. Defaults to white.
+	GradientBottom color.Color // Fill at the bottom of the line, blended from Foreground.
+	Background     color.Color // Behind whole strings. Transparent if nil.
+	OutlineWidth   int         // Pixels.
+	OutlineColor   color.Color
+	ShadowOffset   image.Point // Pixels, with y down.
+	ShadowBlur     int         // Box blur radius in pixels. Negative is treated as zero.
+	ShadowColor    color.Color
+}
+
+type styleColor struct {
+	r, g, b, a float64
+}
+
+func newStyleColor(c color.Color) (out styleColor) {
+	var n = color.NRGBAModel.Convert(c).(color.NRGBA)
+	return styleColor{
+		float64(n.R) / 255,
+		float64(n.G) / 255,
+		float64(n.B) / 255,
+		float64(n.A) / 255,
+	}
+}
+
+func (c styleColor) lerp(d styleColor, t float64) styleColor {
+	return styleColor{
+		c.r + (d.r-c.r)*t,
+		c.g + (d.g-c.g)*t,
+		c.b + (d.b-c.b)*t,
+		c.a + (d.a-c.a)*t,
+	}
+}
+
+// Coverage values over a rectangle, zero outside it.
+type coverage struct {
+	rect image.Rectangle
+	data []float64
+}
+
+func newCoverage(rect image.Rectangle) *coverage {
+	return &coverage{rect: rect, data: make([]float64, rect.Dx()*rect.Dy())}
+}
+
+func (c *coverage) at(x, y int) float64 {
+	if !(image.Point{x, y}).In(c.rect) {
+		return 0
+	}
+	return c.data[(y-c.rect.Min.Y)*c.rect.Dx()+x-c.rect.Min.X]
+}
+
+func (c *coverage) set(x, y int, v float64) {
+	c.data[(y-c.rect.Min.Y)*c.rect.Dx()+x-c.rect.Min.X] = v
+}
+
+// Grows c by radius pixels, taking the maximum coverage within a disc.
+func (c *coverage) dilate(radius int) (out *coverage) {
+	var v float64
+	out = newCoverage(c.rect.Inset(-radius))
+	for y := out.rect.Min.Y; y < out.rect.Max.Y; y++ {
+		for x := out.rect.Min.X; x < out.rect.Max.X; x++ {
+			v = 0
+			for dy := -radius; dy <= radius; dy++ {
+				for dx := -radius; dx <= radius; dx++ {
+					if dx*dx+dy*dy <= radius*radius {
+						v = math.Max(v, c.at(x+dx, y+dy))
+					}
+				}
+			}
+			out.set(x, y, v)
+		}
+	}
+	return
+}
+
+// Returns c moved by offset and blurred with a box of the given radius,
+// applied horizontally and then vertically.
+func (c *coverage) shadow(offset image.Point, radius int) (out *coverage) {
+	if radius < 0 {
+		radius = 0
+	}
+	var (
+		moved = &coverage{rect: c.rect.Add(offset), data: c.data}
+		horiz = newCoverage(moved.rect.Inset(-radius))
+		size  = float64(2*radius + 1)
+		sum   float64
+	)
+	out = newCoverage(horiz.rect)
+	for y := horiz.rect.Min.Y; y < horiz.rect.Max.Y; y++ {
+		for x := horiz.rect.Min.X; x < horiz.rect.Max.X; x++ {
+			sum = 0
+			for d := -radius; d <= radius; d++ {
+				sum += moved.at(x+d, y)
+			}
+			horiz.set(x, y, sum/size)
+		}
+	}
+	for y := out.rect.Min.Y; y < out.rect.Max.Y; y++ {
+		for x := out.rect.Min.X; x < out.rect.Max.X; x++ {
+			sum = 0
+			for d := -radius; d <= radius; d++ {
+				sum += horiz.at(x, y+d)
+			}
+			out.set(x, y, sum/size)
+		}
+	}
+	return
+}
+
+// Draws mask with the style's effects. baseline is the y of the baseline in
+// mask, and ascent and descent size the line for gradients. The image grows
+// to fit outlines and shadows, and origin is where mask's top left corner
+// lands in it.
+func (s FontStyle) render(mask *image.Alpha, baseline int, ascent, descent float32, background bool) (out *image.RGBA, origin image.Point) {
+	var (
+		bounds  = mask.Bounds()
+		fill    = newCoverage(bounds)
+		outline *coverage
+		shadow  *coverage
+		rect    = bounds
+		top     = newStyleColor(color.White)
+		bottom  styleColor
+		lineTop = float64(baseline) - float64(ascent)
+		lineH   = float64(ascent + descent)
+		bg      styleColor // Premultiplied.
+	)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			fill.set(x, y, float64(mask.AlphaAt(x, y).A)/255)
+		}
+	}
+	if s.OutlineWidth > 0 && s.OutlineColor != nil {
+		outline = fill.dilate(s.OutlineWidth)
+		rect = rect.Union(outline.rect)
+	}
+	if s.ShadowColor != nil {
+		if outline != nil {
+			shadow = outline.shadow(s.ShadowOffset, s.ShadowBlur)
+		} else {
+			shadow = fill.shadow(s.ShadowOffset, s.ShadowBlur)
+		}
+		rect = rect.Union(shadow.rect)
+	}
+	if s.Foreground != nil {
+		top = newStyleColor(s.Foreground)
+	}
+	if bottom = top; s.GradientBottom != nil {
+		bottom = newStyleColor(s.GradientBottom)
+	}
+	if background && s.Background != nil {
+		c := newStyleColor(s.Background)
+		bg = styleColor{c.r * c.a, c.g * c.a, c.b * c.a, c.a}
+	}
+	var layers = []struct {
+		cover *coverage
+		color styleColor
+	}{
+		{shadow, styleColor{}},
+		{outline, styleColor{}},
+		{fill, top},
+	}
+	if shadow != nil {
+		layers[0].color = newStyleColor(s.ShadowColor)
+	}
+	if outline != nil {
+		layers[1].color = newStyleColor(s.OutlineColor)
+	}
+	out = image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	origin = bounds.Min.Sub(rect.Min)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		if lineH > 0 {
+			t := math.Max(0, math.Min(1, (float64(y)+0.5-lineTop)/lineH))
+			layers[2].color = top.lerp(bottom, t)
+		}
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			// Premultiplied, composited back to front.
+			var dst = bg
+			for _, layer := range layers {
+				if layer.cover == nil {
+					continue
+				}
+				var (
+					c = layer.color
+					a = c.a * layer.cover.at(x, y)
+				)
+				dst = styleColor{
+					c.r*a + dst.r*(1-a),
+					c.g*a + dst.g*(1-a),
+					c.b*a + dst.b*(1-a),
+					a + dst.a*(1-a),
+				}
+			}
+			out.SetRGBA(x-rect.Min.X, y-rect.Min.Y, color.RGBA{
+				uint8(dst.r*255 + 0.5),
+				uint8(dst.g*255 + 0.5),
+				uint8(dst.b*255 + 0.5),
+				uint8(dst.a*255 + 0.5),
+			})
+		}
+	}
+	return
+}
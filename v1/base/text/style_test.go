@@ -0,0 +1,165 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// A 3x3 mask with only its center pixel set, standing in for a glyph.
+func dotMask() *image.Alpha {
+	var mask = image.NewAlpha(image.Rect(0, 0, 3, 3))
+	mask.SetAlpha(1, 1, color.Alpha{255})
+	return mask
+}
+
+// A full 1x4 column, for gradients.
+func columnMask() *image.Alpha {
+	var mask = image.NewAlpha(image.Rect(0, 0, 1, 4))
+	for y := 0; y < 4; y++ {
+		mask.SetAlpha(0, y, color.Alpha{255})
+	}
+	return mask
+}
+
+func TestFontStyleRender(t *testing.T) {
+	var (
+		clear = color.RGBA{}
+		white = color.RGBA{255, 255, 255, 255}
+		red   = color.RGBA{255, 0, 0, 255}
+		black = color.RGBA{0, 0, 0, 255}
+		blue  = color.RGBA{0, 0, 255, 255}
+	)
+	var tests = []struct {
+		name       string
+		mask       *image.Alpha
+		style      FontStyle
+		background bool
+		size       image.Point
+		origin     image.Point
+		pixels     map[image.Point]color.RGBA
+	}{
+		{
+			name: "plain",
+			mask: dotMask(),
+			size: image.Pt(3, 3),
+			pixels: map[image.Point]color.RGBA{
+				{1, 1}: white,
+				{0, 1}: clear,
+			},
+		},
+		{
+			name:   "outline dilates by a disc",
+			mask:   dotMask(),
+			style:  FontStyle{OutlineWidth: 1, OutlineColor: red},
+			size:   image.Pt(5, 5),
+			origin: image.Pt(1, 1),
+			pixels: map[image.Point]color.RGBA{
+				{2, 2}: white,
+				{1, 2}: red,
+				{3, 2}: red,
+				{2, 1}: red,
+				{2, 3}: red,
+				{1, 1}: clear,
+				{0, 2}: clear,
+			},
+		},
+		{
+			name:  "shadow offset",
+			mask:  dotMask(),
+			style: FontStyle{ShadowOffset: image.Pt(2, 1), ShadowColor: black},
+			size:  image.Pt(5, 4),
+			pixels: map[image.Point]color.RGBA{
+				{1, 1}: white,
+				{3, 2}: black,
+				{2, 2}: clear,
+				{3, 1}: clear,
+			},
+		},
+		{
+			name:   "shadow blur",
+			mask:   dotMask(),
+			style:  FontStyle{ShadowBlur: 1, ShadowColor: black},
+			size:   image.Pt(5, 5),
+			origin: image.Pt(1, 1),
+			pixels: map[image.Point]color.RGBA{
+				{2, 2}: white,
+				{1, 2}: {0, 0, 0, 28}, // A ninth of the dot.
+				{1, 1}: {0, 0, 0, 28},
+				{0, 2}: clear,
+			},
+		},
+		{
+			name:  "negative shadow blur",
+			mask:  dotMask(),
+			style: FontStyle{ShadowBlur: -2, ShadowOffset: image.Pt(1, 0), ShadowColor: black},
+			size:  image.Pt(4, 3),
+			pixels: map[image.Point]color.RGBA{
+				{1, 1}: white,
+				{2, 1}: black,
+			},
+		},
+		{
+			name:  "gradient",
+			mask:  columnMask(),
+			style: FontStyle{Foreground: color.White, GradientBottom: color.Black},
+			size:  image.Pt(1, 4),
+			pixels: map[image.Point]color.RGBA{
+				{0, 0}: {223, 223, 223, 255},
+				{0, 3}: {32, 32, 32, 255},
+			},
+		},
+		{
+			name:       "background",
+			mask:       dotMask(),
+			style:      FontStyle{Foreground: red, Background: blue},
+			background: true,
+			size:       image.Pt(3, 3),
+			pixels: map[image.Point]color.RGBA{
+				{1, 1}: red,
+				{0, 0}: blue,
+			},
+		},
+		{
+			name:  "background left out",
+			mask:  dotMask(),
+			style: FontStyle{Background: blue},
+			size:  image.Pt(3, 3),
+			pixels: map[image.Point]color.RGBA{
+				{0, 0}: clear,
+			},
+		},
+	}
+	for _, test := range tests {
+		var (
+			height      = test.mask.Bounds().Dy()
+			out, origin = test.style.render(test.mask, height, float32(height), 0, test.background)
+		)
+		if size := out.Bounds().Size(); size != test.size {
+			t.Errorf("%v: size = %v, want %v", test.name, size, test.size)
+			continue
+		}
+		if origin != test.origin {
+			t.Errorf("%v: origin = %v, want %v", test.name, origin, test.origin)
+		}
+		for p, want := range test.pixels {
+			if got := out.RGBAAt(p.X, p.Y); got != want {
+				t.Errorf("%v: pixel %v = %v, want %v", test.name, p, got, want)
+			}
+		}
+	}
+}
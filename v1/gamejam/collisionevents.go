@@ -0,0 +1,47 @@
+// Copyright 2016 Pikkpoiss
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamejam
+
+import (
+	"github.com/pikkpoiss/gamejam/v1/base/collision"
+)
+
+type CollisionEventObserver interface {
+	OnCollisionBegin(event collision.BeginEvent)
+	OnCollisionStay(event collision.StayEvent)
+	OnCollisionEnd(event collision.EndEvent)
+}
+
+// Sends the overlap events of world to events on each Step.
+func NotifyCollisionEvents(world *collision.World, events Events) {
+	world.SetEventHandler(func(event interface{}) {
+		events.Notify(event)
+	})
+}
+
+func BindCollisionEvents(events Events, obs CollisionEventObserver) (id EventObserverID) {
+	id = events.AddEventObserver(func(evt Event) {
+		switch event := evt.(type) {
+		case collision.BeginEvent:
+			obs.OnCollisionBegin(event)
+		case collision.StayEvent:
+			obs.OnCollisionStay(event)
+		case collision.EndEvent:
+			obs.OnCollisionEnd(event)
+		}
+		return
+	})
+	return
+}
@@ -16,6 +16,7 @@ package gamejam
 
 import (
 	"fmt"
+	"github.com/pikkpoiss/gamejam/v1/base/audio"
 	"github.com/pikkpoiss/gamejam/v1/base/core"
 	"github.com/pikkpoiss/gamejam/v1/base/loaders"
 	"github.com/pikkpoiss/gamejam/v1/base/render"
@@ -47,6 +48,19 @@ func (t SheetType) Key() ResourceKey {
 	return t.key
 }
 
+type SoundType struct {
+	*audio.Sound
+	key ResourceKey
+}
+
+func (t SoundType) Key() ResourceKey {
+	return t.key
+}
+
+// Decoded sounds hold no GPU or file handles, so there is nothing to free.
+func (t SoundType) Delete() {
+}
+
 type ResourceLoader interface {
 	Key() ResourceKey
 	Load(resources Resources) (res ResourceType, err error)
@@ -65,7 +79,7 @@ func NewTexturePackerSheetLoader(path string, smoothing TextureSmoothing) *Textu
 }
 
 func (l *TexturePackerSheetLoader) Key() ResourceKey {
-	return ResourceKey(fmt.Sprintf("%v-%v", l.jsonPath, l.smoothing))
+	return ResourceKey(fmt.Sprintf("texturepacker:%v-%v", l.jsonPath, l.smoothing))
 }
 
 func (l *TexturePackerSheetLoader) Load(resources Resources) (res ResourceType, err error) {
@@ -83,6 +97,174 @@ func (l *TexturePackerSheetLoader) Load(resources Resources) (res ResourceType,
 	return
 }
 
+// Loads a sheet from a single file, such as an atlas description, with one
+// of the loaders in the loaders package.
+type sheetFileLoader struct {
+	kind      string // Keeps keys apart when formats share a path.
+	path      string
+	smoothing TextureSmoothing
+	load      func(path string, smoothing core.TextureSmoothing) (*sprites.Sheet, error)
+}
+
+func (l *sheetFileLoader) Key() ResourceKey {
+	return ResourceKey(fmt.Sprintf("%v:%v-%v", l.kind, l.path, l.smoothing))
+}
+
+func (l *sheetFileLoader) Load(resources Resources) (res ResourceType, err error) {
+	var sheet *sprites.Sheet
+	if sheet, err = l.load(l.path, core.TextureSmoothing(l.smoothing)); err != nil {
+		return
+	}
+	res = SheetType{
+		Sheet: sheet,
+		key:   l.Key(),
+	}
+	return
+}
+
+type AsepriteSheetLoader struct {
+	sheetFileLoader
+}
+
+func NewAsepriteSheetLoader(path string, smoothing TextureSmoothing) *AsepriteSheetLoader {
+	return &AsepriteSheetLoader{sheetFileLoader{
+		kind:      "aseprite",
+		path:      path,
+		smoothing: smoothing,
+		load:      loaders.NewAsepriteLoader().Load,
+	}}
+}
+
+type LibGDXSheetLoader struct {
+	sheetFileLoader
+}
+
+func NewLibGDXSheetLoader(path string, smoothing TextureSmoothing) *LibGDXSheetLoader {
+	return &LibGDXSheetLoader{sheetFileLoader{
+		kind:      "libgdx",
+		path:      path,
+		smoothing: smoothing,
+		load:      loaders.NewLibGDXLoader().Load,
+	}}
+}
+
+type SparrowSheetLoader struct {
+	sheetFileLoader
+}
+
+func NewSparrowSheetLoader(path string, smoothing TextureSmoothing) *SparrowSheetLoader {
+	return &SparrowSheetLoader{sheetFileLoader{
+		kind:      "sparrow",
+		path:      path,
+		smoothing: smoothing,
+		load:      loaders.NewSparrowLoader().Load,
+	}}
+}
+
+type GridConfig loaders.GridConfig
+
+type GridSheetLoader struct {
+	pngPath   string
+	cfg       GridConfig
+	smoothing TextureSmoothing
+}
+
+func NewGridSheetLoader(path string, cfg GridConfig, smoothing TextureSmoothing) *GridSheetLoader {
+	return &GridSheetLoader{
+		pngPath:   path,
+		cfg:       cfg,
+		smoothing: smoothing,
+	}
+}
+
+func (l *GridSheetLoader) Key() ResourceKey {
+	return ResourceKey(fmt.Sprintf("grid:%v-%+v-%v", l.pngPath, l.cfg, l.smoothing))
+}
+
+func (l *GridSheetLoader) Load(resources Resources) (res ResourceType, err error) {
+	var (
+		loader = loaders.NewGridLoader()
+		sheet  *sprites.Sheet
+	)
+	if sheet, err = loader.Load(
+		l.pngPath,
+		loaders.GridConfig(l.cfg),
+		core.TextureSmoothing(l.smoothing),
+	); err != nil {
+		return
+	}
+	res = SheetType{
+		Sheet: sheet,
+		key:   l.Key(),
+	}
+	return
+}
+
+type DirectoryConfig loaders.DirectoryConfig
+
+type DirectorySheetLoader struct {
+	dir       string
+	cfg       DirectoryConfig
+	smoothing TextureSmoothing
+}
+
+func NewDirectorySheetLoader(dir string, cfg DirectoryConfig, smoothing TextureSmoothing) *DirectorySheetLoader {
+	return &DirectorySheetLoader{
+		dir:       dir,
+		cfg:       cfg,
+		smoothing: smoothing,
+	}
+}
+
+func (l *DirectorySheetLoader) Key() ResourceKey {
+	return ResourceKey(fmt.Sprintf("directory:%v-%+v-%v", l.dir, l.cfg, l.smoothing))
+}
+
+func (l *DirectorySheetLoader) Load(resources Resources) (res ResourceType, err error) {
+	var (
+		loader = loaders.NewDirectoryLoader()
+		sheet  *sprites.Sheet
+	)
+	if sheet, err = loader.LoadDir(
+		l.dir,
+		loaders.DirectoryConfig(l.cfg),
+		core.TextureSmoothing(l.smoothing),
+	); err != nil {
+		return
+	}
+	res = SheetType{
+		Sheet: sheet,
+		key:   l.Key(),
+	}
+	return
+}
+
+type SoundLoader struct {
+	path string
+}
+
+func NewSoundLoader(path string) *SoundLoader {
+	return &SoundLoader{
+		path: path,
+	}
+}
+
+func (l *SoundLoader) Key() ResourceKey {
+	return ResourceKey(fmt.Sprintf("sound:%v", l.path))
+}
+
+func (l *SoundLoader) Load(resources Resources) (res ResourceType, err error) {
+	var sound *audio.Sound
+	if sound, err = audio.LoadSound(l.path); err != nil {
+		return
+	}
+	res = SoundType{
+		Sound: sound,
+		key:   l.Key(),
+	}
+	return
+}
+
 type Resources interface {
 	Get(loader ResourceLoader) (res ResourceType, err error)
 	Release(key ResourceKey) (err error)
@@ -16,6 +16,7 @@ package gamejam
 
 import (
 	"fmt"
+	"github.com/pikkpoiss/gamejam/v1/base/audio"
 )
 
 type SceneManager interface {
@@ -27,10 +28,18 @@ type SceneManager interface {
 	Delete() (err error)
 }
 
+// Scenes implementing MusicScene declare the music to play while they are
+// the head of the scene list. Returning nil leaves the current music playing
+// and an empty playlist fades it out.
+type MusicScene interface {
+	Music() *audio.Playlist
+}
+
 type BaseSceneManager struct {
 	removelist []SceneID
 	scenelist  *SceneList
 	resources  Resources
+	music      *audio.MusicPlayer
 }
 
 func NewBaseSceneManager(res Resources, scenes ...Scene) (m *BaseSceneManager, err error) {
@@ -51,6 +60,38 @@ func (m *BaseSceneManager) Head() *SceneNode {
 	return m.scenelist.Head()
 }
 
+// Crossfades music with player's fade whenever the head scene declares a
+// different playlist.
+func (m *BaseSceneManager) SetMusicPlayer(player *audio.MusicPlayer) {
+	m.music = player
+}
+
+func (m *BaseSceneManager) MusicPlayer() *audio.MusicPlayer {
+	return m.music
+}
+
+func (m *BaseSceneManager) updateMusic() (err error) {
+	var (
+		head     = m.Head()
+		scene    MusicScene
+		ok       bool
+		playlist *audio.Playlist
+	)
+	if m.music == nil {
+		return
+	}
+	if head != nil {
+		if scene, ok = head.Scene.(MusicScene); ok {
+			if playlist = scene.Music(); playlist != nil {
+				if err = m.music.PlayPlaylist(playlist, m.music.Fade()); err != nil {
+					return
+				}
+			}
+		}
+	}
+	return m.music.Update()
+}
+
 func (m *BaseSceneManager) AddScene(s Scene) (err error) {
 	//BindSceneEventObserver(s, m)
 	if err = s.Load(m.resources); err != nil {
@@ -88,6 +129,7 @@ func (m *BaseSceneManager) Update() (err error) {
 		}
 		m.removelist = nil
 	}
+	err = m.updateMusic()
 	return
 }
 